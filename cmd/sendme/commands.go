@@ -0,0 +1,302 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/andremaeshaa/faspay-sendme-snap-go/snap"
+)
+
+// command is a subcommand. setup registers the flags of a command that calls the API
+// and returns the function that runs it once the flags are parsed, which may write
+// progress notes to stderr; offline does the same for a command that works without
+// the API.
+type command struct {
+	name    string
+	summary string
+	moves   bool // Moves money, so -yes is required in production
+	setup   func(set *flag.FlagSet, now func() time.Time, stderr io.Writer) func(ctx context.Context, client snap.Services) (result, error)
+	offline func(set *flag.FlagSet, now func() time.Time) func(cfg config, stdin io.Reader) (result, error)
+}
+
+var commands = []command{
+	{name: "inquiry", summary: "look up the name of a beneficiary account", setup: inquiryCommand},
+	{name: "transfer", summary: "transfer to another bank account", moves: true, setup: transferCommand},
+	{name: "status", summary: "get the status of a transfer", setup: statusCommand},
+	{name: "balance", summary: "get the balance of a source account", setup: balanceCommand},
+	{name: "history", summary: "list the transactions of a source account", setup: historyCommand},
+	{name: "topup", summary: "top up an e-money account", moves: true, setup: topupCommand},
+	{name: "topup-status", summary: "get the status of a top-up", setup: topupStatusCommand},
+	{name: "bill-inquiry", summary: "look up a bill by virtual account", setup: billInquiryCommand},
+	{name: "bill-pay", summary: "pay a bill", moves: true, setup: billPayCommand},
+	{name: "string-to-sign", summary: "show the minified body, its hash and the string to sign of a request", offline: stringToSignCommand},
+	{name: "sign", summary: "sign a request with the private key and show each step", offline: signCommand},
+	{name: "verify", summary: "verify the signature of a request or response and show each step", offline: verifyCommand},
+	{name: "keygen", summary: "create an RSA keypair to sign requests with", offline: keygenCommand},
+}
+
+func lookupCommand(name string) (command, bool) {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd, true
+		}
+	}
+	return command{}, false
+}
+
+// ensureReference fills in a generated partnerReferenceNo and writes it to stderr
+// before the request is sent, so that a transfer whose response never arrives can
+// still be looked up with the status commands
+func ensureReference(reference *string, now func() time.Time, stderr io.Writer) {
+	if *reference == "" {
+		*reference = newReference(now)
+		fmt.Fprintf(stderr, "partnerReferenceNo: %s\n", *reference)
+	}
+}
+
+// newReference returns a partnerReferenceNo made of the time in Jakarta down to the
+// millisecond, e.g. "20250609103003000"
+func newReference(now func() time.Time) string {
+	return strings.ReplaceAll(now().In(snap.Jakarta).Format("20060102150405.000"), ".", "")
+}
+
+// amountFlags registers -amount and -currency
+type amountFlags struct {
+	value    string
+	currency string
+}
+
+func (a *amountFlags) register(set *flag.FlagSet, name string) {
+	set.StringVar(&a.value, name, "", "amount, e.g. 10000 or 10000.00")
+	set.StringVar(&a.currency, "currency", snap.CurrencyIDR, "currency of the amount")
+}
+
+func (a *amountFlags) amount() (*snap.Amount, error) {
+	if a.value == "" {
+		return nil, nil // Reported by the request validation
+	}
+	money, err := snap.ParseMoney(a.value, a.currency)
+	if err != nil {
+		return nil, err
+	}
+	return money.Amount(), nil
+}
+
+func inquiryCommand(set *flag.FlagSet, now func() time.Time, _ io.Writer) func(context.Context, snap.Services) (result, error) {
+	request := &snap.ExternalAccountInquiryRequest{AdditionalInfo: &snap.AdditionalInfoInquiryAccount{}}
+	set.StringVar(&request.BeneficiaryBankCode, "bank-code", "", "beneficiary bank code, e.g. 008")
+	set.StringVar(&request.BeneficiaryAccountNo, "account-no", "", "beneficiary account number")
+	set.StringVar(&request.AdditionalInfo.SourceAccount, "source-account", "", "source account number")
+	set.StringVar(&request.PartnerReferenceNo, "reference", "", "partnerReferenceNo (default generated from the time)")
+
+	return func(ctx context.Context, client snap.Services) (result, error) {
+		if request.PartnerReferenceNo == "" {
+			request.PartnerReferenceNo = newReference(now)
+		}
+		response, err := client.AccountInquiry(ctx, request)
+		return result{value: response}, err
+	}
+}
+
+func transferCommand(set *flag.FlagSet, now func() time.Time, stderr io.Writer) func(context.Context, snap.Services) (result, error) {
+	request := &snap.TransferInterBankRequest{AdditionalInfo: &snap.AdditionalInfoTransferInterBank{}}
+	var amount amountFlags
+	set.StringVar(&request.PartnerReferenceNo, "reference", "", "partnerReferenceNo (default generated from the time)")
+	amount.register(set, "amount")
+	set.StringVar(&request.BeneficiaryBankCode, "bank-code", "", "beneficiary bank code, e.g. 008")
+	set.StringVar(&request.BeneficiaryAccountNo, "account-no", "", "beneficiary account number")
+	set.StringVar(&request.BeneficiaryAccountName, "account-name", "", "beneficiary account name")
+	set.StringVar(&request.BeneficiaryEmail, "email", "", "beneficiary email")
+	set.StringVar(&request.SourceAccountNo, "source-account", "", "source account number")
+	set.StringVar(&request.AdditionalInfo.TransactionDescription, "description", "", "transaction description")
+	set.StringVar(&request.AdditionalInfo.CallbackUrl, "callback-url", "", "URL notified of the final status")
+
+	return func(ctx context.Context, client snap.Services) (result, error) {
+		var err error
+		if request.Amount, err = amount.amount(); err != nil {
+			return result{}, err
+		}
+		ensureReference(&request.PartnerReferenceNo, now, stderr)
+		request.SetTransactionDate(now())
+		response, err := client.TransferInterBank(ctx, request)
+		return result{value: response}, err
+	}
+}
+
+func statusCommand(set *flag.FlagSet, _ func() time.Time, _ io.Writer) func(context.Context, snap.Services) (result, error) {
+	var partnerReferenceNo, referenceNo string
+	set.StringVar(&partnerReferenceNo, "reference", "", "partnerReferenceNo of the transfer")
+	set.StringVar(&referenceNo, "reference-no", "", "referenceNo Faspay returned for the transfer")
+
+	return func(ctx context.Context, client snap.Services) (result, error) {
+		response, err := client.StatusTransfer(ctx, snap.NewStatusTransferRequest(partnerReferenceNo, referenceNo))
+		return result{value: response}, err
+	}
+}
+
+func balanceCommand(set *flag.FlagSet, _ func() time.Time, _ io.Writer) func(context.Context, snap.Services) (result, error) {
+	request := &snap.InquiryBalanceRequest{}
+	set.StringVar(&request.AccountNo, "account-no", "", "source account number")
+
+	return func(ctx context.Context, client snap.Services) (result, error) {
+		response, err := client.InquiryBalance(ctx, request)
+		if err != nil {
+			return result{}, err
+		}
+		rows := make([]any, len(response.AccountInfos))
+		for i, info := range response.AccountInfos {
+			rows[i] = info
+		}
+		return result{value: response, rows: rows}, nil
+	}
+}
+
+func historyCommand(set *flag.FlagSet, now func() time.Time, _ io.Writer) func(context.Context, snap.Services) (result, error) {
+	var accountNo, from, to string
+	set.StringVar(&accountNo, "account-no", "", "source account number")
+	set.StringVar(&from, "from", "", "start of the range, a date or a SNAP timestamp (default today in Jakarta)")
+	set.StringVar(&to, "to", "", "end of the range, a date for the whole day or a SNAP timestamp (default now)")
+
+	return func(ctx context.Context, client snap.Services) (result, error) {
+		current := snap.NewTime(now())
+		start := time.Date(current.Year(), current.Month(), current.Day(), 0, 0, 0, 0, snap.Jakarta)
+		if from != "" {
+			t, err := snap.ParseTime(from)
+			if err != nil {
+				return result{}, err
+			}
+			start = t.Time
+		}
+		end := current.Time
+		if to != "" {
+			t, err := snap.ParseTime(to)
+			if err != nil {
+				return result{}, err
+			}
+			end = t.Time
+			if _, err := time.Parse(time.DateOnly, to); err == nil {
+				end = end.AddDate(0, 0, 1).Add(-time.Second)
+			}
+		}
+
+		entries := []*snap.DetailData{}
+		rows := []any{}
+		for entry, err := range snap.HistoryAll(ctx, client, &snap.HistoryAllRequest{AccountNo: accountNo, From: start, To: end}) {
+			if err != nil {
+				return result{}, err
+			}
+			entries = append(entries, entry)
+			rows = append(rows, entry)
+		}
+		return result{value: entries, rows: rows}, nil
+	}
+}
+
+func topupCommand(set *flag.FlagSet, now func() time.Time, stderr io.Writer) func(context.Context, snap.Services) (result, error) {
+	request := &snap.CustomerTopupRequest{AdditionalInfo: &snap.AdditionalInfoCustomerTopupRequest{}}
+	var amount amountFlags
+	var platform string
+	set.StringVar(&request.PartnerReferenceNo, "reference", "", "partnerReferenceNo (default generated from the time)")
+	amount.register(set, "amount")
+	set.StringVar(&request.CustomerNumber, "customer-number", "", "phone number of the e-money account")
+	set.StringVar(&platform, "platform", "", "e-money platform code: gpy, ovo, dna, spy or lja")
+	set.StringVar(&request.AdditionalInfo.SourceAccount, "source-account", "", "source account number")
+	set.StringVar(&request.AdditionalInfo.BeneficiaryEmail, "email", "", "beneficiary email")
+	set.StringVar(&request.AdditionalInfo.TransactionDescription, "description", "", "transaction description")
+	set.StringVar(&request.AdditionalInfo.CallbackUrl, "callback-url", "", "URL notified of the final status")
+
+	return func(ctx context.Context, client snap.Services) (result, error) {
+		var err error
+		if request.Amount, err = amount.amount(); err != nil {
+			return result{}, err
+		}
+		ensureReference(&request.PartnerReferenceNo, now, stderr)
+		request.AdditionalInfo.PlatformCode = strings.ToLower(platform)
+		request.SetTransactionDate(now())
+		response, err := client.CustomerTopup(ctx, request)
+		return result{value: response}, err
+	}
+}
+
+func topupStatusCommand(set *flag.FlagSet, _ func() time.Time, _ io.Writer) func(context.Context, snap.Services) (result, error) {
+	var partnerReferenceNo, referenceNo string
+	set.StringVar(&partnerReferenceNo, "reference", "", "partnerReferenceNo of the top-up")
+	set.StringVar(&referenceNo, "reference-no", "", "referenceNo Faspay returned for the top-up")
+
+	return func(ctx context.Context, client snap.Services) (result, error) {
+		response, err := client.CustomerTopupStatus(ctx, snap.NewCustomerTopupStatusRequest(partnerReferenceNo, referenceNo))
+		return result{value: response}, err
+	}
+}
+
+// virtualAccountFlags registers the flags identifying a virtual account
+type virtualAccountFlags struct {
+	partnerServiceID string
+	customerNo       string
+	virtualAccountNo string
+}
+
+func (va *virtualAccountFlags) register(set *flag.FlagSet) {
+	set.StringVar(&va.partnerServiceID, "partner-service-id", "", "partnerServiceId of the virtual account, padded to 8 characters")
+	set.StringVar(&va.customerNo, "customer-no", "", "customerNo of the virtual account")
+	set.StringVar(&va.virtualAccountNo, "virtual-account-no", "", "virtualAccountNo (default partner service ID followed by customer number)")
+}
+
+func (va *virtualAccountFlags) number() string {
+	if va.virtualAccountNo != "" {
+		return va.virtualAccountNo
+	}
+	return va.partnerServiceID + va.customerNo
+}
+
+func billInquiryCommand(set *flag.FlagSet, now func() time.Time, _ io.Writer) func(context.Context, snap.Services) (result, error) {
+	request := &snap.BillInquiryRequest{AdditionalInfo: &snap.AdditionalInfoBillInquiry{}}
+	var va virtualAccountFlags
+	set.StringVar(&request.PartnerReferenceNo, "reference", "", "partnerReferenceNo (default generated from the time)")
+	va.register(set)
+	set.StringVar(&request.AdditionalInfo.BillerCode, "biller-code", "", "biller code")
+	set.StringVar(&request.AdditionalInfo.SourceAccount, "source-account", "", "source account number")
+
+	return func(ctx context.Context, client snap.Services) (result, error) {
+		if request.PartnerReferenceNo == "" {
+			request.PartnerReferenceNo = newReference(now)
+		}
+		request.PartnerServiceId, request.CustomerNo, request.VirtualAccountNo = va.partnerServiceID, va.customerNo, va.number()
+		response, err := client.BillInquiry(ctx, request)
+		return result{value: response}, err
+	}
+}
+
+func billPayCommand(set *flag.FlagSet, now func() time.Time, stderr io.Writer) func(context.Context, snap.Services) (result, error) {
+	request := &snap.BillPaymentRequest{AdditionalInfo: &snap.AdditionalInfoBillPayment{}}
+	var va virtualAccountFlags
+	var amount amountFlags
+	set.StringVar(&request.PartnerReferenceNo, "reference", "", "partnerReferenceNo (default generated from the time)")
+	va.register(set)
+	set.StringVar(&request.VirtualAccountName, "virtual-account-name", "", "virtualAccountName returned by bill-inquiry")
+	amount.register(set, "amount")
+	set.StringVar(&request.SourceAccount, "source-account", "", "source account number")
+	set.StringVar(&request.AdditionalInfo.BillerCode, "biller-code", "", "biller code")
+	set.StringVar(&request.AdditionalInfo.CallbackUrl, "callback-url", "", "URL notified of the final status")
+
+	return func(ctx context.Context, client snap.Services) (result, error) {
+		var err error
+		if request.PaidAmount, err = amount.amount(); err != nil {
+			return result{}, err
+		}
+		ensureReference(&request.PartnerReferenceNo, now, stderr)
+		request.PartnerServiceId, request.CustomerNo, request.VirtualAccountNo = va.partnerServiceID, va.customerNo, va.number()
+		request.SetTrxDateTime(now())
+		response, err := client.BillPayment(ctx, request)
+		return result{value: response}, err
+	}
+}
+
+// errProductionConfirmation is returned for a command that moves money in production
+// without -yes
+var errProductionConfirmation = errors.New("this command moves money in production: pass -yes to confirm")
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/andremaeshaa/faspay-sendme-snap-go/snap"
+	"gopkg.in/yaml.v3"
+)
+
+// Environment variables read by every command. Flags take precedence over them and they
+// take precedence over the config file.
+const (
+	envConfig          = "SENDME_CONFIG"
+	envPartnerID       = "SENDME_PARTNER_ID"
+	envPrivateKey      = "SENDME_PRIVATE_KEY"
+	envSSLCert         = "SENDME_SSL_CERT"
+	envFaspayPublicKey = "SENDME_FASPAY_PUBLIC_KEY"
+	envEnvironment     = "SENDME_ENV"
+	envBaseURL         = "SENDME_BASE_URL"
+	envTimeout         = "SENDME_TIMEOUT"
+	envOutput          = "SENDME_OUTPUT"
+)
+
+// config holds the credentials and settings shared by every command. Key and
+// certificate fields are file paths.
+type config struct {
+	PartnerID       string        `yaml:"partnerId"`
+	PrivateKey      string        `yaml:"privateKey"`
+	SSLCert         string        `yaml:"sslCert"`
+	FaspayPublicKey string        `yaml:"faspayPublicKey"`
+	Environment     string        `yaml:"environment"`
+	BaseURL         string        `yaml:"baseUrl"`
+	Timeout         time.Duration `yaml:"timeout"`
+	Output          string        `yaml:"output"`
+	Debug           bool          `yaml:"debug"`
+}
+
+// globalFlags registers the settings every command accepts
+type globalFlags struct {
+	configPath string
+	flags      config
+}
+
+func (g *globalFlags) register(set *flag.FlagSet) {
+	set.StringVar(&g.configPath, "config", "", "config file (default $"+envConfig+" or sendme/config.yaml in the user config directory)")
+	set.StringVar(&g.flags.PartnerID, "partner-id", "", "partner ID (X-PARTNER-ID)")
+	set.StringVar(&g.flags.PrivateKey, "private-key", "", "path to the PEM private key requests are signed with")
+	set.StringVar(&g.flags.SSLCert, "ssl-cert", "", "path to the CA certificate of the API; the system roots are used when empty")
+	set.StringVar(&g.flags.FaspayPublicKey, "faspay-public-key", "", "path to Faspay's public key, to verify response signatures")
+	set.StringVar(&g.flags.Environment, "env", "", "environment: dev, sandbox or prod")
+	set.StringVar(&g.flags.BaseURL, "base-url", "", "API base URL, overriding the environment's host")
+	set.DurationVar(&g.flags.Timeout, "timeout", 0, "HTTP timeout (default 30s)")
+	set.StringVar(&g.flags.Output, "o", "", "output format: json, table or csv (default table)")
+	set.StringVar(&g.flags.Output, "output", "", "same as -o")
+	set.BoolVar(&g.flags.Debug, "debug", false, "log requests and responses to stderr, with sensitive values masked")
+}
+
+// resolve merges the config file, the environment and the flags given on the command
+// line, in increasing order of precedence
+func (g *globalFlags) resolve(set *flag.FlagSet, getenv func(string) string) (config, error) {
+	cfg, err := loadConfig(g.configPath, getenv)
+	if err != nil {
+		return config{}, err
+	}
+
+	for name, value := range map[string]*string{
+		envPartnerID:       &cfg.PartnerID,
+		envPrivateKey:      &cfg.PrivateKey,
+		envSSLCert:         &cfg.SSLCert,
+		envFaspayPublicKey: &cfg.FaspayPublicKey,
+		envEnvironment:     &cfg.Environment,
+		envBaseURL:         &cfg.BaseURL,
+		envOutput:          &cfg.Output,
+	} {
+		if v := getenv(name); v != "" {
+			*value = v
+		}
+	}
+	if v := getenv(envTimeout); v != "" {
+		timeout, err := time.ParseDuration(v)
+		if err != nil {
+			return config{}, fmt.Errorf("%s: %w", envTimeout, err)
+		}
+		cfg.Timeout = timeout
+	}
+
+	set.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "partner-id":
+			cfg.PartnerID = g.flags.PartnerID
+		case "private-key":
+			cfg.PrivateKey = g.flags.PrivateKey
+		case "ssl-cert":
+			cfg.SSLCert = g.flags.SSLCert
+		case "faspay-public-key":
+			cfg.FaspayPublicKey = g.flags.FaspayPublicKey
+		case "env":
+			cfg.Environment = g.flags.Environment
+		case "base-url":
+			cfg.BaseURL = g.flags.BaseURL
+		case "timeout":
+			cfg.Timeout = g.flags.Timeout
+		case "o", "output":
+			cfg.Output = g.flags.Output
+		case "debug":
+			cfg.Debug = g.flags.Debug
+		}
+	})
+
+	if cfg.Output == "" {
+		cfg.Output = formatTable
+	}
+	if !isFormat(cfg.Output) {
+		return config{}, fmt.Errorf("unknown output format %q: expected json, table or csv", cfg.Output)
+	}
+	return cfg, nil
+}
+
+// loadConfig reads the config file at path, or at the default location when path and
+// SENDME_CONFIG are empty. A missing default file is not an error.
+func loadConfig(path string, getenv func(string) string) (config, error) {
+	if path == "" {
+		path = getenv(envConfig)
+	}
+	explicit := path != ""
+	if !explicit {
+		dir, err := os.UserConfigDir()
+		if err != nil {
+			return config{}, nil
+		}
+		path = filepath.Join(dir, "sendme", "config.yaml")
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) && !explicit {
+		return config{}, nil
+	}
+	if err != nil {
+		return config{}, fmt.Errorf("error reading config: %w", err)
+	}
+
+	var cfg config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return config{}, fmt.Errorf("error parsing config %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// isProduction reports whether the config targets production, either by environment
+// or by a base URL on the production host
+func (cfg config) isProduction() bool {
+	if env, err := snap.ParseEnvironment(cfg.Environment); err == nil && env.IsProduction() {
+		return true
+	}
+	if cfg.BaseURL == "" {
+		return false
+	}
+	target, err := url.Parse(cfg.BaseURL)
+	if err != nil {
+		return false
+	}
+	production, _ := url.Parse(snap.Production.BaseURL())
+	return strings.EqualFold(target.Hostname(), production.Hostname())
+}
+
+// newClient creates the client described by cfg
+func (cfg config) newClient(stderr io.Writer) (snap.Services, error) {
+	if cfg.PartnerID == "" {
+		return nil, fmt.Errorf("partner ID is required: use -partner-id, $%s or partnerId in the config file", envPartnerID)
+	}
+	if cfg.PrivateKey == "" {
+		return nil, fmt.Errorf("private key is required: use -private-key, $%s or privateKey in the config file", envPrivateKey)
+	}
+	privateKey, err := os.ReadFile(cfg.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("error reading private key: %w", err)
+	}
+
+	var options []snap.ClientOption
+	var sslCert []byte
+	if cfg.SSLCert != "" {
+		if sslCert, err = os.ReadFile(cfg.SSLCert); err != nil {
+			return nil, fmt.Errorf("error reading SSL certificate: %w", err)
+		}
+	} else {
+		options = append(options, snap.WithHTTPClient(&http.Client{}))
+	}
+	if cfg.Environment != "" {
+		env, err := snap.ParseEnvironment(cfg.Environment)
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, snap.WithEnvironment(env))
+	}
+	if cfg.BaseURL != "" {
+		options = append(options, snap.WithBaseURL(cfg.BaseURL))
+	}
+	if cfg.Timeout > 0 {
+		options = append(options, snap.WithTimeout(cfg.Timeout))
+	} else {
+		options = append(options, snap.WithTimeout(time.Duration(snap.DefaultTimeout)*time.Second))
+	}
+	if cfg.FaspayPublicKey != "" {
+		publicKey, err := os.ReadFile(cfg.FaspayPublicKey)
+		if err != nil {
+			return nil, fmt.Errorf("error reading Faspay public key: %w", err)
+		}
+		options = append(options, snap.WithFaspayPublicKey(publicKey))
+	}
+	if cfg.Debug {
+		options = append(options, snap.WithLogger(slog.New(slog.NewTextHandler(stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))))
+	}
+
+	return snap.NewClient(cfg.PartnerID, privateKey, sslCert, options...)
+}
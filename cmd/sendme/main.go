@@ -0,0 +1,144 @@
+// Command sendme calls the Faspay SendMe SNAP API from a shell, for checking a
+// beneficiary, a balance or a stuck transfer without writing Go code.
+//
+//	sendme <command> [flags]
+//
+// Commands: inquiry, transfer, status, balance, history, topup, topup-status,
+// bill-inquiry and bill-pay call the API. string-to-sign, sign and verify show each
+// value a signature is computed from, to debug "Unauthorized. Signature" errors, and
+// keygen creates the keypair requests are signed with. Run "sendme <command> -h" for
+// the flags of a command.
+//
+// Credentials and settings are read from flags, then from SENDME_* environment
+// variables, then from a YAML config file (-config, $SENDME_CONFIG or
+// sendme/config.yaml in the user config directory):
+//
+//	partnerId: "99999"
+//	privateKey: /etc/sendme/enc.key
+//	sslCert: /etc/sendme/faspay.crt
+//	environment: sandbox
+//	timeout: 60s
+//	output: table
+//
+// Results are printed as a table (the default), JSON (-o json) or CSV (-o csv).
+// Commands that move money in production require -yes.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/andremaeshaa/faspay-sendme-snap-go/snap"
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	os.Exit(run(ctx, os.Args[1:], os.Stdin, os.Stdout, os.Stderr, os.Getenv, time.Now))
+}
+
+// Exit codes
+const (
+	exitOK    = 0
+	exitError = 1 // The request failed
+	exitUsage = 2 // Invalid command line or configuration
+)
+
+// run runs the command in args and returns the exit code
+func run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer, getenv func(string) string, now func() time.Time) int {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "-help" || args[0] == "--help" || args[0] == "help" {
+		usage(stderr)
+		if len(args) == 0 {
+			return exitUsage
+		}
+		return exitOK
+	}
+	if args[0] == "version" {
+		fmt.Fprintln(stdout, "sendme", snap.Version)
+		return exitOK
+	}
+
+	cmd, ok := lookupCommand(args[0])
+	if !ok {
+		fmt.Fprintf(stderr, "sendme: unknown command %q\n\n", args[0])
+		usage(stderr)
+		return exitUsage
+	}
+
+	set := flag.NewFlagSet("sendme "+cmd.name, flag.ContinueOnError)
+	set.SetOutput(stderr)
+	var global globalFlags
+	global.register(set)
+	var confirmed bool
+	if cmd.moves {
+		set.BoolVar(&confirmed, "yes", false, "confirm a command that moves money in production")
+	}
+	var execute func(context.Context, snap.Services) (result, error)
+	var executeOffline func(config, io.Reader) (result, error)
+	if cmd.offline != nil {
+		executeOffline = cmd.offline(set, now)
+	} else {
+		execute = cmd.setup(set, now, stderr)
+	}
+	if err := set.Parse(args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+	if set.NArg() > 0 {
+		fmt.Fprintf(stderr, "sendme %s: unexpected arguments %q\n", cmd.name, set.Args())
+		return exitUsage
+	}
+
+	cfg, err := global.resolve(set, getenv)
+	if err != nil {
+		fmt.Fprintf(stderr, "sendme %s: %v\n", cmd.name, err)
+		return exitUsage
+	}
+	if cmd.moves && cfg.isProduction() && !confirmed {
+		fmt.Fprintf(stderr, "sendme %s: %v\n", cmd.name, errProductionConfirmation)
+		return exitUsage
+	}
+
+	var res result
+	if executeOffline != nil {
+		res, err = executeOffline(cfg, stdin)
+	} else {
+		client, clientErr := cfg.newClient(stderr)
+		if clientErr != nil {
+			fmt.Fprintf(stderr, "sendme %s: %v\n", cmd.name, clientErr)
+			return exitUsage
+		}
+		res, err = execute(ctx, client)
+	}
+
+	// A failed verification still prints the values it was computed from
+	if res.value != nil || res.rows != nil {
+		if renderErr := render(stdout, cfg.Output, res); renderErr != nil && err == nil {
+			err = renderErr
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "sendme %s: %v\n", cmd.name, err)
+		return exitError
+	}
+	return exitOK
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: sendme <command> [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-14s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, `Run "sendme <command> -h" for the flags of a command.`)
+}
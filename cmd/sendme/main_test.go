@@ -0,0 +1,201 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andremaeshaa/faspay-sendme-snap-go/snap"
+	"github.com/andremaeshaa/faspay-sendme-snap-go/snap/snaptest"
+)
+
+// testTime is the fixed clock shared by the test server and the commands
+var testTime = time.Date(2025, 6, 9, 3, 30, 3, 0, time.UTC)
+
+func writeFile(t *testing.T, name string, data []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// sendme runs the CLI with env as its environment and no config file
+func sendme(t *testing.T, env map[string]string, args ...string) (stdout, stderr string, code int) {
+	t.Helper()
+	return sendmeInput(t, env, "", args...)
+}
+
+// sendmeInput runs the CLI with stdin as its standard input
+func sendmeInput(t *testing.T, env map[string]string, stdin string, args ...string) (stdout, stderr string, code int) {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	var out, errOut bytes.Buffer
+	code = run(context.Background(), args, strings.NewReader(stdin), &out, &errOut, func(key string) string { return env[key] }, func() time.Time { return testTime })
+	return out.String(), errOut.String(), code
+}
+
+// TestCommands tests every command against the fake server in each output format
+func TestCommands(t *testing.T) {
+	server := snaptest.NewServer(
+		snaptest.WithClock(func() time.Time { return testTime }),
+		snaptest.WithAccount("9920017573", snap.MustIDR(1_000_000)),
+		snaptest.WithBeneficiary("008", "60004400184", "John Doe"),
+		snaptest.WithVirtualAccount(snaptest.VirtualAccount{PartnerServiceID: "   12345", CustomerNo: "0001", Name: "PLN Postpaid", Amount: snap.MustIDR(100_000)}),
+	)
+	defer server.Close()
+	env := map[string]string{
+		envPartnerID:       snaptest.DefaultPartnerID,
+		envPrivateKey:      writeFile(t, "enc.key", snaptest.PartnerPrivateKey()),
+		envFaspayPublicKey: writeFile(t, "faspay.pem", server.FaspayPublicKey()),
+		envBaseURL:         server.URL,
+	}
+
+	stdout, stderr, code := sendme(t, env, "inquiry", "-bank-code", "008", "-account-no", "60004400184", "-source-account", "9920017573", "-o", "json")
+	var inquiry snap.ExternalAccountInquiryResponse
+	if code != exitOK || json.Unmarshal([]byte(stdout), &inquiry) != nil || inquiry.BeneficiaryAccountName != "John Doe" {
+		t.Fatalf("inquiry exited with %d: %s%s", code, stdout, stderr)
+	}
+	if inquiry.PartnerReferenceNo != "20250609103003000" {
+		t.Errorf("Expected a reference generated from the time, got %q", inquiry.PartnerReferenceNo)
+	}
+
+	stdout, stderr, code = sendme(t, env, "transfer", "-reference", "TRX0001", "-amount", "250000",
+		"-bank-code", "008", "-account-no", "60004400184", "-account-name", "John Doe", "-source-account", "9920017573")
+	if code != exitOK || !strings.Contains(stdout, "additionalInfo.latestTransactionStatus  00\n") {
+		t.Fatalf("transfer exited with %d: %s%s", code, stdout, stderr)
+	}
+
+	stdout, stderr, code = sendme(t, env, "status", "-reference", "TRX0001", "-o", "csv")
+	records, err := csv.NewReader(strings.NewReader(stdout)).ReadAll()
+	if code != exitOK || err != nil || len(records) != 2 {
+		t.Fatalf("status exited with %d: %s%s", code, stdout, stderr)
+	}
+	for i, column := range records[0] {
+		if column == "latestTransactionStatus" && records[1][i] != "00" {
+			t.Errorf("Expected a successful status, got %q", records[1][i])
+		}
+	}
+
+	stdout, stderr, code = sendme(t, env, "balance", "-account-no", "9920017573")
+	if code != exitOK || !strings.HasPrefix(stdout, "BALANCETYPE") || !strings.Contains(stdout, "750000.00") {
+		t.Fatalf("balance exited with %d: %s%s", code, stdout, stderr)
+	}
+
+	stdout, stderr, code = sendme(t, env, "history", "-account-no", "9920017573", "-from", "2025-06-09", "-to", "2025-06-09", "-o", "csv")
+	records, err = csv.NewReader(strings.NewReader(stdout)).ReadAll()
+	if code != exitOK || err != nil || len(records) != 2 {
+		t.Fatalf("history exited with %d: %s%s", code, stdout, stderr)
+	}
+
+	stdout, stderr, code = sendme(t, env, "topup", "-reference", "TOP0001", "-amount", "50000", "-customer-number", "081234567890",
+		"-platform", "OVO", "-source-account", "9920017573", "-o", "json")
+	if code != exitOK {
+		t.Fatalf("topup exited with %d: %s%s", code, stdout, stderr)
+	}
+	stdout, stderr, code = sendme(t, env, "topup-status", "-reference", "TOP0001")
+	if code != exitOK || !strings.Contains(stdout, "customerNumber") {
+		t.Fatalf("topup-status exited with %d: %s%s", code, stdout, stderr)
+	}
+
+	stdout, stderr, code = sendme(t, env, "bill-inquiry", "-partner-service-id", "   12345", "-customer-no", "0001", "-source-account", "9920017573")
+	if code != exitOK || !strings.Contains(stdout, "PLN Postpaid") {
+		t.Fatalf("bill-inquiry exited with %d: %s%s", code, stdout, stderr)
+	}
+	stdout, stderr, code = sendme(t, env, "bill-pay", "-reference", "BILL0001", "-partner-service-id", "   12345", "-customer-no", "0001",
+		"-virtual-account-name", "PLN Postpaid", "-amount", "100000", "-source-account", "9920017573")
+	if code != exitOK {
+		t.Fatalf("bill-pay exited with %d: %s%s", code, stdout, stderr)
+	}
+
+	if balance, _ := server.Balance("9920017573"); !balance.Equal(snap.MustIDR(600_000)) {
+		t.Errorf("Balance = %v, expected 600000.00 IDR", balance)
+	}
+}
+
+// TestErrors tests the exit codes of failed requests and invalid command lines
+func TestErrors(t *testing.T) {
+	server := snaptest.NewServer(snaptest.WithClock(func() time.Time { return testTime }))
+	defer server.Close()
+	env := map[string]string{
+		envPartnerID:  snaptest.DefaultPartnerID,
+		envPrivateKey: writeFile(t, "enc.key", snaptest.PartnerPrivateKey()),
+		envBaseURL:    server.URL,
+	}
+
+	if _, stderr, code := sendme(t, env, "status", "-reference", "TRX9999"); code != exitError || !strings.Contains(stderr, "404") {
+		t.Errorf("Expected an API error, got %d: %s", code, stderr)
+	}
+	if _, stderr, code := sendme(t, env, "transfer", "-amount", "10000", "-env", "prod"); code != exitUsage || !strings.Contains(stderr, "-yes") {
+		t.Errorf("Expected a production transfer to need -yes, got %d: %s", code, stderr)
+	}
+	if _, stderr, code := sendme(t, env, "transfer", "-amount", "10000", "-base-url", snap.Production.BaseURL()+"/"); code != exitUsage || !strings.Contains(stderr, "-yes") {
+		t.Errorf("Expected a transfer to the production host to need -yes, got %d: %s", code, stderr)
+	}
+	if len(server.Requests()) != 1 {
+		t.Errorf("Expected 1 request, got %d", len(server.Requests()))
+	}
+
+	for _, args := range [][]string{
+		{},
+		{"refund"},
+		{"status", "-unknown"},
+		{"status", "TRX0001"},
+		{"status", "-o", "xml"},
+		{"balance", "-partner-id", ""},
+	} {
+		if _, _, code := sendme(t, env, args...); code != exitUsage {
+			t.Errorf("Expected exit code %d for %q, got %d", exitUsage, args, code)
+		}
+	}
+
+	// A failed transfer still tells the operator which reference to check the status of
+	failing := snaptest.NewServer(
+		snaptest.WithClock(func() time.Time { return testTime }),
+		snaptest.WithAccount("9920017573", snap.MustIDR(1_000_000)),
+		snaptest.WithBeneficiary("008", "60004400184", "John Doe"),
+		snaptest.WithFaults(snaptest.Fault{Kind: snaptest.FaultServerError, Endpoint: "transfer"}),
+	)
+	defer failing.Close()
+	env[envBaseURL] = failing.URL
+	_, stderr, code := sendme(t, env, "transfer", "-amount", "250000",
+		"-bank-code", "008", "-account-no", "60004400184", "-account-name", "John Doe", "-source-account", "9920017573")
+	if code != exitError || !strings.Contains(stderr, "partnerReferenceNo: 20250609103003000\n") {
+		t.Errorf("Expected the generated reference on stderr of a failed transfer, got %d: %s", code, stderr)
+	}
+}
+
+// TestResolveConfig tests that flags override the environment, which overrides the
+// config file
+func TestResolveConfig(t *testing.T) {
+	path := writeFile(t, "config.yaml", []byte("partnerId: \"11111\"\nprivateKey: /etc/sendme/enc.key\ntimeout: 5s\noutput: csv\nenvironment: sandbox\n"))
+	env := map[string]string{envConfig: path, envPartnerID: "22222", envOutput: "json"}
+
+	set := flag.NewFlagSet("sendme", flag.ContinueOnError)
+	var global globalFlags
+	global.register(set)
+	if err := set.Parse([]string{"-partner-id", "33333"}); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := global.resolve(set, func(key string) string { return env[key] })
+	if err != nil {
+		t.Fatalf("resolve failed: %v", err)
+	}
+	expected := config{PartnerID: "33333", PrivateKey: "/etc/sendme/enc.key", Environment: "sandbox", Timeout: 5 * time.Second, Output: formatJSON}
+	if cfg != expected {
+		t.Errorf("resolve = %+v, expected %+v", cfg, expected)
+	}
+
+	if _, err := loadConfig(filepath.Join(t.TempDir(), "missing.yaml"), func(string) string { return "" }); err == nil {
+		t.Error("Expected an error for a missing config file")
+	}
+}
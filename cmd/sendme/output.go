@@ -0,0 +1,185 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// Output formats
+const (
+	formatJSON  = "json"
+	formatTable = "table"
+	formatCSV   = "csv"
+)
+
+func isFormat(format string) bool {
+	return format == formatJSON || format == formatTable || format == formatCSV
+}
+
+// result is what a command prints: value as a whole in JSON, and rows, or value as a
+// single row when rows is nil, in table and CSV
+type result struct {
+	value any
+	rows  []any
+}
+
+// field is a flattened JSON value, keyed by its dotted path such as "amount.value"
+type field struct {
+	key   string
+	value string
+}
+
+// render writes res in format
+func render(w io.Writer, format string, res result) error {
+	if format == formatJSON {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(res.value)
+	}
+
+	rows := res.rows
+	if rows == nil {
+		rows = []any{res.value}
+	}
+	flattened := make([][]field, len(rows))
+	for i, row := range rows {
+		fields, err := flatten(row)
+		if err != nil {
+			return err
+		}
+		flattened[i] = fields
+	}
+
+	if format == formatCSV {
+		return writeCSV(w, flattened)
+	}
+	if res.rows == nil {
+		return writeRecord(w, flattened[0])
+	}
+	return writeTable(w, flattened)
+}
+
+// writeRecord prints the non-empty fields of a single record as key-value lines
+func writeRecord(w io.Writer, fields []field) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, f := range fields {
+		if f.value != "" {
+			fmt.Fprintf(tw, "%s\t%s\n", f.key, f.value)
+		}
+	}
+	return tw.Flush()
+}
+
+// writeTable prints rows as aligned columns
+func writeTable(w io.Writer, rows [][]field) error {
+	if len(rows) == 0 {
+		_, err := fmt.Fprintln(w, "No records")
+		return err
+	}
+	columns := columnsOf(rows)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.ToUpper(strings.Join(columns, "\t")))
+	for _, row := range rows {
+		fmt.Fprintln(tw, strings.Join(valuesOf(row, columns), "\t"))
+	}
+	return tw.Flush()
+}
+
+// writeCSV prints a header of every field path followed by one line per row
+func writeCSV(w io.Writer, rows [][]field) error {
+	columns := columnsOf(rows)
+	cw := csv.NewWriter(w)
+	if err := cw.Write(columns); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		if err := cw.Write(valuesOf(row, columns)); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// columnsOf returns the field paths of rows in the order they first appear
+func columnsOf(rows [][]field) []string {
+	var columns []string
+	for _, row := range rows {
+		for _, f := range row {
+			if !slices.Contains(columns, f.key) {
+				columns = append(columns, f.key)
+			}
+		}
+	}
+	return columns
+}
+
+func valuesOf(row []field, columns []string) []string {
+	values := make([]string, len(columns))
+	for _, f := range row {
+		values[slices.Index(columns, f.key)] = f.value
+	}
+	return values
+}
+
+// flatten returns the leaves of the JSON encoding of v in document order. Nested
+// objects are joined with dots and array elements are indexed, e.g. "accountInfos[0].status".
+func flatten(v any) ([]field, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var fields []field
+	if err := flattenValue(decoder, "", &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+func flattenValue(decoder *json.Decoder, path string, fields *[]field) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+
+	switch value := token.(type) {
+	case json.Delim:
+		for i := 0; decoder.More(); i++ {
+			child := fmt.Sprintf("%s[%d]", path, i)
+			if value == '{' {
+				key, err := decoder.Token()
+				if err != nil {
+					return err
+				}
+				child = key.(string)
+				if path != "" {
+					child = path + "." + child
+				}
+			}
+			if err := flattenValue(decoder, child, fields); err != nil {
+				return err
+			}
+		}
+		_, err := decoder.Token() // Closing delimiter
+		return err
+	case string:
+		*fields = append(*fields, field{path, value})
+	case json.Number:
+		*fields = append(*fields, field{path, value.String()})
+	case bool:
+		*fields = append(*fields, field{path, strconv.FormatBool(value)})
+	case nil:
+		*fields = append(*fields, field{path, ""})
+	}
+	return nil
+}
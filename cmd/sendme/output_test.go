@@ -0,0 +1,39 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+// TestRender tests the table and CSV layouts of single records and lists
+func TestRender(t *testing.T) {
+	type info struct {
+		Status string   `json:"status"`
+		Tags   []string `json:"tags"`
+		Note   *string  `json:"note"`
+	}
+	record := struct {
+		Code string `json:"code"`
+		Info *info  `json:"info"`
+	}{Code: "2001800", Info: &info{Tags: []string{"a", "b,c"}}}
+
+	for _, test := range []struct {
+		format   string
+		res      result
+		expected string
+	}{
+		{formatTable, result{value: record}, "code          2001800\ninfo.tags[0]  a\ninfo.tags[1]  b,c\n"},
+		{formatCSV, result{value: record}, "code,info.status,info.tags[0],info.tags[1],info.note\n2001800,,a,\"b,c\",\n"},
+		{formatTable, result{rows: []any{map[string]string{"a": "1"}, map[string]string{"b": "22"}}}, "A  B\n1  \n   22\n"},
+		{formatTable, result{value: []int{}, rows: []any{}}, "No records\n"},
+		{formatJSON, result{value: map[string]int{"a": 1}}, "{\n  \"a\": 1\n}\n"},
+	} {
+		var out bytes.Buffer
+		if err := render(&out, test.format, test.res); err != nil {
+			t.Fatalf("render failed: %v", err)
+		}
+		if out.String() != test.expected {
+			t.Errorf("render(%s) = %q, expected %q", test.format, out.String(), test.expected)
+		}
+	}
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/andremaeshaa/faspay-sendme-snap-go/snap"
+	"github.com/andremaeshaa/faspay-sendme-snap-go/snap/signing"
+)
+
+// endpointPaths lets -path name an endpoint by its command
+var endpointPaths = map[string]string{
+	"inquiry":      snap.EndpointAccountInquiry,
+	"transfer":     snap.EndpointTransferInterbank,
+	"status":       snap.EndpointInquiryStatus,
+	"balance":      snap.EndpointInquiryBalance,
+	"history":      snap.EndpointHistoryList,
+	"topup":        snap.EndpointCustomerTopup,
+	"topup-status": snap.EndpointCustomerTopupStatus,
+	"bill-inquiry": snap.EndpointBillInquiry,
+	"bill-pay":     snap.EndpointBillPayment,
+}
+
+// requestFlags registers the flags describing the request a signature is computed for
+type requestFlags struct {
+	method    string
+	path      string
+	timestamp string
+	body      string
+	data      string
+}
+
+func (r *requestFlags) register(set *flag.FlagSet) {
+	set.StringVar(&r.method, "method", http.MethodPost, "HTTP method")
+	set.StringVar(&r.path, "path", "", "endpoint path, e.g. "+snap.EndpointTransferInterbank+", or a command name such as transfer")
+	set.StringVar(&r.timestamp, "timestamp", "", "X-TIMESTAMP (default now)")
+	set.StringVar(&r.body, "body", "", `file holding the request body, or "-" for stdin`)
+	set.StringVar(&r.data, "data", "", "request body")
+}
+
+// trace computes the intermediate values of the signature of the request
+func (r *requestFlags) trace(stdin io.Reader, now func() time.Time) (*signing.Trace, error) {
+	if r.path == "" {
+		return nil, errors.New("-path is required")
+	}
+	path := r.path
+	if endpoint, ok := endpointPaths[path]; ok {
+		path = endpoint
+	}
+	if r.timestamp == "" {
+		r.timestamp = snap.NewTime(now()).String()
+	}
+
+	body := []byte(r.data)
+	switch {
+	case r.data != "" && r.body != "":
+		return nil, errors.New("-body and -data are mutually exclusive")
+	case r.body == "-":
+		var err error
+		if body, err = io.ReadAll(stdin); err != nil {
+			return nil, fmt.Errorf("error reading body: %w", err)
+		}
+	case r.body != "":
+		var err error
+		if body, err = os.ReadFile(r.body); err != nil {
+			return nil, fmt.Errorf("error reading body: %w", err)
+		}
+	}
+	return signing.NewTrace(strings.ToUpper(r.method), path, body, r.timestamp), nil
+}
+
+func stringToSignCommand(set *flag.FlagSet, now func() time.Time) func(config, io.Reader) (result, error) {
+	var request requestFlags
+	request.register(set)
+
+	return func(_ config, stdin io.Reader) (result, error) {
+		trace, err := request.trace(stdin, now)
+		if err != nil {
+			return result{}, err
+		}
+		return result{value: trace}, nil
+	}
+}
+
+func signCommand(set *flag.FlagSet, now func() time.Time) func(config, io.Reader) (result, error) {
+	var request requestFlags
+	request.register(set)
+
+	return func(cfg config, stdin io.Reader) (result, error) {
+		trace, err := request.trace(stdin, now)
+		if err != nil {
+			return result{}, err
+		}
+		if cfg.PrivateKey == "" {
+			return result{}, fmt.Errorf("private key is required: use -private-key, $%s or privateKey in the config file", envPrivateKey)
+		}
+		privateKeyPEM, err := os.ReadFile(cfg.PrivateKey)
+		if err != nil {
+			return result{}, fmt.Errorf("error reading private key: %w", err)
+		}
+		privateKey, err := snap.ParsePrivateKey(privateKeyPEM)
+		if err != nil {
+			return result{}, fmt.Errorf("error loading private key: %w", err)
+		}
+		if err := trace.Sign(privateKey); err != nil {
+			return result{}, err
+		}
+		return result{value: trace}, nil
+	}
+}
+
+// verification is the output of verify
+type verification struct {
+	*signing.Trace
+	Verified bool `json:"verified"`
+}
+
+func verifyCommand(set *flag.FlagSet, now func() time.Time) func(config, io.Reader) (result, error) {
+	var request requestFlags
+	var publicKeyPath, signature string
+	request.register(set)
+	set.StringVar(&signature, "signature", "", "X-SIGNATURE to verify")
+	set.StringVar(&publicKeyPath, "public-key", "", "public key or certificate the signature is verified with (default the Faspay public key)")
+
+	return func(cfg config, stdin io.Reader) (result, error) {
+		if signature == "" {
+			return result{}, errors.New("-signature is required")
+		}
+		if publicKeyPath == "" {
+			publicKeyPath = cfg.FaspayPublicKey
+		}
+		if publicKeyPath == "" {
+			return result{}, fmt.Errorf("public key is required: use -public-key, -faspay-public-key or $%s", envFaspayPublicKey)
+		}
+		publicKeyPEM, err := os.ReadFile(publicKeyPath)
+		if err != nil {
+			return result{}, fmt.Errorf("error reading public key: %w", err)
+		}
+		publicKey, err := snap.ParsePublicKey(publicKeyPEM)
+		if err != nil {
+			return result{}, err
+		}
+		trace, err := request.trace(stdin, now)
+		if err != nil {
+			return result{}, err
+		}
+
+		err = trace.Verify(publicKey, signature)
+		return result{value: verification{Trace: trace, Verified: err == nil}}, err
+	}
+}
+
+// keyPair is the output of keygen
+type keyPair struct {
+	PrivateKey  string `json:"privateKey"`
+	PublicKey   string `json:"publicKey"`
+	Bits        int    `json:"bits"`
+	Fingerprint string `json:"fingerprint"` // SHA-256 of the public key
+}
+
+func keygenCommand(set *flag.FlagSet, _ func() time.Time) func(config, io.Reader) (result, error) {
+	var bits int
+	var privateOut, publicOut string
+	set.IntVar(&bits, "bits", signing.KeySize2048, "key size: 2048 or 4096")
+	set.StringVar(&privateOut, "out", "private_key.pem", "file the PKCS#8 private key is written to")
+	set.StringVar(&publicOut, "public-out", "public_key.pem", "file the public key to upload to the Faspay dashboard is written to")
+
+	return func(config, io.Reader) (result, error) {
+		key, err := signing.GenerateKey(bits)
+		if err != nil {
+			return result{}, err
+		}
+		privatePEM, err := signing.EncodePrivateKey(key)
+		if err != nil {
+			return result{}, err
+		}
+		publicPEM, err := signing.EncodePublicKey(&key.PublicKey)
+		if err != nil {
+			return result{}, err
+		}
+		fingerprint, err := signing.Fingerprint(&key.PublicKey)
+		if err != nil {
+			return result{}, err
+		}
+
+		// Never overwrite a key: a replaced private key cannot be recovered
+		if err := writeNewFile(privateOut, privatePEM, 0o600); err != nil {
+			return result{}, err
+		}
+		if err := writeNewFile(publicOut, publicPEM, 0o644); err != nil {
+			_ = os.Remove(privateOut)
+			return result{}, err
+		}
+		return result{value: keyPair{PrivateKey: privateOut, PublicKey: publicOut, Bits: bits, Fingerprint: fingerprint}}, nil
+	}
+}
+
+// writeNewFile writes data to path, failing when the file exists
+func writeNewFile(path string, data []byte, perm os.FileMode) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if errors.Is(err, fs.ErrExist) {
+		return fmt.Errorf("%s already exists", path)
+	}
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andremaeshaa/faspay-sendme-snap-go/snap"
+	"github.com/andremaeshaa/faspay-sendme-snap-go/snap/signing"
+	"github.com/andremaeshaa/faspay-sendme-snap-go/snap/snaptest"
+)
+
+// TestKeygenSignVerify tests that a generated key signs requests that verify with its
+// public key, and that a mismatch still prints each step
+func TestKeygenSignVerify(t *testing.T) {
+	dir := t.TempDir()
+	privatePath, publicPath := filepath.Join(dir, "enc.key"), filepath.Join(dir, "enc.pub")
+	keygen := []string{"keygen", "-bits", "2048", "-out", privatePath, "-public-out", publicPath, "-o", "json"}
+
+	stdout, stderr, code := sendme(t, nil, keygen...)
+	var pair keyPair
+	if code != exitOK || json.Unmarshal([]byte(stdout), &pair) != nil || len(pair.Fingerprint) != 64 {
+		t.Fatalf("keygen exited with %d: %s%s", code, stdout, stderr)
+	}
+	if info, err := os.Stat(privatePath); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("Expected a private key only readable by its owner, got %v, %v", info, err)
+	}
+	if _, stderr, code := sendme(t, nil, keygen...); code != exitError || !strings.Contains(stderr, "already exists") {
+		t.Errorf("Expected keygen to refuse to overwrite a key, got %d: %s", code, stderr)
+	}
+
+	env := map[string]string{envPrivateKey: privatePath}
+	request := []string{"-path", "balance", "-data", `{ "accountNo": "9920017573" }`, "-timestamp", "2025-06-09T10:30:03+07:00", "-o", "json"}
+	stdout, stderr, code = sendme(t, env, append([]string{"sign"}, request...)...)
+	var trace signing.Trace
+	if code != exitOK || json.Unmarshal([]byte(stdout), &trace) != nil {
+		t.Fatalf("sign exited with %d: %s%s", code, stdout, stderr)
+	}
+	if trace.Path != snap.EndpointInquiryBalance || trace.MinifiedBody != `{"accountNo":"9920017573"}` || trace.Signature == "" {
+		t.Errorf("Unexpected trace %+v", trace)
+	}
+
+	verify := append([]string{"verify", "-public-key", publicPath, "-signature", trace.Signature}, request...)
+	if stdout, stderr, code := sendme(t, env, verify...); code != exitOK || !strings.Contains(stdout, `"verified": true`) {
+		t.Errorf("verify exited with %d: %s%s", code, stdout, stderr)
+	}
+	verify[len(verify)-3] = "2025-06-09T10:30:04+07:00"
+	stdout, stderr, code = sendme(t, env, verify...)
+	if code != exitError || !strings.Contains(stdout, `"verified": false`) || !strings.Contains(stderr, "signature mismatch") {
+		t.Errorf("Expected a mismatch with its trace, got %d: %s%s", code, stdout, stderr)
+	}
+}
+
+// TestVerifyClientRequest tests that verify accepts the signature of a request sent by
+// the client and that string-to-sign reproduces its string to sign
+func TestVerifyClientRequest(t *testing.T) {
+	var header http.Header
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Clone()
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"responseCode":"4011100","responseMessage":"Unauthorized. Signature"}`))
+	}))
+	defer server.Close()
+
+	client, err := snap.NewClient(snaptest.DefaultPartnerID, snaptest.PartnerPrivateKey(), nil,
+		snap.WithBaseURL(server.URL), snap.WithClock(func() time.Time { return testTime }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.InquiryBalance(context.Background(), &snap.InquiryBalanceRequest{AccountNo: "9920017573"}); err == nil {
+		t.Fatal("Expected the request to be rejected")
+	}
+
+	publicKey := writeFile(t, "partner.pub", snaptest.PartnerPublicKey())
+	request := []string{"-path", snap.EndpointInquiryBalance, "-body", "-", "-timestamp", header.Get("X-TIMESTAMP")}
+	verify := append([]string{"verify", "-public-key", publicKey, "-signature", header.Get("X-SIGNATURE")}, request...)
+	if stdout, stderr, code := sendmeInput(t, nil, string(body), verify...); code != exitOK {
+		t.Errorf("verify exited with %d: %s%s", code, stdout, stderr)
+	}
+
+	stdout, stderr, code := sendmeInput(t, nil, string(body), append([]string{"string-to-sign", "-o", "csv"}, request...)...)
+	expected := snap.StringToSign(http.MethodPost, snap.EndpointInquiryBalance, body, header.Get("X-TIMESTAMP"))
+	if code != exitOK || !strings.Contains(stdout, expected) {
+		t.Errorf("string-to-sign exited with %d: %s%s", code, stdout, stderr)
+	}
+}
@@ -7,7 +7,7 @@ import (
 	"os"
 	"time"
 
-	"faspay-sendme-snap-go/snap" // Import the snap package using the module path
+	"github.com/andremaeshaa/faspay-sendme-snap-go/snap" // Import the snap package using the module path
 )
 
 // This example demonstrates how to use the Faspay SendMe Snap SDK to perform various operations.
@@ -21,27 +21,29 @@ func main() {
 		log.Fatalf("Failed to read private key: %v", err)
 	}
 
+	// The SSL certificate is used to verify the Faspay server
+	sslCertPath := "../certs/faspay.crt"
+	sslCert, err := os.ReadFile(sslCertPath)
+	if err != nil {
+		log.Fatalf("Failed to read SSL certificate: %v", err)
+	}
+
 	// Step 2: Initialize the client
 	// Replace these values with your actual credentials
 	partnerId := "99999" // Your 5-digit partner ID
 
-	// Create a new client with a custom timeout
+	// Create a new client for the sandbox environment with a custom timeout
 	client, err := snap.NewClient(
 		partnerId,
 		privateKey,
-		snap.WithTimeout(60*time.Second), // Optional: Set a custom timeout
+		sslCert,
+		snap.WithEnvironment(snap.Sandbox), // Sandbox or Production
+		snap.WithTimeout(60*time.Second),   // Optional: Set a custom timeout
 	)
 	if err != nil {
 		log.Fatalf("Failed to initialize client: %v", err)
 	}
 
-	// Optional: Set the environment (sandbox or prod)
-	// By default, the client uses the sandbox environment
-	err = client.SetEnv("sandbox")
-	if err != nil {
-		log.Fatalf("Failed to set environment: %v", err)
-	}
-
 	// Step 3: Create a context with timeout
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
@@ -0,0 +1,299 @@
+// Package banks lists the Indonesian banks Faspay SendMe can transfer to, with their
+// clearing codes, names and SWIFT/BIC codes.
+//
+// The list is embedded in the package. When Faspay adds a bank before the SDK is
+// updated, the list can be extended or overridden from a JSON file:
+//
+//	registry, err := banks.Default().ExtendFile("banks.json")
+//
+// Registry.Validate and Registry.ValidateOnline have the signature expected by
+// snap.WithBankCodeValidator, so the beneficiary bank code of transfers and account
+// inquiries can be checked before a request is signed:
+//
+//	client, err := snap.NewClient(partnerID, key, cert,
+//		snap.WithBankCodeValidator(banks.Default().ValidateOnline))
+package banks
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+//go:embed banks.json
+var embeddedBanks []byte
+
+// Sentinel errors returned by validation
+var (
+	ErrUnknownBank               = errors.New("unknown bank code")
+	ErrOnlineTransferUnsupported = errors.New("bank does not support online transfer")
+)
+
+// Bank is a bank Faspay can send money to
+type Bank struct {
+	Code           string   `json:"code"`                // 3-digit clearing code used as beneficiaryBankCode
+	ShortName      string   `json:"shortName"`           // Common name, e.g. "BCA"
+	Name           string   `json:"name"`                // Registered name of the bank
+	SwiftCode      string   `json:"swiftCode,omitempty"` // SWIFT/BIC, empty when unknown
+	OnlineTransfer bool     `json:"onlineTransfer"`      // Whether transfers are credited in real time
+	Aliases        []string `json:"aliases,omitempty"`   // Other names the bank is known by
+}
+
+// Registry is an immutable list of banks indexed by code and name. It is safe for
+// concurrent use.
+type Registry struct {
+	banks  []Bank
+	byCode map[string]int
+	byName map[string]int
+}
+
+var defaultRegistry = mustParse(embeddedBanks)
+
+// Default returns the registry built from the embedded bank list
+func Default() *Registry {
+	return defaultRegistry
+}
+
+// New builds a registry from banks. Codes must be numeric and unique.
+func New(banks []Bank) (*Registry, error) {
+	r := &Registry{
+		banks:  make([]Bank, 0, len(banks)),
+		byCode: make(map[string]int, len(banks)),
+		byName: make(map[string]int, len(banks)*3),
+	}
+	for _, bank := range banks {
+		if err := checkBank(bank); err != nil {
+			return nil, err
+		}
+		if _, ok := r.byCode[bank.Code]; ok {
+			return nil, fmt.Errorf("banks: duplicate code %q", bank.Code)
+		}
+		r.add(bank)
+	}
+	return r, nil
+}
+
+// Parse builds a registry from a JSON array of banks
+func Parse(data []byte) (*Registry, error) {
+	banks, err := decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	return New(banks)
+}
+
+// LoadFile builds a registry from a JSON file holding an array of banks, replacing
+// the embedded list
+func LoadFile(path string) (*Registry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("banks: %w", err)
+	}
+	return Parse(data)
+}
+
+// Extend returns a new registry holding the banks of r plus banks. A bank whose
+// code is already known replaces the existing entry. r is not modified.
+func (r *Registry) Extend(banks ...Bank) (*Registry, error) {
+	merged := slices.Clone(r.banks)
+	for _, bank := range banks {
+		if err := checkBank(bank); err != nil {
+			return nil, err
+		}
+		if i := slices.IndexFunc(merged, func(b Bank) bool { return b.Code == bank.Code }); i >= 0 {
+			merged[i] = bank
+			continue
+		}
+		merged = append(merged, bank)
+	}
+	return New(merged)
+}
+
+// ExtendJSON is Extend with banks read from a JSON array
+func (r *Registry) ExtendJSON(reader io.Reader) (*Registry, error) {
+	banks, err := decode(reader)
+	if err != nil {
+		return nil, err
+	}
+	return r.Extend(banks...)
+}
+
+// ExtendFile is Extend with banks read from a JSON file
+func (r *Registry) ExtendFile(path string) (*Registry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("banks: %w", err)
+	}
+	defer f.Close()
+	return r.ExtendJSON(f)
+}
+
+// All returns the banks ordered by code
+func (r *Registry) All() []Bank {
+	banks := make([]Bank, len(r.banks))
+	for i, bank := range r.banks {
+		banks[i] = bank.clone()
+	}
+	slices.SortFunc(banks, func(a, b Bank) int { return strings.Compare(a.Code, b.Code) })
+	return banks
+}
+
+// Len returns the number of banks in the registry
+func (r *Registry) Len() int {
+	return len(r.banks)
+}
+
+// ByCode returns the bank with the given clearing code
+func (r *Registry) ByCode(code string) (Bank, bool) {
+	i, ok := r.byCode[strings.TrimSpace(code)]
+	if !ok {
+		return Bank{}, false
+	}
+	return r.banks[i].clone(), true
+}
+
+// ByName returns the bank whose short name, registered name or alias matches name.
+// Case, punctuation and the words "Bank", "PT", "Tbk" and "Persero" are ignored, so
+// "bca", "Bank BCA" and "PT Bank Central Asia Tbk" all find BCA.
+func (r *Registry) ByName(name string) (Bank, bool) {
+	i, ok := r.byName[normalizeName(name)]
+	if !ok {
+		return Bank{}, false
+	}
+	return r.banks[i].clone(), true
+}
+
+// Search returns the banks whose code is query or whose names contain it, ordered by code
+func (r *Registry) Search(query string) []Bank {
+	key := normalizeName(query)
+	if key == "" {
+		return nil
+	}
+
+	var found []Bank
+	for _, bank := range r.All() {
+		if bank.Code == strings.TrimSpace(query) || slices.ContainsFunc(bank.names(), func(name string) bool {
+			return strings.Contains(normalizeName(name), key)
+		}) {
+			found = append(found, bank)
+		}
+	}
+	return found
+}
+
+// Validate returns an error matching ErrUnknownBank when code is not in the registry
+func (r *Registry) Validate(code string) error {
+	if _, ok := r.ByCode(code); !ok {
+		return fmt.Errorf("%w %q", ErrUnknownBank, code)
+	}
+	return nil
+}
+
+// ValidateOnline is Validate that also returns an error matching
+// ErrOnlineTransferUnsupported when the bank cannot receive online transfers
+func (r *Registry) ValidateOnline(code string) error {
+	bank, ok := r.ByCode(code)
+	if !ok {
+		return fmt.Errorf("%w %q", ErrUnknownBank, code)
+	}
+	if !bank.OnlineTransfer {
+		return fmt.Errorf("%w: %s (%s)", ErrOnlineTransferUnsupported, bank.ShortName, bank.Code)
+	}
+	return nil
+}
+
+// ByCode returns the bank with the given clearing code from the embedded list
+func ByCode(code string) (Bank, bool) {
+	return defaultRegistry.ByCode(code)
+}
+
+// ByName returns the bank with the given name from the embedded list
+func ByName(name string) (Bank, bool) {
+	return defaultRegistry.ByName(name)
+}
+
+// Validate checks code against the embedded list
+func Validate(code string) error {
+	return defaultRegistry.Validate(code)
+}
+
+// ValidateOnline checks code against the embedded list and requires online transfer
+func ValidateOnline(code string) error {
+	return defaultRegistry.ValidateOnline(code)
+}
+
+func (r *Registry) add(bank Bank) {
+	i := len(r.banks)
+	r.banks = append(r.banks, bank.clone())
+	r.byCode[bank.Code] = i
+	for _, name := range bank.names() {
+		// The first bank claiming a name keeps it
+		if key := normalizeName(name); key != "" {
+			if _, taken := r.byName[key]; !taken {
+				r.byName[key] = i
+			}
+		}
+	}
+}
+
+func (b Bank) names() []string {
+	return append([]string{b.ShortName, b.Name}, b.Aliases...)
+}
+
+func (b Bank) clone() Bank {
+	b.Aliases = slices.Clone(b.Aliases)
+	return b
+}
+
+func checkBank(bank Bank) error {
+	if bank.Code == "" || strings.IndexFunc(bank.Code, func(r rune) bool { return r < '0' || r > '9' }) >= 0 {
+		return fmt.Errorf("banks: code %q must be numeric", bank.Code)
+	}
+	if bank.ShortName == "" && bank.Name == "" {
+		return fmt.Errorf("banks: bank %s has no name", bank.Code)
+	}
+	return nil
+}
+
+func decode(reader io.Reader) ([]Bank, error) {
+	var banks []Bank
+	decoder := json.NewDecoder(reader)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&banks); err != nil {
+		return nil, fmt.Errorf("banks: decoding bank list: %w", err)
+	}
+	return banks, nil
+}
+
+func mustParse(data []byte) *Registry {
+	r, err := Parse(data)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// ignoredWords are dropped from names before they are compared
+var ignoredWords = map[string]bool{"BANK": true, "PT": true, "TBK": true, "PERSERO": true}
+
+// normalizeName upper-cases name, replaces punctuation by spaces and drops ignoredWords
+func normalizeName(name string) string {
+	words := strings.FieldsFunc(strings.ToUpper(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	kept := words[:0]
+	for _, word := range words {
+		if !ignoredWords[word] {
+			kept = append(kept, word)
+		}
+	}
+	return strings.Join(kept, " ")
+}
@@ -0,0 +1,139 @@
+package banks
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestDefault tests the embedded bank list
+func TestDefault(t *testing.T) {
+	registry := Default()
+	if registry.Len() == 0 {
+		t.Fatal("Expected the embedded list to hold banks")
+	}
+
+	bca, ok := registry.ByCode("014")
+	if !ok {
+		t.Fatal("Expected BCA to be listed")
+	}
+	if bca.ShortName != "BCA" || bca.SwiftCode != "CENAIDJA" || !bca.OnlineTransfer {
+		t.Errorf("Unexpected BCA entry: %+v", bca)
+	}
+
+	all := registry.All()
+	for i := 1; i < len(all); i++ {
+		if all[i-1].Code >= all[i].Code {
+			t.Fatalf("Expected banks ordered by unique code, got %s before %s", all[i-1].Code, all[i].Code)
+		}
+	}
+}
+
+// TestByName tests lookups by short name, registered name and alias
+func TestByName(t *testing.T) {
+	tests := map[string]string{
+		"bca":                                 "014",
+		"Bank BCA":                            "014",
+		"PT Bank Central Asia Tbk":            "014",
+		"mandiri":                             "008",
+		"PT. Bank Rakyat Indonesia (Persero)": "002",
+		"cimb":                                "022",
+	}
+	for name, code := range tests {
+		bank, ok := ByName(name)
+		if !ok || bank.Code != code {
+			t.Errorf("ByName(%q) = %s, %v; expected %s", name, bank.Code, ok, code)
+		}
+	}
+
+	if _, ok := ByName("Bank of Nowhere"); ok {
+		t.Error("Expected an unknown name not to be found")
+	}
+}
+
+// TestSearch tests partial name and code searches
+func TestSearch(t *testing.T) {
+	found := Default().Search("syariah")
+	if len(found) < 2 {
+		t.Fatalf("Expected several Syariah banks, got %v", found)
+	}
+	for _, bank := range found {
+		if !strings.Contains(strings.ToUpper(bank.Name+" "+bank.ShortName+" "+strings.Join(bank.Aliases, " ")), "SYARIAH") {
+			t.Errorf("Unexpected bank %s in results", bank.Code)
+		}
+	}
+
+	if found := Default().Search("008"); len(found) != 1 || found[0].ShortName != "Mandiri" {
+		t.Errorf("Expected Mandiri for code 008, got %v", found)
+	}
+}
+
+// TestValidate tests the validation hooks
+func TestValidate(t *testing.T) {
+	if err := Validate("008"); err != nil {
+		t.Errorf("Expected 008 to be valid, got %v", err)
+	}
+	if err := Validate("999"); !errors.Is(err, ErrUnknownBank) {
+		t.Errorf("Expected ErrUnknownBank, got %v", err)
+	}
+	if err := Validate("031"); err != nil {
+		t.Errorf("Expected 031 to be known, got %v", err)
+	}
+	if err := ValidateOnline("031"); !errors.Is(err, ErrOnlineTransferUnsupported) {
+		t.Errorf("Expected ErrOnlineTransferUnsupported, got %v", err)
+	}
+	if err := ValidateOnline("999"); !errors.Is(err, ErrUnknownBank) {
+		t.Errorf("Expected ErrUnknownBank, got %v", err)
+	}
+}
+
+// TestExtendFile tests that a JSON file adds and overrides banks without touching the default list
+func TestExtendFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "banks.json")
+	data := `[
+		{"code": "031", "shortName": "Citibank", "name": "Citibank N.A.", "onlineTransfer": true},
+		{"code": "899", "shortName": "New Bank", "name": "PT Bank Baru Indonesia", "onlineTransfer": true, "aliases": ["Bank Baru"]}
+	]`
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	registry, err := Default().ExtendFile(path)
+	if err != nil {
+		t.Fatalf("ExtendFile failed: %v", err)
+	}
+	if registry.Len() != Default().Len()+1 {
+		t.Errorf("Expected one bank to be added, got %d banks", registry.Len())
+	}
+	if err := registry.ValidateOnline("031"); err != nil {
+		t.Errorf("Expected the override to enable online transfer, got %v", err)
+	}
+	if bank, ok := registry.ByName("bank baru"); !ok || bank.Code != "899" {
+		t.Errorf("Expected the new bank to be found by alias, got %+v", bank)
+	}
+	if err := Default().Validate("899"); err == nil {
+		t.Error("Expected the default list not to be modified")
+	}
+}
+
+// TestLoadErrors tests that malformed lists are rejected
+func TestLoadErrors(t *testing.T) {
+	tests := map[string]string{
+		"not json":       `{`,
+		"unknown field":  `[{"code": "001", "shortName": "A", "bic": "X"}]`,
+		"non-numeric":    `[{"code": "BCA", "shortName": "BCA"}]`,
+		"duplicate code": `[{"code": "001", "shortName": "A"}, {"code": "001", "shortName": "B"}]`,
+		"no name":        `[{"code": "001"}]`,
+	}
+	for name, data := range tests {
+		if _, err := Parse([]byte(data)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+
+	if _, err := LoadFile(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("Expected an error for a missing file")
+	}
+}
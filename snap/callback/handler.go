@@ -0,0 +1,375 @@
+// Package callback receives the final status notifications Faspay SendMe sends to the
+// callbackUrl of transfers, e-money top-ups and bill payments.
+package callback
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/andremaeshaa/faspay-sendme-snap-go/snap"
+)
+
+// Default configuration values
+const (
+	DefaultMaxClockSkew = 5 * time.Minute // Maximum accepted age of X-TIMESTAMP
+	DefaultMaxBodySize  = 1 << 20         // Maximum accepted notification body in bytes
+)
+
+// Service codes used in acknowledgments when the notification does not carry one
+const (
+	ServiceCodeTransfer    = string(snap.ServiceCodeTransferInterbank)
+	ServiceCodeTopup       = string(snap.ServiceCodeEmoneyTopup)
+	ServiceCodeBillPayment = string(snap.ServiceCodeBillPayment)
+)
+
+// Metadata describes the delivery of a notification
+type Metadata struct {
+	ExternalID string    // X-EXTERNAL-ID header, unique per delivery
+	PartnerID  string    // X-PARTNER-ID header
+	Timestamp  time.Time // Parsed X-TIMESTAMP header
+	Body       []byte    // Raw notification body
+}
+
+// TransferEvent is the final status of an interbank transfer
+type TransferEvent struct {
+	Metadata                   Metadata                                   `json:"-"`
+	OriginalPartnerReferenceNo string                                     `json:"originalPartnerReferenceNo"`
+	OriginalReferenceNo        string                                     `json:"originalReferenceNo"`
+	ServiceCode                snap.ServiceCode                           `json:"serviceCode"`
+	TransactionDate            string                                     `json:"transactionDate"`
+	Amount                     *snap.Amount                               `json:"amount"`
+	BeneficiaryAccountNo       string                                     `json:"beneficiaryAccountNo"`
+	BeneficiaryBankCode        string                                     `json:"beneficiaryBankCode"`
+	SourceAccountNo            string                                     `json:"sourceAccountNo"`
+	LatestTransactionStatus    snap.TransactionStatusCode                 `json:"latestTransactionStatus"`
+	TransactionStatusDesc      string                                     `json:"transactionStatusDesc"`
+	AdditionalInfo             *snap.AdditionalInfoStatusTransferResponse `json:"additionalInfo"`
+}
+
+// TopupEvent is the final status of an e-money top-up
+type TopupEvent struct {
+	Metadata                   Metadata                        `json:"-"`
+	OriginalPartnerReferenceNo string                          `json:"originalPartnerReferenceNo"`
+	OriginalReferenceNo        string                          `json:"originalReferenceNo"`
+	ServiceCode                snap.ServiceCode                `json:"serviceCode"`
+	CustomerNumber             string                          `json:"customerNumber"`
+	Amount                     *snap.Amount                    `json:"amount"`
+	LatestTransactionStatus    snap.TransactionStatusCode      `json:"latestTransactionStatus"`
+	TransactionStatusDesc      string                          `json:"transactionStatusDesc"`
+	AdditionalInfo             *snap.AdditionalInfoTopupStatus `json:"additionalInfo"`
+}
+
+// BillPaymentEvent is the final status of a virtual account bill payment
+type BillPaymentEvent struct {
+	Metadata                Metadata                                `json:"-"`
+	PartnerReferenceNo      string                                  `json:"partnerReferenceNo"`
+	ReferenceNo             string                                  `json:"referenceNo"`
+	ServiceCode             snap.ServiceCode                        `json:"serviceCode"`
+	PartnerServiceId        string                                  `json:"partnerServiceId"`
+	CustomerNo              string                                  `json:"customerNo"`
+	VirtualAccountNo        string                                  `json:"virtualAccountNo"`
+	VirtualAccountName      string                                  `json:"virtualAccountName"`
+	SourceAccount           string                                  `json:"sourceAccount"`
+	PaidAmount              *snap.Amount                            `json:"paidAmount"`
+	TrxDateTime             string                                  `json:"trxDateTime"`
+	LatestTransactionStatus snap.TransactionStatusCode              `json:"latestTransactionStatus"`
+	TransactionStatusDesc   string                                  `json:"transactionStatusDesc"`
+	AdditionalInfo          *snap.AdditionalInfoBillPaymentResponse `json:"additionalInfo"`
+}
+
+// Handler is an http.Handler that verifies Faspay notifications, dispatches them to
+// the registered handler funcs and replies with a SNAP formatted acknowledgment.
+// A Handler is safe for concurrent use.
+type Handler struct {
+	publicKey    *rsa.PublicKey
+	partnerID    string
+	maxClockSkew time.Duration
+	maxBodySize  int64
+	now          func() time.Time
+
+	onTransfer    func(context.Context, *TransferEvent) error
+	onTopup       func(context.Context, *TopupEvent) error
+	onBillPayment func(context.Context, *BillPaymentEvent) error
+}
+
+// Option is a function that configures a Handler
+type Option func(*Handler)
+
+// OnTransfer registers the func called for transfer notifications
+func OnTransfer(fn func(context.Context, *TransferEvent) error) Option {
+	return func(h *Handler) {
+		h.onTransfer = fn
+	}
+}
+
+// OnTopup registers the func called for e-money top-up notifications
+func OnTopup(fn func(context.Context, *TopupEvent) error) Option {
+	return func(h *Handler) {
+		h.onTopup = fn
+	}
+}
+
+// OnBillPayment registers the func called for bill payment notifications
+func OnBillPayment(fn func(context.Context, *BillPaymentEvent) error) Option {
+	return func(h *Handler) {
+		h.onBillPayment = fn
+	}
+}
+
+// WithPartnerID rejects notifications whose X-PARTNER-ID differs from partnerID
+func WithPartnerID(partnerID string) Option {
+	return func(h *Handler) {
+		h.partnerID = partnerID
+	}
+}
+
+// WithMaxClockSkew sets how far X-TIMESTAMP may be from the current time
+func WithMaxClockSkew(skew time.Duration) Option {
+	return func(h *Handler) {
+		h.maxClockSkew = skew
+	}
+}
+
+// WithMaxBodySize sets the largest notification body accepted, in bytes. Larger
+// notifications are refused with 413. Sizes of zero or less keep DefaultMaxBodySize.
+func WithMaxBodySize(size int64) Option {
+	return func(h *Handler) {
+		if size > 0 {
+			h.maxBodySize = size
+		}
+	}
+}
+
+// WithClock sets the function used to read the current time
+func WithClock(now func() time.Time) Option {
+	return func(h *Handler) {
+		h.now = now
+	}
+}
+
+// NewHandler creates a Handler that verifies notifications with Faspay's PEM encoded
+// public key or certificate
+func NewHandler(faspayPublicKey []byte, options ...Option) (*Handler, error) {
+	publicKey, err := snap.ParsePublicKey(faspayPublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("error loading Faspay public key: %w", err)
+	}
+
+	h := &Handler{
+		publicKey:    publicKey,
+		maxClockSkew: DefaultMaxClockSkew,
+		maxBodySize:  DefaultMaxBodySize,
+		now:          time.Now,
+	}
+
+	for _, option := range options {
+		option(h)
+	}
+
+	return h, nil
+}
+
+// ServeHTTP verifies and dispatches a notification. Faspay receives a 2xx
+// acknowledgment only when the registered handler func returned nil. Notifications
+// that are too large or malformed are refused with a 4xx, while an error from the
+// handler func is answered with 500 General Error so that Faspay retries the delivery.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeAck(w, http.StatusMethodNotAllowed, "", "00", "Requested Function Is Not Supported")
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, h.maxBodySize+1))
+	if err != nil {
+		writeAck(w, http.StatusBadRequest, "", "00", "Bad Request")
+		return
+	}
+	if int64(len(body)) > h.maxBodySize {
+		writeAck(w, http.StatusRequestEntityTooLarge, "", "00", "Request Entity Too Large")
+		return
+	}
+
+	metadata, rejected := h.verify(r, body)
+	if rejected != nil {
+		writeAck(w, rejected.status, "", rejected.caseCode, rejected.message)
+		return
+	}
+
+	kind, serviceCode, err := classify(body)
+	if err != nil {
+		writeAck(w, http.StatusBadRequest, "", "01", "Invalid Field Format")
+		return
+	}
+
+	if err := h.dispatch(r.Context(), kind, metadata, body); err != nil {
+		if errors.Is(err, errNoHandler) {
+			writeAck(w, http.StatusNotFound, serviceCode, "00", "Requested Function Is Not Supported")
+			return
+		}
+		if errors.Is(err, errInvalidFormat) {
+			writeAck(w, http.StatusBadRequest, serviceCode, "01", "Invalid Field Format")
+			return
+		}
+		writeAck(w, http.StatusInternalServerError, serviceCode, "00", "General Error")
+		return
+	}
+
+	writeAck(w, http.StatusOK, serviceCode, "00", "Successful")
+}
+
+// rejection describes why a notification was refused
+type rejection struct {
+	status   int
+	caseCode string
+	message  string
+}
+
+// verify checks the SNAP headers and signature, returning a rejection when the
+// notification must be refused
+func (h *Handler) verify(r *http.Request, body []byte) (Metadata, *rejection) {
+	metadata := Metadata{
+		ExternalID: r.Header.Get("X-EXTERNAL-ID"),
+		PartnerID:  r.Header.Get("X-PARTNER-ID"),
+		Body:       body,
+	}
+
+	for _, header := range []string{"X-TIMESTAMP", "X-SIGNATURE", "X-EXTERNAL-ID"} {
+		if r.Header.Get(header) == "" {
+			return metadata, &rejection{http.StatusBadRequest, "02", "Invalid Mandatory Field " + header}
+		}
+	}
+
+	if h.partnerID != "" && metadata.PartnerID != h.partnerID {
+		return metadata, &rejection{http.StatusUnauthorized, "00", "Unauthorized. Unknown Partner"}
+	}
+
+	timestamp, err := time.Parse(time.RFC3339, r.Header.Get("X-TIMESTAMP"))
+	if err != nil {
+		return metadata, &rejection{http.StatusBadRequest, "01", "Invalid Field Format X-TIMESTAMP"}
+	}
+	metadata.Timestamp = timestamp
+
+	if skew := h.now().Sub(timestamp); skew > h.maxClockSkew || skew < -h.maxClockSkew {
+		return metadata, &rejection{http.StatusUnauthorized, "00", "Unauthorized. Stale Timestamp"}
+	}
+
+	if err := snap.VerifyRequest(h.publicKey, r, body); err != nil {
+		return metadata, &rejection{http.StatusUnauthorized, "00", "Unauthorized. Signature"}
+	}
+
+	return metadata, nil
+}
+
+var (
+	errNoHandler     = errors.New("no handler registered for notification")
+	errInvalidFormat = errors.New("invalid notification format")
+)
+
+// decodeEvent unmarshals body into event. A body that does not match the event is
+// reported as errInvalidFormat, which is not worth retrying.
+func decodeEvent(body []byte, event any) error {
+	if err := json.Unmarshal(body, event); err != nil {
+		return fmt.Errorf("%w: %w", errInvalidFormat, err)
+	}
+	return nil
+}
+
+// dispatch decodes body into the event type for kind and calls the registered func
+func (h *Handler) dispatch(ctx context.Context, kind eventKind, metadata Metadata, body []byte) error {
+	switch kind {
+	case kindTransfer:
+		if h.onTransfer == nil {
+			return errNoHandler
+		}
+		event := &TransferEvent{Metadata: metadata}
+		if err := decodeEvent(body, event); err != nil {
+			return err
+		}
+		return h.onTransfer(ctx, event)
+	case kindTopup:
+		if h.onTopup == nil {
+			return errNoHandler
+		}
+		event := &TopupEvent{Metadata: metadata}
+		if err := decodeEvent(body, event); err != nil {
+			return err
+		}
+		return h.onTopup(ctx, event)
+	default:
+		if h.onBillPayment == nil {
+			return errNoHandler
+		}
+		event := &BillPaymentEvent{Metadata: metadata}
+		if err := decodeEvent(body, event); err != nil {
+			return err
+		}
+		return h.onBillPayment(ctx, event)
+	}
+}
+
+// eventKind identifies the operation a notification reports on
+type eventKind int
+
+const (
+	kindTransfer eventKind = iota
+	kindTopup
+	kindBillPayment
+)
+
+// classify determines the notification kind from its serviceCode or, when it has
+// none, from the fields only that kind carries. The service code used for the
+// acknowledgment is returned with it.
+func classify(body []byte) (eventKind, string, error) {
+	var probe struct {
+		ServiceCode          string `json:"serviceCode"`
+		CustomerNumber       string `json:"customerNumber"`
+		VirtualAccountNo     string `json:"virtualAccountNo"`
+		BeneficiaryAccountNo string `json:"beneficiaryAccountNo"`
+		AdditionalInfo       struct {
+			PlatformCode string `json:"platformCode"`
+		} `json:"additionalInfo"`
+	}
+	if err := json.Unmarshal(body, &probe); err != nil {
+		return 0, "", err
+	}
+
+	switch {
+	case probe.ServiceCode == ServiceCodeTransfer:
+		return kindTransfer, probe.ServiceCode, nil
+	case probe.ServiceCode == ServiceCodeTopup:
+		return kindTopup, probe.ServiceCode, nil
+	case probe.ServiceCode == ServiceCodeBillPayment:
+		return kindBillPayment, probe.ServiceCode, nil
+	case probe.VirtualAccountNo != "":
+		return kindBillPayment, ServiceCodeBillPayment, nil
+	case probe.CustomerNumber != "" || probe.AdditionalInfo.PlatformCode != "":
+		return kindTopup, ServiceCodeTopup, nil
+	case probe.BeneficiaryAccountNo != "":
+		return kindTransfer, ServiceCodeTransfer, nil
+	}
+	return 0, "", errors.New("unknown notification type")
+}
+
+// writeAck writes a SNAP formatted acknowledgment. The response code is the HTTP
+// status, the service code and the case code.
+func writeAck(w http.ResponseWriter, status int, serviceCode, caseCode, message string) {
+	if serviceCode == "" {
+		serviceCode = "00"
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(struct {
+		ResponseCode    string `json:"responseCode"`
+		ResponseMessage string `json:"responseMessage"`
+	}{
+		ResponseCode:    fmt.Sprintf("%03d%s%s", status, serviceCode, caseCode),
+		ResponseMessage: message,
+	})
+}
@@ -0,0 +1,242 @@
+package callback
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/andremaeshaa/faspay-sendme-snap-go/snap"
+)
+
+const testTimestamp = "2025-06-09T10:30:03+07:00"
+
+func testNow() time.Time {
+	now, _ := time.Parse(time.RFC3339, testTimestamp)
+	return now.Add(30 * time.Second)
+}
+
+// getTestKeys returns the throwaway keypair stored in the snap testdata directory
+func getTestKeys(t *testing.T) (*rsa.PrivateKey, []byte) {
+	t.Helper()
+
+	privatePEM, err := os.ReadFile("../testdata/private_key.pem")
+	if err != nil {
+		t.Fatalf("Failed to read private key: %v", err)
+	}
+	publicPEM, err := os.ReadFile("../testdata/public_key.pem")
+	if err != nil {
+		t.Fatalf("Failed to read public key: %v", err)
+	}
+
+	block, _ := pem.Decode(privatePEM)
+	parsedKey, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		t.Fatalf("Failed to parse private key: %v", err)
+	}
+	return parsedKey.(*rsa.PrivateKey), publicPEM
+}
+
+// newNotification builds a notification request signed by privateKey
+func newNotification(t *testing.T, privateKey *rsa.PrivateKey, body string) *http.Request {
+	t.Helper()
+
+	digest := sha256.Sum256([]byte(snap.StringToSign(http.MethodPost, "/v1/snap/callback", []byte(body), testTimestamp)))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, privateKey, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatalf("Failed to sign: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/snap/callback", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-TIMESTAMP", testTimestamp)
+	req.Header.Set("X-SIGNATURE", base64.StdEncoding.EncodeToString(signature))
+	req.Header.Set("X-PARTNER-ID", "99999")
+	req.Header.Set("X-EXTERNAL-ID", "9999917494373120001")
+	return req
+}
+
+// decodeAck decodes the acknowledgment written by the handler
+func decodeAck(t *testing.T, rec *httptest.ResponseRecorder) (string, string) {
+	t.Helper()
+
+	var ack struct {
+		ResponseCode    string `json:"responseCode"`
+		ResponseMessage string `json:"responseMessage"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &ack); err != nil {
+		t.Fatalf("Failed to decode acknowledgment: %v", err)
+	}
+	return ack.ResponseCode, ack.ResponseMessage
+}
+
+const transferNotification = `{
+	"originalPartnerReferenceNo": "20250609103003234",
+	"originalReferenceNo": "53883",
+	"serviceCode": "18",
+	"amount": {"value": "10000.00", "currency": "IDR"},
+	"beneficiaryAccountNo": "60004400184",
+	"beneficiaryBankCode": "008",
+	"latestTransactionStatus": "00",
+	"transactionStatusDesc": "Success"
+}`
+
+const topupNotification = `{
+	"originalPartnerReferenceNo": "20250609150352616",
+	"originalReferenceNo": "59732",
+	"customerNumber": "0812254830",
+	"amount": {"value": "76860.00", "currency": "IDR"},
+	"latestTransactionStatus": "06",
+	"additionalInfo": {"platformCode": "gpy"}
+}`
+
+// TestHandler tests dispatching verified notifications
+func TestHandler(t *testing.T) {
+	privateKey, publicPEM := getTestKeys(t)
+
+	var transfer *TransferEvent
+	var topup *TopupEvent
+	handler, err := NewHandler(publicPEM,
+		WithClock(testNow),
+		WithPartnerID("99999"),
+		OnTransfer(func(ctx context.Context, event *TransferEvent) error {
+			transfer = event
+			return nil
+		}),
+		OnTopup(func(ctx context.Context, event *TopupEvent) error {
+			topup = event
+			return errors.New("database unavailable")
+		}),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create handler: %v", err)
+	}
+
+	t.Run("Transfer", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, newNotification(t, privateKey, transferNotification))
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", rec.Code)
+		}
+		if code, _ := decodeAck(t, rec); code != "2001800" {
+			t.Errorf("Expected responseCode 2001800, got %s", code)
+		}
+		if transfer == nil || transfer.OriginalPartnerReferenceNo != "20250609103003234" {
+			t.Fatalf("Expected transfer event to be dispatched, got %+v", transfer)
+		}
+		if transfer.Amount.Value != "10000.00" || transfer.Metadata.ExternalID != "9999917494373120001" {
+			t.Errorf("Expected amount and metadata to be populated, got %+v", transfer)
+		}
+	})
+
+	t.Run("TopupHandlerError", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, newNotification(t, privateKey, topupNotification))
+
+		if rec.Code != http.StatusInternalServerError {
+			t.Fatalf("Expected status 500, got %d", rec.Code)
+		}
+		if code, _ := decodeAck(t, rec); code != "5003800" {
+			t.Errorf("Expected responseCode 5003800, got %s", code)
+		}
+		if topup == nil || topup.CustomerNumber != "0812254830" {
+			t.Errorf("Expected top-up event to be dispatched, got %+v", topup)
+		}
+	})
+
+	t.Run("NoHandlerRegistered", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, newNotification(t, privateKey, `{"partnerReferenceNo":"BILL1","virtualAccountNo":"700808000047816"}`))
+
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404, got %d", rec.Code)
+		}
+	})
+}
+
+// TestHandlerRejects tests notifications that fail verification
+func TestHandlerRejects(t *testing.T) {
+	privateKey, publicPEM := getTestKeys(t)
+
+	called := false
+	handler, err := NewHandler(publicPEM,
+		WithClock(testNow),
+		WithPartnerID("99999"),
+		OnTransfer(func(ctx context.Context, event *TransferEvent) error {
+			called = true
+			return nil
+		}),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create handler: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		mutate   func(req *http.Request)
+		status   int
+		wantCode string
+	}{
+		{name: "MissingSignature", mutate: func(req *http.Request) { req.Header.Del("X-SIGNATURE") }, status: http.StatusBadRequest, wantCode: "4000002"},
+		{name: "WrongPartner", mutate: func(req *http.Request) { req.Header.Set("X-PARTNER-ID", "11111") }, status: http.StatusUnauthorized, wantCode: "4010000"},
+		{name: "BadSignature", mutate: func(req *http.Request) {
+			req.Header.Set("X-SIGNATURE", base64.StdEncoding.EncodeToString([]byte("forged")))
+		}, status: http.StatusUnauthorized, wantCode: "4010000"},
+		{name: "StaleTimestamp", mutate: func(req *http.Request) { req.Header.Set("X-TIMESTAMP", "2025-06-09T09:00:00+07:00") }, status: http.StatusUnauthorized, wantCode: "4010000"},
+		{name: "WrongMethod", mutate: func(req *http.Request) { req.Method = http.MethodGet }, status: http.StatusMethodNotAllowed, wantCode: "4050000"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := newNotification(t, privateKey, transferNotification)
+			tt.mutate(req)
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Errorf("Expected status %d, got %d", tt.status, rec.Code)
+			}
+			if code, _ := decodeAck(t, rec); code != tt.wantCode {
+				t.Errorf("Expected responseCode %s, got %s", tt.wantCode, code)
+			}
+		})
+	}
+
+	t.Run("InvalidFormat", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, newNotification(t, privateKey, `{"serviceCode":"18","amount":"10000.00"}`))
+		if code, _ := decodeAck(t, rec); rec.Code != http.StatusBadRequest || code != "4001801" {
+			t.Errorf("Expected an invalid format acknowledgment, got %d %s", rec.Code, code)
+		}
+	})
+
+	t.Run("TooLarge", func(t *testing.T) {
+		limited, err := NewHandler(publicPEM, WithClock(testNow), WithMaxBodySize(int64(len(transferNotification))-1))
+		if err != nil {
+			t.Fatalf("Failed to create handler: %v", err)
+		}
+		rec := httptest.NewRecorder()
+		limited.ServeHTTP(rec, newNotification(t, privateKey, transferNotification))
+		if code, _ := decodeAck(t, rec); rec.Code != http.StatusRequestEntityTooLarge || code != "4130000" {
+			t.Errorf("Expected a too large acknowledgment, got %d %s", rec.Code, code)
+		}
+	})
+
+	if called {
+		t.Error("Expected rejected notifications not to be dispatched")
+	}
+}
@@ -15,21 +15,34 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"math"
-	mathRand "math/rand"
+	"log/slog"
 	"net/http"
-	"strings"
+	"sync"
 	"time"
 )
 
-// Client represents a Faspay SendMe Snap API client
+// Client represents a Faspay SendMe Snap API client. A Client is configured once by
+// NewClient and is safe for concurrent use by multiple goroutines.
 type Client struct {
-	environment string
+	mu          sync.RWMutex // Guards environment and baseURL against the deprecated SetEnv
+	environment Environment
 	baseURL     string
 	httpClient  *http.Client
 	PartnerId   string
-	privateKey  []byte
+	signer      crypto.Signer
 	timeout     time.Duration
+	logger      *slog.Logger
+	redaction   *RedactionPolicy
+	retryPolicy RetryPolicy
+
+	faspayPublicKeyPEM []byte
+	faspayPublicKey    *rsa.PublicKey
+	sandboxPartnerIDs  []string
+	bankCodeValidator  BankCodeValidator
+	platforms          *PlatformRegistry
+	now                func() time.Time
+	externalID         ExternalIDGenerator
+	wrapTransport      func(http.RoundTripper) http.RoundTripper
 }
 
 // ClientOption is a function that configures a Client
@@ -50,7 +63,47 @@ func WithHTTPClient(httpClient *http.Client) ClientOption {
 	}
 }
 
+// WithLogger sets the logger used for request and response records. Logging is
+// disabled by default. Sensitive values are masked according to the redaction policy.
+func WithLogger(logger *slog.Logger) ClientOption {
+	return func(c *Client) {
+		if logger == nil {
+			logger = slog.New(discardHandler{})
+		}
+		c.logger = logger
+	}
+}
+
+// WithRedactionPolicy sets the policy used to mask sensitive values before they are
+// logged. DefaultRedactionPolicy is used when this option is not given.
+func WithRedactionPolicy(policy *RedactionPolicy) ClientOption {
+	return func(c *Client) {
+		c.redaction = policy
+	}
+}
+
+// WithSigner signs requests with signer instead of the PEM private key passed to NewClient,
+// so the key can live in an HSM, a KMS or a PKCS#11 token. The signer must hold an RSA key;
+// it is called with a SHA-256 digest and crypto.SHA256 and must return a PKCS#1 v1.5 signature.
+func WithSigner(signer crypto.Signer) ClientOption {
+	return func(c *Client) {
+		c.signer = signer
+	}
+}
+
+// WithTransportRecorder passes the transport of the HTTP client through wrap once all
+// other options are applied, so that every request and response can be recorded or
+// replayed, for instance by a snaptest.Recorder. A client given to WithHTTPClient is
+// copied rather than modified.
+func WithTransportRecorder(wrap func(http.RoundTripper) http.RoundTripper) ClientOption {
+	return func(c *Client) {
+		c.wrapTransport = wrap
+	}
+}
+
 // NewClient initializes and returns a new Client instance with the given API key, secret, and optional configurations.
+// The private key is parsed and validated here, so a malformed key is reported before the first request.
+// privateKey may be nil when WithSigner is used.
 func NewClient(partnerId string, privateKey, sslCert []byte, options ...ClientOption) (Services, error) {
 	caCertPool := x509.NewCertPool()
 	caCertPool.AppendCertsFromPEM(sslCert)
@@ -64,14 +117,10 @@ func NewClient(partnerId string, privateKey, sslCert []byte, options ...ClientOp
 				},
 			},
 		},
-		PartnerId:  partnerId,
-		privateKey: privateKey,
-		timeout:    time.Duration(DefaultTimeout) * time.Second,
-	}
-
-	if client.baseURL == "" {
-		client.baseURL = DefaultBaseURL
-		client.environment = "sandbox"
+		PartnerId: partnerId,
+		timeout:   time.Duration(DefaultTimeout) * time.Second,
+		logger:    slog.New(discardHandler{}),
+		redaction: DefaultRedactionPolicy(),
 	}
 
 	// Apply options
@@ -79,22 +128,102 @@ func NewClient(partnerId string, privateKey, sslCert []byte, options ...ClientOp
 		option(client)
 	}
 
+	if client.wrapTransport != nil {
+		httpClient := *client.httpClient
+		transport := httpClient.Transport
+		if transport == nil {
+			transport = http.DefaultTransport
+		}
+		httpClient.Transport = client.wrapTransport(transport)
+		client.httpClient = &httpClient
+	}
+
+	if client.now == nil {
+		client.now = time.Now
+	}
+	if client.platforms == nil {
+		client.platforms = DefaultPlatforms()
+	}
+	if client.externalID == nil {
+		client.externalID = newExternalIDGenerator(client.PartnerId, client.now)
+	}
+
+	if err := client.resolveTarget(); err != nil {
+		return nil, err
+	}
+
+	if client.signer == nil {
+		rsaKey, err := ParsePrivateKey(privateKey)
+		if err != nil {
+			return nil, fmt.Errorf("error loading private key: %w", err)
+		}
+		client.signer = rsaKey
+	}
+
+	if _, ok := client.signer.Public().(*rsa.PublicKey); !ok {
+		return nil, errors.New("signer must hold an RSA key")
+	}
+
+	if client.faspayPublicKeyPEM != nil {
+		publicKey, err := ParsePublicKey(client.faspayPublicKeyPEM)
+		if err != nil {
+			return nil, fmt.Errorf("error loading Faspay public key: %w", err)
+		}
+		client.faspayPublicKey = publicKey
+	}
+
 	return client, nil
 }
 
-// doRequest performs an HTTP request with the specified method, URL path, and request body, returning the HTTP response.
-func (c *Client) doRequest(ctx context.Context, method, path string, body interface{}) (*http.Response, error) {
-	url := fmt.Sprintf("%s%s", c.baseURL, path)
+// call signs and sends body to path and decodes the response into v, retrying
+// according to the retry policy. Each attempt is logged.
+func (c *Client) call(ctx context.Context, path string, body, v any) error {
+	jsonBody, err := json.Marshal(body)
+	if err != nil {
+		return &MarshalError{Err: err}
+	}
 
-	var jsonBody []byte
-	var reqBody io.Reader
-	if body != nil {
-		var err error
-		jsonBody, err = json.Marshal(body)
-		if err != nil {
-			return nil, fmt.Errorf("error marshaling request body: %w", err)
+	for attempt := 1; ; attempt++ {
+		err = c.attempt(ctx, path, jsonBody, v, attempt)
+		if err == nil || attempt >= c.retryPolicy.MaxAttempts || !c.shouldRetry(ctx, path, jsonBody, err) {
+			return err
+		}
+
+		if sleepErr := sleepContext(ctx, c.retryPolicy.backoff(attempt)); sleepErr != nil {
+			return err
 		}
-		reqBody = bytes.NewBuffer(jsonBody)
+	}
+}
+
+// attempt sends jsonBody to path once with a fresh timestamp, signature and external ID.
+func (c *Client) attempt(ctx context.Context, path string, jsonBody []byte, v any, attempt int) error {
+	externalID, err := c.nextExternalID()
+	if err != nil {
+		return err
+	}
+	start := time.Now()
+
+	resp, err := c.doRequest(ctx, http.MethodPost, path, jsonBody, externalID)
+	if err != nil {
+		c.logExchange(ctx, path, externalID, attempt, jsonBody, nil, time.Since(start), err)
+		return err
+	}
+
+	respBody, err := c.parseResponse(resp, path, v)
+	c.logExchange(ctx, path, externalID, attempt, jsonBody, &loggedResponse{statusCode: resp.StatusCode, body: respBody}, time.Since(start), err)
+
+	return err
+}
+
+// doRequest performs a signed HTTP request with the specified method, URL path, JSON body and
+// external ID, returning the HTTP response.
+func (c *Client) doRequest(ctx context.Context, method, path string, jsonBody []byte, externalID string) (*http.Response, error) {
+	_, baseURL := c.target()
+	url := fmt.Sprintf("%s%s", baseURL, path)
+
+	var reqBody io.Reader
+	if jsonBody != nil {
+		reqBody = bytes.NewReader(jsonBody)
 	}
 
 	req, err := http.NewRequestWithContext(ctx, method, url, reqBody)
@@ -103,13 +232,20 @@ func (c *Client) doRequest(ctx context.Context, method, path string, body interf
 	}
 
 	// Generate timestamp for signature
-	timestamp := time.Now().Format("2006-01-02T15:04:05-07:00")
+	timestamp := NewTime(c.now()).String()
 
-	signature, err := c.generateSignatureSnap(method, path, string(jsonBody), timestamp, c.privateKey)
+	signature, err := c.generateSignatureSnap(method, path, string(jsonBody), timestamp)
 	if err != nil {
-		return nil, fmt.Errorf("error generating signature: %w", err)
+		return nil, &SigningError{Err: err}
 	}
 
+	c.logger.LogAttrs(ctx, slog.LevelDebug, "snap request signed",
+		slog.String("endpoint", path),
+		slog.String("external_id", externalID),
+		slog.String("timestamp", timestamp),
+		slog.String("signature", c.redaction.Redact("signature", signature)),
+	)
+
 	// Set headers
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Accept", "application/json")
@@ -117,36 +253,41 @@ func (c *Client) doRequest(ctx context.Context, method, path string, body interf
 	req.Header.Set("X-TIMESTAMP", timestamp)
 	req.Header.Set("X-SIGNATURE", signature)
 	req.Header.Set("X-PARTNER-ID", c.PartnerId)
-	req.Header.Set("X-EXTERNAL-ID", c.generateRandomNumber())
+	req.Header.Set("X-EXTERNAL-ID", externalID)
 	req.Header.Set("CHANNEL-ID", "88001")
 
-	if c.environment == "sandbox" {
-		println("Info: Transaction will be processed in sandbox mode")
-	}
-
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("error executing request: %w", err)
+		return nil, &TransportError{Method: method, URL: url, Err: err}
 	}
 
 	return resp, nil
 }
 
-func (c *Client) generateSignatureSnap(httpMethod, endpointUrl, requestBody, timeStamp string, privateKeyPEM []byte) (string, error) {
-	// Remove escaped slashes (\/ → /)
-	minifiedBody := strings.ReplaceAll(requestBody, `\/`, `/`)
+func (c *Client) generateSignatureSnap(httpMethod, endpointUrl, requestBody, timeStamp string) (string, error) {
+	// Build string to sign from the minified body hash
+	stringToSign := StringToSign(httpMethod, endpointUrl, []byte(requestBody), timeStamp)
 
-	// SHA-256 hash of minified body
-	hashed := sha256.Sum256([]byte(minifiedBody))
-	lowercaseHash := fmt.Sprintf("%x", hashed[:])
+	// Sign using SHA256withRSA
+	hash := sha256.New()
+	hash.Write([]byte(stringToSign))
+	hashedBytes := hash.Sum(nil)
 
-	// Build string to sign
-	stringToSign := fmt.Sprintf("%s:%s:%s:%s", httpMethod, endpointUrl, lowercaseHash, timeStamp)
+	signature, err := c.signer.Sign(rand.Reader, hashedBytes, crypto.SHA256)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign: %w", err)
+	}
+
+	// Encode to base64
+	encodedSignature := base64.StdEncoding.EncodeToString(signature)
+	return encodedSignature, nil
+}
 
-	// Parse private key from PEM format
-	block, _ := pem.Decode([]byte(privateKeyPEM))
+// ParsePrivateKey parses and validates a PEM encoded PKCS#8 or PKCS#1 RSA private key
+func ParsePrivateKey(privateKeyPEM []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(privateKeyPEM)
 	if block == nil {
-		return "", errors.New("failed to parse private key PEM")
+		return nil, errors.New("failed to parse private key PEM")
 	}
 
 	parsedKey, err := x509.ParsePKCS8PrivateKey(block.Bytes)
@@ -154,56 +295,57 @@ func (c *Client) generateSignatureSnap(httpMethod, endpointUrl, requestBody, tim
 		// Try PKCS1 if PKCS8 fails
 		parsedKey, err = x509.ParsePKCS1PrivateKey(block.Bytes)
 		if err != nil {
-			return "", errors.New("failed to parse private key")
+			return nil, errors.New("failed to parse private key")
 		}
 	}
 
 	rsaKey, ok := parsedKey.(*rsa.PrivateKey)
 	if !ok {
-		return "", errors.New("not an RSA private key")
+		return nil, errors.New("not an RSA private key")
 	}
 
-	// Sign using SHA256withRSA
-	hash := sha256.New()
-	hash.Write([]byte(stringToSign))
-	hashedBytes := hash.Sum(nil)
-
-	signature, err := rsa.SignPKCS1v15(rand.Reader, rsaKey, crypto.SHA256, hashedBytes)
-	if err != nil {
-		return "", fmt.Errorf("failed to sign: %v", err)
+	if err := rsaKey.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid RSA private key: %w", err)
 	}
 
-	// Encode to base64
-	encodedSignature := base64.StdEncoding.EncodeToString(signature)
-	return encodedSignature, nil
+	return rsaKey, nil
 }
 
-func (c *Client) generateRandomNumber() string {
-	// Get current time in milliseconds (equivalent to microtime(true) * 1000)
-	milliseconds := math.Round(float64(time.Now().UnixNano()) / 1e6)
-
-	// Generate random number (assuming $rand is a random number)
-	randomNum := mathRand.Intn(1000) // Adjust range as needed
-
-	// Concatenate all parts (equivalent to '99999' . round(...) . $rand)
-	return fmt.Sprintf("%s%d%d", c.PartnerId, int64(milliseconds), randomNum)
-}
-
-// parseResponse parses the HTTP response into the provided response object
-func (c *Client) parseResponse(resp *http.Response, v any) error {
+// parseResponse parses the HTTP response to a request for path into the provided response
+// object, returning an *Error when the HTTP status or the SNAP responseCode reports a failure.
+// When a Faspay public key is configured, the signature is checked first, so a forged error
+// cannot trigger a retry. The raw body is returned so it can be logged.
+func (c *Client) parseResponse(resp *http.Response, path string, v any) ([]byte, error) {
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return fmt.Errorf("error reading response body: %w", err)
+		return nil, fmt.Errorf("error reading response body: %w", err)
+	}
+
+	apiErr := checkResponse(resp.StatusCode, body)
+	if c.faspayPublicKey != nil && (resp.Header.Get("X-SIGNATURE") != "" || mustBeSigned(apiErr)) {
+		err := VerifySignature(c.faspayPublicKey, http.MethodPost, path, body, resp.Header.Get("X-TIMESTAMP"), resp.Header.Get("X-SIGNATURE"))
+		if err != nil {
+			return body, err
+		}
+	}
+	if apiErr != nil {
+		return body, apiErr
 	}
-	println("resp: ", string(body))
 
 	if v != nil {
 		if err := json.Unmarshal(body, v); err != nil {
-			return fmt.Errorf("error unmarshaling response: %w", err)
+			return body, fmt.Errorf("error unmarshaling response: %w", err)
 		}
 	}
 
-	return nil
+	return body, nil
+}
+
+// mustBeSigned reports whether a response checked as apiErr has to carry an X-SIGNATURE:
+// successful responses, and errors with a SNAP responseCode that may lead to a retry,
+// which come from Faspay rather than from a gateway or proxy in front of it
+func mustBeSigned(apiErr *Error) bool {
+	return apiErr == nil || (apiErr.ServiceCode != "" && apiErr.retryCandidate())
 }
@@ -160,7 +160,7 @@ func TestClient_CustomerTopup(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Failed to create client: %v", err)
 	}
-	err = client.SetEnv("prod")
+	err = client.SetEnv("sandbox")
 	if err != nil {
 		panic(err)
 	}
@@ -199,7 +199,7 @@ func TestClient_CustomerTopupStatus(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Failed to create client: %v", err)
 	}
-	err = client.SetEnv("prod")
+	err = client.SetEnv("sandbox")
 	if err != nil {
 		panic(err)
 	}
@@ -259,7 +259,7 @@ func TestClient_BillPayment(t *testing.T) {
 		panic(err)
 	}
 
-	err = client.SetEnv("prod")
+	err = client.SetEnv("sandbox")
 	if err != nil {
 		panic(err)
 	}
@@ -0,0 +1,160 @@
+package snap
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+)
+
+// TestClientConcurrentUse calls all nine service methods from many goroutines on a
+// shared client. Run with -race to detect unsynchronized access.
+func TestClientConcurrentUse(t *testing.T) {
+	mockHTTPClient := NewMockClient(func(req *http.Request) (*http.Response, error) {
+		return MockResponse(http.StatusOK, `{"responseCode":"2000000","responseMessage":"Successful"}`), nil
+	})
+
+	client, err := NewClient("99999", getTestPrivateKey(), nil,
+		WithHTTPClient(mockHTTPClient),
+		WithEnvironment(Sandbox),
+		WithRetryPolicy(testRetryPolicy()),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	ctx := context.Background()
+	calls := map[string]func() error{
+		"AccountInquiry": func() error {
+			_, err := client.AccountInquiry(ctx, &ExternalAccountInquiryRequest{
+				BeneficiaryBankCode:  "008",
+				BeneficiaryAccountNo: "60004400184",
+				PartnerReferenceNo:   "20250606234037372",
+			})
+			return err
+		},
+		"TransferInterBank": func() error {
+			_, err := client.TransferInterBank(ctx, testTransferRequest())
+			return err
+		},
+		"StatusTransfer": func() error {
+			_, err := client.StatusTransfer(ctx, &StatusTransferRequest{OriginalPartnerReferenceNo: "TRX123456789", ServiceCode: "18"})
+			return err
+		},
+		"InquiryBalance": func() error {
+			_, err := client.InquiryBalance(ctx, &InquiryBalanceRequest{AccountNo: "9920017573"})
+			return err
+		},
+		"HistoryList": func() error {
+			_, err := client.HistoryList(ctx, &HistoryListRequest{
+				FromDateTime:   "2024-12-01T00:00:00+07:00",
+				ToDateTime:     "2024-12-30T00:00:00+07:00",
+				AdditionalInfo: &AdditionalHistoryListRequest{AccountNo: "9920017573"},
+			})
+			return err
+		},
+		"CustomerTopup": func() error {
+			_, err := client.CustomerTopup(ctx, &CustomerTopupRequest{
+				PartnerReferenceNo: "20250609150352617",
+				CustomerNumber:     "0812254830",
+				Amount:             &Amount{Value: "76860.00", Currency: "IDR"},
+				TransactionDate:    "2025-06-09T15:03:52+07:00",
+				AdditionalInfo:     &AdditionalInfoCustomerTopupRequest{PlatformCode: "gpy"},
+			})
+			return err
+		},
+		"CustomerTopupStatus": func() error {
+			_, err := client.CustomerTopupStatus(ctx, &CustomerTopupStatusRequest{OriginalPartnerReferenceNo: "20250609150352617", ServiceCode: "38"})
+			return err
+		},
+		"BillInquiry": func() error {
+			_, err := client.BillInquiry(ctx, &BillInquiryRequest{
+				PartnerReferenceNo: "20250609162756943",
+				PartnerServiceId:   "    7008",
+				CustomerNo:         "08000047816",
+				VirtualAccountNo:   "700808000047816",
+			})
+			return err
+		},
+		"BillPayment": func() error {
+			_, err := client.BillPayment(ctx, &BillPaymentRequest{
+				PartnerReferenceNo: "20250609162921210",
+				PartnerServiceId:   "    7008",
+				CustomerNo:         "08000047816",
+				VirtualAccountNo:   "700808000047816",
+				SourceAccount:      "9920017573",
+				PaidAmount:         &Amount{Value: "41454.00", Currency: "IDR"},
+				TrxDateTime:        "2025-06-09T16:29:21+07:00",
+			})
+			return err
+		},
+	}
+
+	const goroutinesPerMethod = 20
+	var wg sync.WaitGroup
+	for name, call := range calls {
+		for i := 0; i < goroutinesPerMethod; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if err := call(); err != nil {
+					t.Errorf("%s failed: %v", name, err)
+				}
+			}()
+		}
+	}
+
+	// The deprecated SetEnv may still be called while requests are in flight
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < goroutinesPerMethod; i++ {
+			if err := client.SetEnv("sandbox"); err != nil {
+				t.Errorf("SetEnv failed: %v", err)
+			}
+		}
+	}()
+
+	wg.Wait()
+}
+
+// TestClientEnvironmentOptions tests selecting the environment at construction time
+func TestClientEnvironmentOptions(t *testing.T) {
+	var requestedHost string
+	mockHTTPClient := NewMockClient(func(req *http.Request) (*http.Response, error) {
+		requestedHost = req.URL.Scheme + "://" + req.URL.Host
+		return MockInquiryBalanceSuccessResponse(), nil
+	})
+
+	tests := []struct {
+		name    string
+		options []ClientOption
+		want    string
+	}{
+		{name: "Production", options: []ClientOption{WithEnvironment(Production)}, want: baseUrlProd},
+		{name: "Sandbox", options: []ClientOption{WithEnvironment(Sandbox)}, want: baseUrlSandbox},
+		{name: "BaseURL", options: []ClientOption{WithEnvironment(Production), WithBaseURL("http://localhost:8080/")}, want: "http://localhost:8080"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := NewClient("12345", getTestPrivateKey(), nil, append(tt.options, WithHTTPClient(mockHTTPClient))...)
+			if err != nil {
+				t.Fatalf("Failed to create client: %v", err)
+			}
+			if _, err := client.InquiryBalance(context.Background(), &InquiryBalanceRequest{AccountNo: "9920017573"}); err != nil {
+				t.Fatalf("Failed to call InquiryBalance: %v", err)
+			}
+			if requestedHost != tt.want {
+				t.Errorf("Expected request to %s, got %s", tt.want, requestedHost)
+			}
+		})
+	}
+
+	if _, err := NewClient("99999", getTestPrivateKey(), nil, WithEnvironment("staging")); err == nil {
+		t.Error("Expected an error for an unknown environment")
+	}
+	if _, err := NewClient("99999", getTestPrivateKey(), nil, WithBaseURL("localhost:8080")); err == nil {
+		t.Error("Expected an error for a base URL without scheme")
+	}
+}
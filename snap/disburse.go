@@ -0,0 +1,224 @@
+package snap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// DisburseOutcome is the final state of a disbursement
+type DisburseOutcome string
+
+// Disbursement outcomes
+const (
+	DisburseSucceeded DisburseOutcome = "succeeded" // Faspay reported the transfer as successful
+	DisburseFailed    DisburseOutcome = "failed"    // Faspay reported the transfer as failed, refunded or cancelled
+	DisbursePending   DisburseOutcome = "pending"   // The transfer was still in progress when polling stopped
+)
+
+// DisburseRequest describes an interbank payout for Disburse
+type DisburseRequest struct {
+	// Transfer is the transfer to send. Its partnerReferenceNo is used for the status
+	// checks; the account inquiry gets its own reference with an "-INQ" suffix.
+	Transfer *TransferInterBankRequest
+	// ExpectedName is the beneficiary name the inquired account name must match.
+	// Transfer.BeneficiaryAccountName is used when it is empty.
+	ExpectedName string
+	// NameMatcher compares the expected and inquired names. It defaults to
+	// FuzzyNameMatcher(DefaultNameMatchThreshold).
+	NameMatcher NameMatcher
+	// PollPolicy controls the status checks. The zero value uses DefaultPollPolicy.
+	PollPolicy PollPolicy
+	// Hooks are called as the disbursement progresses
+	Hooks DisburseHooks
+}
+
+// DisburseHooks are called after each step of Disburse, for example to persist progress
+// or emit metrics. Nil hooks are skipped.
+type DisburseHooks struct {
+	OnInquiry   func(ctx context.Context, response *ExternalAccountInquiryResponse, err error)
+	OnNameCheck func(ctx context.Context, expected, actual string, matched bool)
+	OnTransfer  func(ctx context.Context, response *TransferInterBankResponse, err error)
+	OnStatus    func(ctx context.Context, response *StatusTransferResponse, err error)
+	OnComplete  func(ctx context.Context, result *DisburseResult, err error)
+}
+
+// DisburseResult reports what Disburse learned about a payout
+type DisburseResult struct {
+	Outcome                 DisburseOutcome       // Set once the transfer was sent
+	PartnerReferenceNo      string                // partnerReferenceNo of the transfer
+	ReferenceNo             string                // referenceNo assigned by Faspay, if known
+	BeneficiaryAccountName  string                // Account name returned by the inquiry
+	LatestTransactionStatus TransactionStatusCode // Last latestTransactionStatus seen
+	TransactionStatusDesc   string                // Description of LatestTransactionStatus
+
+	Inquiry  *ExternalAccountInquiryResponse
+	Transfer *TransferInterBankResponse
+	Status   *StatusTransferResponse // Last status check, if any
+}
+
+// NameMismatchError is returned by Disburse when the inquired account name does not
+// match the expected name. No transfer is sent.
+type NameMismatchError struct {
+	Expected string // Name the caller expected
+	Actual   string // Name returned by the account inquiry
+}
+
+// Error returns the error message
+func (e *NameMismatchError) Error() string {
+	return fmt.Sprintf("beneficiary name mismatch: expected %q, bank returned %q", e.Expected, e.Actual)
+}
+
+// inquiryReferenceSuffix marks the partnerReferenceNo of the account inquiry of a disbursement
+const inquiryReferenceSuffix = "-INQ"
+
+// inquiryReferenceNo derives the partnerReferenceNo of the account inquiry from the
+// transfer's, so that the two requests are not recorded under the same reference.
+// The start of long references is dropped to stay within maxPartnerReferenceNo.
+func inquiryReferenceNo(partnerReferenceNo string) string {
+	if excess := len(partnerReferenceNo) + len(inquiryReferenceSuffix) - maxPartnerReferenceNo; excess > 0 {
+		partnerReferenceNo = partnerReferenceNo[excess:]
+	}
+	return partnerReferenceNo + inquiryReferenceSuffix
+}
+
+// Disburse sends an interbank payout and waits for its final status: it inquires the
+// beneficiary account, checks the returned name, sends the transfer and polls
+// StatusTransfer until the status is final or the poll policy gives up.
+//
+// An error is returned when the payout stopped before Faspay accepted the transfer,
+// or when the status could not be checked; the result then holds what was learned so
+// far. A transfer that was still in progress after PollPolicy.MaxWait is reported as
+// DisbursePending without error and must be reconciled later, for example from the
+// Faspay notification.
+//
+// When the transfer call times out or fails with a server error, Faspay may still
+// have accepted it, and a status check may not find it until Faspay has indexed it.
+// Such a transfer is polled like any other, and one Faspay never reports is left
+// DisbursePending rather than failed, so that it is not paid out twice.
+func Disburse(ctx context.Context, client Services, req *DisburseRequest) (result *DisburseResult, err error) {
+	if req == nil || req.Transfer == nil {
+		return nil, &ValidationError{Fields: []FieldError{{Field: "transfer", Message: "is required"}}}
+	}
+	if err := req.Transfer.Validate(); err != nil {
+		return nil, err
+	}
+
+	hooks := req.Hooks
+	result = &DisburseResult{PartnerReferenceNo: req.Transfer.PartnerReferenceNo}
+	defer func() {
+		if hooks.OnComplete != nil {
+			hooks.OnComplete(ctx, result, err)
+		}
+	}()
+
+	inquiry, err := client.AccountInquiry(ctx, &ExternalAccountInquiryRequest{
+		BeneficiaryBankCode:  req.Transfer.BeneficiaryBankCode,
+		BeneficiaryAccountNo: req.Transfer.BeneficiaryAccountNo,
+		PartnerReferenceNo:   inquiryReferenceNo(req.Transfer.PartnerReferenceNo),
+		AdditionalInfo:       &AdditionalInfoInquiryAccount{SourceAccount: req.Transfer.SourceAccountNo},
+	})
+	if hooks.OnInquiry != nil {
+		hooks.OnInquiry(ctx, inquiry, err)
+	}
+	if err != nil {
+		return result, fmt.Errorf("account inquiry: %w", err)
+	}
+	result.Inquiry = inquiry
+	result.BeneficiaryAccountName = inquiry.BeneficiaryAccountName
+
+	expected := req.ExpectedName
+	if expected == "" {
+		expected = req.Transfer.BeneficiaryAccountName
+	}
+	matcher := req.NameMatcher
+	if matcher == nil {
+		matcher = FuzzyNameMatcher(DefaultNameMatchThreshold)
+	}
+	matched := matcher(expected, inquiry.BeneficiaryAccountName)
+	if hooks.OnNameCheck != nil {
+		hooks.OnNameCheck(ctx, expected, inquiry.BeneficiaryAccountName, matched)
+	}
+	if !matched {
+		return result, &NameMismatchError{Expected: expected, Actual: inquiry.BeneficiaryAccountName}
+	}
+
+	transfer, transferErr := client.TransferInterBank(ctx, req.Transfer)
+	if hooks.OnTransfer != nil {
+		hooks.OnTransfer(ctx, transfer, transferErr)
+	}
+	if transferErr != nil && !outcomeUnknown(transferErr) {
+		return result, fmt.Errorf("transfer: %w", transferErr)
+	}
+
+	result.Outcome = DisbursePending
+	if transfer != nil {
+		result.Transfer = transfer
+		result.ReferenceNo = transfer.ReferenceNo
+		if info := transfer.AdditionalInfo; info != nil {
+			result.setStatus(info.Status(), info.TransactionStatusDesc)
+			if info.Status().IsTerminal() {
+				return result, nil
+			}
+		}
+	}
+
+	status, err := PollStatus(ctx, client, &PollRequest{
+		OriginalPartnerReferenceNo: result.PartnerReferenceNo,
+		OriginalReferenceNo:        result.ReferenceNo,
+		ServiceCode:                ServiceCodeTransferInterbank,
+		Policy:                     req.PollPolicy,
+		OnUpdate: func(update StatusUpdate) {
+			if update.Status != nil && update.Status.Transfer != nil {
+				result.Status = update.Status.Transfer
+			}
+			if hooks.OnStatus != nil {
+				var response *StatusTransferResponse
+				if update.Status != nil {
+					response = update.Status.Transfer
+				}
+				hooks.OnStatus(ctx, response, update.Err)
+			}
+		},
+	})
+	if status != nil {
+		result.setStatus(status.LatestTransactionStatus, status.TransactionStatusDesc)
+	}
+
+	switch {
+	case errors.Is(err, ErrPending):
+		// Still in progress after MaxWait: reported through the outcome
+		return result, nil
+	case err != nil:
+		return result, fmt.Errorf("transfer status: %w", err)
+	}
+	return result, nil
+}
+
+// setStatus records a latestTransactionStatus and the outcome it implies
+func (r *DisburseResult) setStatus(status TransactionStatusCode, description string) {
+	r.LatestTransactionStatus = status
+	r.TransactionStatusDesc = description
+	switch {
+	case status.IsSuccess():
+		r.Outcome = DisburseSucceeded
+	case status.IsTerminal():
+		r.Outcome = DisburseFailed
+	}
+}
+
+// outcomeUnknown reports whether err leaves open whether Faspay processed the request
+func outcomeUnknown(err error) bool {
+	var transportErr *TransportError
+	if errors.As(err, &transportErr) {
+		return !neverSent(err)
+	}
+	return errors.Is(err, ErrTimeout) || errors.Is(err, ErrServer) || errors.Is(err, ErrPending)
+}
+
+// isTransactionNotFound reports whether err is Faspay's "Transaction Not Found" response
+func isTransactionNotFound(err error) bool {
+	var apiErr *Error
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound && apiErr.CaseCode == caseTransactionNotFound
+}
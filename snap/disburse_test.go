@@ -0,0 +1,208 @@
+package snap
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// disburseServer answers the endpoints Disburse calls. statuses are returned by
+// successive status checks; the last one repeats.
+type disburseServer struct {
+	mu           sync.Mutex
+	inquiredName string
+	transfer     func() *http.Response
+	statuses     []TransactionStatusCode
+	calls        map[string]int
+	inquiryRefs  []string // partnerReferenceNo of each account inquiry
+}
+
+func (s *disburseServer) client(t *testing.T) Services {
+	s.calls = map[string]int{}
+	mockHTTPClient := NewMockClient(func(req *http.Request) (*http.Response, error) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.calls[req.URL.Path]++
+
+		switch req.URL.Path {
+		case EndpointAccountInquiry:
+			var inquiry ExternalAccountInquiryRequest
+			_ = json.NewDecoder(req.Body).Decode(&inquiry)
+			s.inquiryRefs = append(s.inquiryRefs, inquiry.PartnerReferenceNo)
+			return MockResponse(http.StatusOK, fmt.Sprintf(`{"responseCode":"2001600","responseMessage":"Successful","beneficiaryAccountName":%q,"beneficiaryAccountNo":"60004400184","beneficiaryBankCode":"008"}`, s.inquiredName)), nil
+		case EndpointTransferInterbank:
+			if s.transfer != nil {
+				return s.transfer(), nil
+			}
+			return MockResponse(http.StatusOK, `{"responseCode":"2001800","responseMessage":"Successful","referenceNo":"53883","partnerReferenceNo":"TRX123456789","additionalInfo":{"latestTransactionStatus":"03","transactionStatusDesc":"Pending"}}`), nil
+		case EndpointInquiryStatus:
+			check := s.calls[req.URL.Path]
+			status := s.statuses[min(check, len(s.statuses))-1]
+			if status == StatusNotFound {
+				return MockResponse(http.StatusNotFound, `{"responseCode":"4043601","responseMessage":"Transaction Not Found"}`), nil
+			}
+			return MockResponse(http.StatusOK, fmt.Sprintf(`{"responseCode":"2003600","responseMessage":"Successful","originalReferenceNo":"53883","originalPartnerReferenceNo":"TRX123456789","latestTransactionStatus":%q,"transactionStatusDesc":"status %s"}`, string(status), string(status))), nil
+		}
+		return MockNotFoundErrorResponse(), nil
+	})
+
+	client, err := NewClient("99999", getTestPrivateKey(), nil, WithHTTPClient(mockHTTPClient))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	return client
+}
+
+func testPollPolicy() PollPolicy {
+	return PollPolicy{InitialInterval: time.Millisecond, MaxInterval: 2 * time.Millisecond, Multiplier: 2, MaxWait: time.Second}
+}
+
+// TestDisburse tests the inquiry, transfer and status polling workflow
+func TestDisburse(t *testing.T) {
+	t.Run("Succeeded", func(t *testing.T) {
+		server := &disburseServer{inquiredName: "BPK JOHN DOE", statuses: []TransactionStatusCode{"01", "03", "00"}}
+		client := server.client(t)
+
+		var steps []string
+		result, err := Disburse(context.Background(), client, &DisburseRequest{
+			Transfer:   testTransferRequest(),
+			PollPolicy: testPollPolicy(),
+			Hooks: DisburseHooks{
+				OnInquiry:   func(context.Context, *ExternalAccountInquiryResponse, error) { steps = append(steps, "inquiry") },
+				OnNameCheck: func(context.Context, string, string, bool) { steps = append(steps, "name") },
+				OnTransfer:  func(context.Context, *TransferInterBankResponse, error) { steps = append(steps, "transfer") },
+				OnStatus:    func(context.Context, *StatusTransferResponse, error) { steps = append(steps, "status") },
+				OnComplete:  func(context.Context, *DisburseResult, error) { steps = append(steps, "complete") },
+			},
+		})
+		if err != nil {
+			t.Fatalf("Failed to disburse: %v", err)
+		}
+		if result.Outcome != DisburseSucceeded || result.ReferenceNo != "53883" || result.PartnerReferenceNo != "TRX123456789" {
+			t.Errorf("Unexpected result %+v", result)
+		}
+		if result.BeneficiaryAccountName != "BPK JOHN DOE" {
+			t.Errorf("Expected inquired name, got %q", result.BeneficiaryAccountName)
+		}
+		if fmt.Sprint(server.inquiryRefs) != "[TRX123456789-INQ]" {
+			t.Errorf("Expected the inquiry to have its own reference, got %v", server.inquiryRefs)
+		}
+		want := "[inquiry name transfer status status status complete]"
+		if fmt.Sprint(steps) != want {
+			t.Errorf("Expected hooks %s, got %v", want, steps)
+		}
+	})
+
+	t.Run("NameMismatch", func(t *testing.T) {
+		server := &disburseServer{inquiredName: "JANE ROE", statuses: []TransactionStatusCode{"00"}}
+		client := server.client(t)
+
+		_, err := Disburse(context.Background(), client, &DisburseRequest{Transfer: testTransferRequest(), PollPolicy: testPollPolicy()})
+		var mismatchErr *NameMismatchError
+		if !errors.As(err, &mismatchErr) {
+			t.Fatalf("Expected *NameMismatchError, got %v", err)
+		}
+		if server.calls[EndpointTransferInterbank] != 0 {
+			t.Error("Expected no transfer to be sent")
+		}
+	})
+
+	t.Run("Failed", func(t *testing.T) {
+		server := &disburseServer{inquiredName: "John Doe", statuses: []TransactionStatusCode{"06"}}
+		result, err := Disburse(context.Background(), server.client(t), &DisburseRequest{Transfer: testTransferRequest(), PollPolicy: testPollPolicy()})
+		if err != nil {
+			t.Fatalf("Failed to disburse: %v", err)
+		}
+		if result.Outcome != DisburseFailed || result.LatestTransactionStatus != "06" {
+			t.Errorf("Expected failed outcome, got %+v", result)
+		}
+	})
+
+	t.Run("PendingAfterDeadline", func(t *testing.T) {
+		server := &disburseServer{inquiredName: "John Doe", statuses: []TransactionStatusCode{"03"}}
+		policy := testPollPolicy()
+		policy.MaxWait = 20 * time.Millisecond
+		result, err := Disburse(context.Background(), server.client(t), &DisburseRequest{Transfer: testTransferRequest(), PollPolicy: policy})
+		if err != nil {
+			t.Fatalf("Failed to disburse: %v", err)
+		}
+		if result.Outcome != DisbursePending || server.calls[EndpointInquiryStatus] == 0 {
+			t.Errorf("Expected pending outcome after status checks, got %+v", result)
+		}
+	})
+
+	t.Run("TransferNotFound", func(t *testing.T) {
+		server := &disburseServer{
+			inquiredName: "John Doe",
+			transfer:     MockServerErrorResponse,
+			statuses:     []TransactionStatusCode{StatusNotFound},
+		}
+		policy := testPollPolicy()
+		policy.MaxWait = 20 * time.Millisecond
+		result, err := Disburse(context.Background(), server.client(t), &DisburseRequest{Transfer: testTransferRequest(), PollPolicy: policy})
+		if err != nil {
+			t.Fatalf("Expected the unknown outcome to be reported as pending, got %v", err)
+		}
+		if result.Outcome != DisbursePending || server.calls[EndpointInquiryStatus] < 2 {
+			t.Errorf("Expected pending outcome after several status checks, got %s after %d checks", result.Outcome, server.calls[EndpointInquiryStatus])
+		}
+	})
+
+	t.Run("TransferFoundLate", func(t *testing.T) {
+		server := &disburseServer{
+			inquiredName: "John Doe",
+			transfer:     MockServerErrorResponse,
+			statuses:     []TransactionStatusCode{StatusNotFound, StatusNotFound, StatusSuccess},
+		}
+		result, err := Disburse(context.Background(), server.client(t), &DisburseRequest{Transfer: testTransferRequest(), PollPolicy: testPollPolicy()})
+		if err != nil {
+			t.Fatalf("Failed to disburse: %v", err)
+		}
+		if result.Outcome != DisburseSucceeded || server.calls[EndpointInquiryStatus] != 3 {
+			t.Errorf("Expected success on the third status check, got %s after %d checks", result.Outcome, server.calls[EndpointInquiryStatus])
+		}
+	})
+}
+
+// TestNameMatcher tests fuzzy matching of account names
+func TestNameMatcher(t *testing.T) {
+	matcher := FuzzyNameMatcher(DefaultNameMatchThreshold)
+	tests := []struct {
+		expected string
+		actual   string
+		match    bool
+	}{
+		{expected: "John Doe", actual: "JOHN DOE", match: true},
+		{expected: "John Doe", actual: "BPK. JOHN DOE", match: true},
+		{expected: "Doe, John", actual: "JOHN DOE", match: true},
+		{expected: "Muhammad Rizky Pratama", actual: "MUHAMMAD RIZKY PRAT", match: true},
+		{expected: "Jon Doe", actual: "JOHN DOE", match: true},
+		{expected: "John Doe", actual: "JANE ROE", match: false},
+		{expected: "John Doe", actual: "", match: false},
+	}
+
+	for _, tt := range tests {
+		if got := matcher(tt.expected, tt.actual); got != tt.match {
+			t.Errorf("match(%q, %q) = %v, want %v (similarity %.2f)", tt.expected, tt.actual, got, tt.match, NameSimilarity(tt.expected, tt.actual))
+		}
+	}
+
+	if ExactNameMatcher("Jon Doe", "JOHN DOE") {
+		t.Error("Expected ExactNameMatcher to reject a misspelled name")
+	}
+}
+
+// TestInquiryReferenceNo tests that derived inquiry references fit the field limit
+func TestInquiryReferenceNo(t *testing.T) {
+	long := strings.Repeat("A", maxPartnerReferenceNo-2) + "42"
+	got := inquiryReferenceNo(long)
+	if len(got) != maxPartnerReferenceNo || !strings.HasSuffix(got, "42-INQ") {
+		t.Errorf("Unexpected reference %q", got)
+	}
+}
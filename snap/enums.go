@@ -0,0 +1,264 @@
+package snap
+
+// TransactionStatusCode is the latestTransactionStatus of a transfer, top-up or bill
+// payment. Values the SDK does not know are kept as received.
+type TransactionStatusCode string
+
+// Transaction statuses defined by SNAP BI
+const (
+	StatusSuccess    TransactionStatusCode = "00"
+	StatusInitiated  TransactionStatusCode = "01"
+	StatusPaying     TransactionStatusCode = "02"
+	StatusInProgress TransactionStatusCode = "03" // Pending
+	StatusRefunded   TransactionStatusCode = "04"
+	StatusCanceled   TransactionStatusCode = "05"
+	StatusFailed     TransactionStatusCode = "06"
+	StatusNotFound   TransactionStatusCode = "07"
+)
+
+var transactionStatusNames = map[TransactionStatusCode]string{
+	StatusSuccess:    "Success",
+	StatusInitiated:  "Initiated",
+	StatusPaying:     "Paying",
+	StatusInProgress: "In Progress",
+	StatusRefunded:   "Refunded",
+	StatusCanceled:   "Canceled",
+	StatusFailed:     "Failed",
+	StatusNotFound:   "Not Found",
+}
+
+// String returns the name of the status, or the raw code when it is unknown
+func (s TransactionStatusCode) String() string {
+	if name, ok := transactionStatusNames[s]; ok {
+		return name
+	}
+	return string(s)
+}
+
+// IsKnown reports whether s is one of the statuses defined by SNAP BI
+func (s TransactionStatusCode) IsKnown() bool {
+	_, ok := transactionStatusNames[s]
+	return ok
+}
+
+// IsTerminal reports whether the status will not change anymore: success, refunded,
+// canceled or failed
+func (s TransactionStatusCode) IsTerminal() bool {
+	switch s {
+	case StatusSuccess, StatusRefunded, StatusCanceled, StatusFailed:
+		return true
+	}
+	return false
+}
+
+// IsSuccess reports whether the transaction completed successfully
+func (s TransactionStatusCode) IsSuccess() bool {
+	return s == StatusSuccess
+}
+
+// ServiceCode is the 2-digit SNAP service code identifying an operation, as used in
+// status requests and response codes. Values the SDK does not know are kept as received.
+type ServiceCode string
+
+// Service codes of the operations the SDK calls
+const (
+	ServiceCodeBalanceInquiry         ServiceCode = "11"
+	ServiceCodeHistoryList            ServiceCode = "12"
+	ServiceCodeAccountInquiryExternal ServiceCode = "16"
+	ServiceCodeTransferInterbank      ServiceCode = "18"
+	ServiceCodeBillPayment            ServiceCode = "33"
+	ServiceCodeTransferStatus         ServiceCode = "36"
+	ServiceCodeEmoneyTopup            ServiceCode = "38"
+	ServiceCodeEmoneyTopupStatus      ServiceCode = "39"
+)
+
+var serviceCodeNames = map[ServiceCode]string{
+	ServiceCodeBalanceInquiry:         "Balance Inquiry",
+	ServiceCodeHistoryList:            "Transaction History List",
+	ServiceCodeAccountInquiryExternal: "External Account Inquiry",
+	ServiceCodeTransferInterbank:      "Transfer Interbank",
+	ServiceCodeBillPayment:            "Bill Payment",
+	ServiceCodeTransferStatus:         "Transfer Status Inquiry",
+	ServiceCodeEmoneyTopup:            "E-money Top-up",
+	ServiceCodeEmoneyTopupStatus:      "E-money Top-up Status",
+}
+
+// String returns the name of the operation, or the raw code when it is unknown
+func (c ServiceCode) String() string {
+	if name, ok := serviceCodeNames[c]; ok {
+		return name
+	}
+	return string(c)
+}
+
+// IsKnown reports whether c is one of the service codes the SDK knows
+func (c ServiceCode) IsKnown() bool {
+	_, ok := serviceCodeNames[c]
+	return ok
+}
+
+// DebitCredit tells whether a history entry took money out of or into the account
+type DebitCredit string
+
+// History entry directions
+const (
+	Debit  DebitCredit = "DEBIT"
+	Credit DebitCredit = "CREDIT"
+)
+
+// String returns the direction as received
+func (d DebitCredit) String() string {
+	return string(d)
+}
+
+// IsDebit reports whether money left the account
+func (d DebitCredit) IsDebit() bool {
+	return d == Debit
+}
+
+// IsCredit reports whether money entered the account
+func (d DebitCredit) IsCredit() bool {
+	return d == Credit
+}
+
+// BalanceType is the kind of balance reported by InquiryBalance
+type BalanceType string
+
+// Balance types
+const (
+	BalanceTypeAvailable BalanceType = "AVAILABLE"
+)
+
+// String returns the balance type as received
+func (b BalanceType) String() string {
+	return string(b)
+}
+
+// HistoryStatus is the status of an entry in the transaction history
+type HistoryStatus string
+
+// History entry statuses
+const (
+	HistoryStatusSuccess HistoryStatus = "SUCCESS"
+	HistoryStatusPending HistoryStatus = "PENDING"
+	HistoryStatusFailed  HistoryStatus = "FAILED"
+)
+
+// String returns the status as received
+func (s HistoryStatus) String() string {
+	return string(s)
+}
+
+// IsTerminal reports whether the entry will not change anymore
+func (s HistoryStatus) IsTerminal() bool {
+	return s == HistoryStatusSuccess || s == HistoryStatusFailed
+}
+
+// TransactionType is the kind of an entry in the transaction history
+type TransactionType string
+
+// History entry types
+const (
+	TransactionTypeTransfer TransactionType = "TRANSFER"
+	TransactionTypeTopup    TransactionType = "TOPUP"
+	TransactionTypePayment  TransactionType = "PAYMENT"
+)
+
+// String returns the type as received
+func (t TransactionType) String() string {
+	return string(t)
+}
+
+// The response fields keep their string type so that existing code comparing or
+// assigning them keeps compiling; these accessors return them as typed values.
+
+// Status returns LatestTransactionStatus as a TransactionStatusCode
+func (r *AdditionalInfoTransferInterBankResponse) Status() TransactionStatusCode {
+	return TransactionStatusCode(r.LatestTransactionStatus)
+}
+
+// Status returns LatestTransactionStatus as a TransactionStatusCode
+func (r *StatusTransferResponse) Status() TransactionStatusCode {
+	return TransactionStatusCode(r.LatestTransactionStatus)
+}
+
+// Status returns LatestTransactionStatus as a TransactionStatusCode
+func (r *AdditionalInfoCustomerTopup) Status() TransactionStatusCode {
+	return TransactionStatusCode(r.LatestTransactionStatus)
+}
+
+// Status returns LatestTransactionStatus as a TransactionStatusCode
+func (r *CustomerTopupStatusResponse) Status() TransactionStatusCode {
+	return TransactionStatusCode(r.LatestTransactionStatus)
+}
+
+// Type returns BalanceType as a BalanceType
+func (a *AccountInfos) Type() BalanceType {
+	return BalanceType(a.BalanceType)
+}
+
+// HistoryStatus returns Status as a HistoryStatus
+func (d *DetailData) HistoryStatus() HistoryStatus {
+	return HistoryStatus(d.Status)
+}
+
+// TransactionType returns Type as a TransactionType
+func (d *DetailData) TransactionType() TransactionType {
+	return TransactionType(d.Type)
+}
+
+// Direction returns AdditionalInfo.DebitCredit as a DebitCredit, or an empty
+// DebitCredit when the entry has no additional info
+func (d *DetailData) Direction() DebitCredit {
+	if d.AdditionalInfo == nil {
+		return ""
+	}
+	return d.AdditionalInfo.Direction()
+}
+
+// Direction returns DebitCredit as a DebitCredit
+func (a *AdditionalInfoDetailData) Direction() DebitCredit {
+	return DebitCredit(a.DebitCredit)
+}
+
+// NewStatusTransferRequest returns the status request for an interbank transfer.
+// referenceNo may be empty when Faspay's response was lost.
+func NewStatusTransferRequest(partnerReferenceNo, referenceNo string) *StatusTransferRequest {
+	return &StatusTransferRequest{
+		OriginalPartnerReferenceNo: partnerReferenceNo,
+		OriginalReferenceNo:        referenceNo,
+		ServiceCode:                string(ServiceCodeTransferInterbank),
+	}
+}
+
+// NewCustomerTopupStatusRequest returns the status request for an e-money top-up.
+// referenceNo may be empty when Faspay's response was lost.
+func NewCustomerTopupStatusRequest(partnerReferenceNo, referenceNo string) *CustomerTopupStatusRequest {
+	return &CustomerTopupStatusRequest{
+		OriginalPartnerReferenceNo: partnerReferenceNo,
+		OriginalReferenceNo:        referenceNo,
+		ServiceCode:                string(ServiceCodeEmoneyTopup),
+	}
+}
+
+// StatusRequest returns the request that checks the status of this transfer, for
+// example when its response was lost
+func (r *TransferInterBankRequest) StatusRequest() *StatusTransferRequest {
+	return NewStatusTransferRequest(r.PartnerReferenceNo, "")
+}
+
+// StatusRequest returns the request that checks the status of this transfer
+func (r *TransferInterBankResponse) StatusRequest() *StatusTransferRequest {
+	return NewStatusTransferRequest(r.PartnerReferenceNo, r.ReferenceNo)
+}
+
+// StatusRequest returns the request that checks the status of this top-up, for
+// example when its response was lost
+func (r *CustomerTopupRequest) StatusRequest() *CustomerTopupStatusRequest {
+	return NewCustomerTopupStatusRequest(r.PartnerReferenceNo, "")
+}
+
+// StatusRequest returns the request that checks the status of this top-up
+func (r *CustomerTopupResponse) StatusRequest() *CustomerTopupStatusRequest {
+	return NewCustomerTopupStatusRequest(r.PartnerReferenceNo, r.ReferenceNo)
+}
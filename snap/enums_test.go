@@ -0,0 +1,93 @@
+package snap
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// TestTransactionStatusCode tests the transaction status enum
+func TestTransactionStatusCode(t *testing.T) {
+	tests := []struct {
+		status   TransactionStatusCode
+		name     string
+		terminal bool
+	}{
+		{status: StatusSuccess, name: "Success", terminal: true},
+		{status: StatusInProgress, name: "In Progress", terminal: false},
+		{status: StatusFailed, name: "Failed", terminal: true},
+		{status: StatusRefunded, name: "Refunded", terminal: true},
+		{status: StatusNotFound, name: "Not Found", terminal: false},
+		{status: "99", name: "99", terminal: false},
+	}
+
+	for _, tt := range tests {
+		if tt.status.String() != tt.name {
+			t.Errorf("Expected %q to be named %q, got %q", string(tt.status), tt.name, tt.status.String())
+		}
+		if tt.status.IsTerminal() != tt.terminal {
+			t.Errorf("Expected IsTerminal of %q to be %v", string(tt.status), tt.terminal)
+		}
+	}
+
+	if TransactionStatusCode("99").IsKnown() || !StatusPaying.IsKnown() {
+		t.Error("Unexpected IsKnown result")
+	}
+}
+
+// TestEnumJSON tests that typed fields round-trip and keep unknown values
+func TestEnumJSON(t *testing.T) {
+	body := `{"originalPartnerReferenceNo":"TRX1","originalReferenceNo":"","serviceCode":"77","transactionDate":"","amount":null,"beneficiaryAccountNo":"","beneficiaryBankCode":"","referenceNumber":"","sourceAccountNo":"","latestTransactionStatus":"09","transactionStatusDesc":"","additionalInfo":null,"responseCode":"2003600","responseMessage":"Successful"}`
+
+	var resp StatusTransferResponse
+	if err := json.Unmarshal([]byte(body), &resp); err != nil {
+		t.Fatalf("Failed to unmarshal: %v", err)
+	}
+	if resp.ServiceCode != "77" || resp.LatestTransactionStatus != "09" {
+		t.Errorf("Expected unknown values to be kept, got %q and %q", resp.ServiceCode, resp.LatestTransactionStatus)
+	}
+
+	data, err := json.Marshal(resp)
+	if err != nil {
+		t.Fatalf("Failed to marshal: %v", err)
+	}
+	var roundTrip map[string]any
+	_ = json.Unmarshal(data, &roundTrip)
+	if roundTrip["serviceCode"] != "77" || roundTrip["latestTransactionStatus"] != "09" {
+		t.Errorf("Expected values to round-trip, got %s", data)
+	}
+
+	var entry DetailData
+	if err := json.Unmarshal([]byte(`{"status":"SUCCESS","type":"TRANSFER","additionalInfo":{"debitCredit":"CREDIT"}}`), &entry); err != nil {
+		t.Fatalf("Failed to unmarshal history entry: %v", err)
+	}
+	if entry.HistoryStatus() != HistoryStatusSuccess || entry.TransactionType() != TransactionTypeTransfer || !entry.Direction().IsCredit() {
+		t.Errorf("Unexpected history entry %+v", entry)
+	}
+	if (&DetailData{}).Direction() != "" {
+		t.Error("Expected no direction for an entry without additional info")
+	}
+
+	var balance AccountInfos
+	if err := json.Unmarshal([]byte(`{"balanceType":"AVAILABLE"}`), &balance); err != nil {
+		t.Fatalf("Failed to unmarshal account info: %v", err)
+	}
+	if balance.Type() != BalanceTypeAvailable {
+		t.Errorf("Expected %s, got %s", BalanceTypeAvailable, balance.Type())
+	}
+}
+
+// TestStatusRequestBuilders tests that status requests get the service code of the operation
+func TestStatusRequestBuilders(t *testing.T) {
+	transfer := (&TransferInterBankResponse{PartnerReferenceNo: "TRX1", ReferenceNo: "53883"}).StatusRequest()
+	if transfer.ServiceCode != string(ServiceCodeTransferInterbank) || transfer.OriginalReferenceNo != "53883" || transfer.OriginalPartnerReferenceNo != "TRX1" {
+		t.Errorf("Unexpected transfer status request %+v", transfer)
+	}
+
+	topup := (&CustomerTopupRequest{PartnerReferenceNo: "TOPUP1"}).StatusRequest()
+	if topup.ServiceCode != string(ServiceCodeEmoneyTopup) || topup.OriginalPartnerReferenceNo != "TOPUP1" {
+		t.Errorf("Unexpected top-up status request %+v", topup)
+	}
+	if err := topup.Validate(); err != nil {
+		t.Errorf("Expected a valid status request, got %v", err)
+	}
+}
@@ -0,0 +1,151 @@
+package snap
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// Environment identifies the Faspay SendMe deployment a client sends requests to
+type Environment string
+
+// Faspay SendMe environments
+const (
+	Dev        Environment = "dev"     // account-dev, the default
+	Sandbox    Environment = "sandbox" // account-staging
+	Production Environment = "prod"    // sendme
+	Custom     Environment = "custom"  // A base URL set with WithBaseURL and no environment
+)
+
+// environmentBaseURLs maps every preset environment to its API base URL
+var environmentBaseURLs = map[Environment]string{
+	Dev:        DefaultBaseURL,
+	Sandbox:    baseUrlSandbox,
+	Production: baseUrlProd,
+}
+
+// ParseEnvironment returns the preset environment named s. Besides the preset names
+// it accepts "development", "staging" and "production".
+func ParseEnvironment(s string) (Environment, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "dev", "development":
+		return Dev, nil
+	case "sandbox", "staging":
+		return Sandbox, nil
+	case "prod", "production":
+		return Production, nil
+	default:
+		return "", fmt.Errorf("unknown environment %q", s)
+	}
+}
+
+// String returns the environment name
+func (e Environment) String() string {
+	return string(e)
+}
+
+// BaseURL returns the API base URL of a preset environment, or an empty string for
+// Custom and unknown environments
+func (e Environment) BaseURL() string {
+	return environmentBaseURLs[e]
+}
+
+// IsProduction reports whether requests in this environment move real money
+func (e Environment) IsProduction() bool {
+	return e == Production
+}
+
+// WithEnvironment selects the environment requests are sent to. It is resolved by
+// NewClient, which rejects unknown environments. Dev is used when no environment
+// and no base URL are given.
+func WithEnvironment(env Environment) ClientOption {
+	return func(c *Client) {
+		c.environment = env
+	}
+}
+
+// WithBaseURL sends requests to baseURL instead of the environment's host, for example
+// a proxy, a mock server or a regional gateway. It takes precedence over WithEnvironment,
+// which then only labels the client; without it the environment is Custom. The URL is
+// validated by NewClient.
+//
+// A path in baseURL, such as "https://gateway.example.com/faspay", is prepended to the
+// endpoint path of each request but left out of the string to sign, which covers the
+// endpoint path only. This suits a proxy that strips its prefix before forwarding to
+// Faspay; a server that verifies signatures over the full path rejects the requests.
+func WithBaseURL(baseURL string) ClientOption {
+	return func(c *Client) {
+		c.baseURL = baseURL
+	}
+}
+
+// DefaultSandboxPartnerIDs are the partner IDs Faspay issues for Dev and Sandbox
+// only, such as the shared test partner ID 99999. NewClient and SetEnv always refuse
+// them with Production.
+var DefaultSandboxPartnerIDs = []string{"99999"}
+
+// WithSandboxPartnerIDs registers more partner IDs that only exist in Dev and Sandbox,
+// on top of DefaultSandboxPartnerIDs. NewClient and SetEnv refuse to use one of them
+// with Production and return an error matching ErrEnvironmentMismatch.
+func WithSandboxPartnerIDs(partnerIDs ...string) ClientOption {
+	return func(c *Client) {
+		c.sandboxPartnerIDs = append(c.sandboxPartnerIDs, partnerIDs...)
+	}
+}
+
+// Environment returns the environment requests are sent to
+func (c *Client) Environment() Environment {
+	env, _ := c.target()
+	return env
+}
+
+// resolveTarget settles the environment and base URL chosen by the options
+func (c *Client) resolveTarget() error {
+	if c.environment != "" && c.environment != Custom {
+		if _, ok := environmentBaseURLs[c.environment]; !ok {
+			return fmt.Errorf("unknown environment %q", c.environment)
+		}
+	}
+
+	if c.baseURL != "" {
+		u, err := url.Parse(c.baseURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid base URL %q: must be an absolute http or https URL", c.baseURL)
+		}
+		c.baseURL = strings.TrimRight(c.baseURL, "/")
+		if c.environment == "" {
+			c.environment = Custom
+		}
+		return c.checkPartnerID(c.environment)
+	}
+
+	if c.environment == Custom {
+		return fmt.Errorf("environment %q requires WithBaseURL", Custom)
+	}
+	if c.environment == "" {
+		c.environment = Dev
+	}
+	c.baseURL = environmentBaseURLs[c.environment]
+	return c.checkPartnerID(c.environment)
+}
+
+// checkPartnerID refuses to run env against a default or registered sandbox partner ID
+func (c *Client) checkPartnerID(env Environment) error {
+	if !env.IsProduction() {
+		return nil
+	}
+	for _, id := range slices.Concat(DefaultSandboxPartnerIDs, c.sandboxPartnerIDs) {
+		if id == c.PartnerId {
+			return fmt.Errorf("%w: partner ID %s is a sandbox partner ID", ErrEnvironmentMismatch, c.PartnerId)
+		}
+	}
+	return nil
+}
+
+// target returns the environment and base URL requests are currently sent to
+func (c *Client) target() (Environment, string) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.environment, c.baseURL
+}
@@ -0,0 +1,137 @@
+package snap
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+)
+
+// TestClientEnvironment tests the environment reported by the client
+func TestClientEnvironment(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []ClientOption
+		want    Environment
+		baseURL string
+	}{
+		{name: "Default", want: Dev, baseURL: DefaultBaseURL},
+		{name: "Sandbox", options: []ClientOption{WithEnvironment(Sandbox)}, want: Sandbox, baseURL: baseUrlSandbox},
+		{name: "CustomBaseURL", options: []ClientOption{WithBaseURL("http://127.0.0.1:9000")}, want: Custom, baseURL: "http://127.0.0.1:9000"},
+		{name: "LabeledBaseURL", options: []ClientOption{WithEnvironment(Sandbox), WithBaseURL("https://proxy.example.com/")}, want: Sandbox, baseURL: "https://proxy.example.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			services, err := NewClient("99999", getTestPrivateKey(), nil, tt.options...)
+			if err != nil {
+				t.Fatalf("Failed to create client: %v", err)
+			}
+			if env := services.Environment(); env != tt.want {
+				t.Errorf("Expected environment %s, got %s", tt.want, env)
+			}
+			if _, baseURL := services.(*Client).target(); baseURL != tt.baseURL {
+				t.Errorf("Expected base URL %s, got %s", tt.baseURL, baseURL)
+			}
+		})
+	}
+
+	if _, err := NewClient("99999", getTestPrivateKey(), nil, WithEnvironment(Custom)); err == nil {
+		t.Error("Expected an error for Custom without a base URL")
+	}
+}
+
+// TestSetEnvBackToDev tests that the deprecated SetEnv can switch back to the dev host
+func TestSetEnvBackToDev(t *testing.T) {
+	client, err := NewClient("12345", getTestPrivateKey(), nil, WithEnvironment(Production))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	if err := client.SetEnv("dev"); err != nil {
+		t.Fatalf("Failed to switch to dev: %v", err)
+	}
+	if env := client.Environment(); env != Dev {
+		t.Errorf("Expected environment %s, got %s", Dev, env)
+	}
+}
+
+// TestSandboxPartnerIDGuard tests that Production refuses a sandbox partner ID
+func TestSandboxPartnerIDGuard(t *testing.T) {
+	_, err := NewClient("99999", getTestPrivateKey(), nil, WithEnvironment(Production))
+	if !errors.Is(err, ErrEnvironmentMismatch) {
+		t.Fatalf("Expected ErrEnvironmentMismatch for the default sandbox partner ID, got %v", err)
+	}
+	_, err = NewClient("88888", getTestPrivateKey(), nil, WithEnvironment(Production), WithSandboxPartnerIDs("88888"))
+	if !errors.Is(err, ErrEnvironmentMismatch) {
+		t.Fatalf("Expected ErrEnvironmentMismatch for a registered partner ID, got %v", err)
+	}
+	_, err = NewClient("99999", getTestPrivateKey(), nil, WithEnvironment(Production), WithBaseURL("http://localhost:8080"))
+	if !errors.Is(err, ErrEnvironmentMismatch) {
+		t.Fatalf("Expected ErrEnvironmentMismatch behind a base URL, got %v", err)
+	}
+
+	client, err := NewClient("99999", getTestPrivateKey(), nil, WithEnvironment(Sandbox))
+	if err != nil {
+		t.Fatalf("Failed to create sandbox client: %v", err)
+	}
+	if err := client.SetEnv("prod"); !errors.Is(err, ErrEnvironmentMismatch) {
+		t.Errorf("Expected SetEnv to refuse production, got %v", err)
+	}
+	if env := client.Environment(); env != Sandbox {
+		t.Errorf("Expected environment to stay %s, got %s", Sandbox, env)
+	}
+
+	if _, err := NewClient("12345", getTestPrivateKey(), nil, WithEnvironment(Production), WithSandboxPartnerIDs("88888")); err != nil {
+		t.Errorf("Expected a production partner ID to be accepted, got %v", err)
+	}
+}
+
+// TestBaseURLPathPrefix tests that a path in the base URL is sent but not signed
+func TestBaseURLPathPrefix(t *testing.T) {
+	publicKey, err := ParsePublicKey(getTestPublicKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sentPath string
+	mockHTTPClient := NewMockClient(func(req *http.Request) (*http.Response, error) {
+		sentPath = req.URL.Path
+		body, _ := io.ReadAll(req.Body)
+		if err := VerifySignature(publicKey, req.Method, EndpointInquiryBalance, body, req.Header.Get("X-TIMESTAMP"), req.Header.Get("X-SIGNATURE")); err != nil {
+			t.Errorf("Expected the signature to cover the endpoint path only, got %v", err)
+		}
+		return MockResponse(http.StatusOK, `{"responseCode":"2001100","responseMessage":"Successful"}`), nil
+	})
+
+	client, err := NewClient("99999", getTestPrivateKey(), nil, WithHTTPClient(mockHTTPClient), WithBaseURL("https://gateway.example.com/faspay/"))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	if _, err := client.InquiryBalance(context.Background(), &InquiryBalanceRequest{AccountNo: "9920017573"}); err != nil {
+		t.Fatalf("InquiryBalance failed: %v", err)
+	}
+	if sentPath != "/faspay"+EndpointInquiryBalance {
+		t.Errorf("Expected the request to be sent to /faspay%s, got %s", EndpointInquiryBalance, sentPath)
+	}
+}
+
+// TestParseEnvironment tests parsing environment names
+func TestParseEnvironment(t *testing.T) {
+	for input, want := range map[string]Environment{
+		"dev":        Dev,
+		"staging":    Sandbox,
+		"Production": Production,
+		"prod":       Production,
+	} {
+		got, err := ParseEnvironment(input)
+		if err != nil || got != want {
+			t.Errorf("ParseEnvironment(%q) = %s, %v; want %s", input, got, err, want)
+		}
+	}
+	if _, err := ParseEnvironment("local"); err == nil {
+		t.Error("Expected an error for an unknown environment")
+	}
+	if Production.BaseURL() != baseUrlProd || !Production.IsProduction() || Sandbox.IsProduction() {
+		t.Error("Unexpected Production presets")
+	}
+}
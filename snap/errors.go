@@ -1,16 +1,49 @@
 package snap
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Sentinel errors for use with errors.Is. They match *Error values by category and
+// the SDK's request errors by kind, so they keep working through fmt.Errorf("%w").
+var (
+	ErrUnauthorized       = errors.New("snap: unauthorized")
+	ErrDuplicateReference = errors.New("snap: duplicate partner reference")
+	ErrInsufficientFunds  = errors.New("snap: insufficient funds")
+	ErrSignature          = errors.New("snap: signature error")
+	ErrTimeout            = errors.New("snap: timeout")
+	ErrServer             = errors.New("snap: server error")
+	ErrInvalidRequest     = errors.New("snap: invalid request")
+
+	// ErrPending is returned by PollStatus when a transaction is still in progress
+	// after MaxWait. It never matches an *Error: Faspay reports a transaction in
+	// progress in a successful response, with a LatestTransactionStatus of
+	// StatusInProgress.
+	ErrPending = errors.New("snap: transaction in progress")
+
+	// ErrEnvironmentMismatch is returned when Production is selected with a sandbox partner ID
+	ErrEnvironmentMismatch = errors.New("snap: production environment with sandbox partner ID")
+)
+
+// SNAP case codes the SDK reacts to
+const (
+	caseInsufficientFunds   = "14" // 403 Insufficient Funds
+	caseTransactionNotFound = "01" // 404 Transaction Not Found
 )
 
 // Error represents an error returned by the Faspay SendMe Snap API
 type Error struct {
-	StatusCode int    // HTTP status code
-	Code       string // Error code returned by the API
-	Message    string // Error message
-	Details    string // Additional error details
+	StatusCode  int    // HTTP status code
+	Code        string // Error code returned by the API
+	ServiceCode string // SNAP service code parsed from Code (e.g. "18")
+	CaseCode    string // SNAP case code parsed from Code (e.g. "00")
+	Message     string // Error message
+	Details     string // Additional error details
 }
 
 // Error returns the error message
@@ -21,42 +54,226 @@ func (e *Error) Error() string {
 	return fmt.Sprintf("Faspay API error (HTTP %d, Code: %s): %s", e.StatusCode, e.Code, e.Message)
 }
 
+// Is reports whether the error matches one of the sentinel errors
+func (e *Error) Is(target error) bool {
+	switch target {
+	case ErrUnauthorized:
+		return e.Category() == CategoryAuth
+	case ErrDuplicateReference:
+		return e.Category() == CategoryDuplicate
+	case ErrInsufficientFunds:
+		return e.StatusCode == http.StatusForbidden && e.CaseCode == caseInsufficientFunds
+	case ErrSignature:
+		return e.Category() == CategoryAuth && strings.Contains(strings.ToLower(e.Message), "signature")
+	case ErrTimeout:
+		return e.Category() == CategoryTimeout
+	case ErrServer:
+		return e.Category() == CategoryServer
+	}
+	return false
+}
+
+// Category classifies the error using the response code catalog, falling back to
+// the HTTP status for codes the catalog does not know
+func (e *Error) Category() ResponseCategory {
+	if info, ok := LookupResponseCode(e.Code); ok {
+		return info.Category
+	}
+	return categoryForStatus(e.StatusCode)
+}
+
+// IsPending reports whether Faspay accepted the request but has not finished processing
+// it, as with the "Request In Progress" codes such as 2021800. The client treats those
+// 2xx responses as successful and returns them without error, so an *Error built by the
+// client is never pending; check LatestTransactionStatus of the response instead.
+func (e *Error) IsPending() bool {
+	return e.Category() == CategoryPending
+}
+
+// IsDuplicate reports whether the partnerReferenceNo or external ID was already used
+func (e *Error) IsDuplicate() bool {
+	return e.Category() == CategoryDuplicate
+}
+
+// Retryable reports whether resending the same request is safe. Timeouts are not:
+// the request may have been processed, so its status must be checked first.
+func (e *Error) Retryable() bool {
+	if info, ok := LookupResponseCode(e.Code); ok {
+		return info.Retryable
+	}
+	return e.StatusCode == http.StatusTooManyRequests || (e.StatusCode >= 500 && e.StatusCode != http.StatusGatewayTimeout)
+}
+
+// retryCandidate reports whether the retry loop considers resending the request: the
+// error is retryable, or a timeout that the loop resends once the status check
+// confirms the request was not processed
+func (e *Error) retryCandidate() bool {
+	return e.Retryable() || e.Category() == CategoryTimeout
+}
+
 // IsAPIError checks if an error is an API error
 func IsAPIError(err error) bool {
-	_, ok := err.(*Error)
-	return ok
+	var apiErr *Error
+	return errors.As(err, &apiErr)
 }
 
 // IsNotFoundError checks if an error is a not found error
 func IsNotFoundError(err error) bool {
-	apiErr, ok := err.(*Error)
-	return ok && apiErr.StatusCode == http.StatusNotFound
+	var apiErr *Error
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
 }
 
 // IsAuthenticationError checks if an error is an authentication error
 func IsAuthenticationError(err error) bool {
-	apiErr, ok := err.(*Error)
-	return ok && apiErr.StatusCode == http.StatusUnauthorized
+	var apiErr *Error
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusUnauthorized
 }
 
-// IsValidationError checks if an error is a validation error
+// IsValidationError checks if an error is a validation error, either reported by
+// the API or found by Validate before the request was sent
 func IsValidationError(err error) bool {
-	apiErr, ok := err.(*Error)
-	return ok && apiErr.StatusCode == http.StatusBadRequest
+	var apiErr *Error
+	var validationErr *ValidationError
+	return errors.As(err, &validationErr) || (errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusBadRequest)
 }
 
 // IsServerError checks if an error is a server error
 func IsServerError(err error) bool {
-	apiErr, ok := err.(*Error)
-	return ok && apiErr.StatusCode >= 500
+	var apiErr *Error
+	return errors.As(err, &apiErr) && apiErr.StatusCode >= 500
+}
+
+// MarshalError is returned when a request body cannot be encoded as JSON
+type MarshalError struct {
+	Err error
+}
+
+// Error returns the error message
+func (e *MarshalError) Error() string {
+	return fmt.Sprintf("error marshaling request body: %v", e.Err)
+}
+
+// Unwrap returns the underlying encoding error
+func (e *MarshalError) Unwrap() error {
+	return e.Err
+}
+
+// SigningError is returned when a request signature cannot be produced
+type SigningError struct {
+	Err error
+}
+
+// Error returns the error message
+func (e *SigningError) Error() string {
+	return fmt.Sprintf("error generating signature: %v", e.Err)
+}
+
+// Unwrap returns the underlying signing error
+func (e *SigningError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is ErrSignature
+func (e *SigningError) Is(target error) bool {
+	return target == ErrSignature
+}
+
+// TransportError is returned when the HTTP request could not be executed, for
+// example because of a DNS, connection or TLS failure or a client timeout
+type TransportError struct {
+	Method string // HTTP method of the failed request
+	URL    string // Full URL of the failed request
+	Err    error  // Underlying error from the HTTP client
+}
+
+// Error returns the error message
+func (e *TransportError) Error() string {
+	return fmt.Sprintf("error executing request %s %s: %v", e.Method, e.URL, e.Err)
+}
+
+// Unwrap returns the underlying HTTP client error
+func (e *TransportError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is ErrTimeout and the transport failed on a timeout
+func (e *TransportError) Is(target error) bool {
+	if target != ErrTimeout {
+		return false
+	}
+	var timeoutErr interface{ Timeout() bool }
+	return errors.As(e.Err, &timeoutErr) && timeoutErr.Timeout()
 }
 
 // NewError creates a new API error
 func NewError(statusCode int, code, message, details string) *Error {
-	return &Error{
+	apiErr := &Error{
 		StatusCode: statusCode,
 		Code:       code,
 		Message:    message,
 		Details:    details,
 	}
+	if _, serviceCode, caseCode, ok := ParseResponseCode(code); ok {
+		apiErr.ServiceCode = serviceCode
+		apiErr.CaseCode = caseCode
+	}
+	return apiErr
+}
+
+// ParseResponseCode splits a 7-digit SNAP response code into its HTTP status,
+// 2-digit service code and 2-digit case code. For example "4011800" yields
+// 401, "18" and "00". ok is false when code is not in the SNAP format.
+func ParseResponseCode(code string) (httpStatus int, serviceCode, caseCode string, ok bool) {
+	if len(code) != 7 {
+		return 0, "", "", false
+	}
+	for _, r := range code {
+		if r < '0' || r > '9' {
+			return 0, "", "", false
+		}
+	}
+	httpStatus, _ = strconv.Atoi(code[:3])
+	return httpStatus, code[3:5], code[5:7], true
+}
+
+// responseStatus holds the fields every SNAP response body carries
+type responseStatus struct {
+	ResponseCode    string `json:"responseCode"`
+	ResponseMessage string `json:"responseMessage"`
+	Details         string `json:"details"`
+	AdditionalInfo  *struct {
+		Message string `json:"message"`
+	} `json:"additionalInfo"`
+}
+
+// checkResponse inspects the HTTP status and the SNAP responseCode of a response
+// body and returns a populated *Error when the call did not succeed.
+func checkResponse(statusCode int, body []byte) *Error {
+	var status responseStatus
+	// Error bodies from gateways and proxies are not always JSON, so a decode
+	// failure only means the HTTP status has to speak for itself.
+	_ = json.Unmarshal(body, &status)
+
+	codeStatus, _, _, isSnapCode := ParseResponseCode(status.ResponseCode)
+	httpSuccess := statusCode >= 200 && statusCode < 300
+	if httpSuccess && (!isSnapCode || codeStatus < 300) {
+		return nil
+	}
+
+	// Some failures are reported with HTTP 200 and only the responseCode tells
+	// them apart, so let the code drive the status in that case.
+	if httpSuccess {
+		statusCode = codeStatus
+	}
+
+	message := status.ResponseMessage
+	if message == "" {
+		message = http.StatusText(statusCode)
+	}
+	details := status.Details
+	if details == "" && status.AdditionalInfo != nil {
+		details = status.AdditionalInfo.Message
+	}
+
+	return NewError(statusCode, status.ResponseCode, message, details)
 }
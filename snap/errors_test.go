@@ -0,0 +1,237 @@
+package snap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+// TestParseResponseCode tests splitting SNAP response codes into their parts
+func TestParseResponseCode(t *testing.T) {
+	tests := []struct {
+		code        string
+		httpStatus  int
+		serviceCode string
+		caseCode    string
+		ok          bool
+	}{
+		{code: "2001800", httpStatus: 200, serviceCode: "18", caseCode: "00", ok: true},
+		{code: "4031814", httpStatus: 403, serviceCode: "18", caseCode: "14", ok: true},
+		{code: "00", ok: false},
+		{code: "40118AB", ok: false},
+		{code: "", ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			httpStatus, serviceCode, caseCode, ok := ParseResponseCode(tt.code)
+			if ok != tt.ok {
+				t.Fatalf("Expected ok to be %v, got %v", tt.ok, ok)
+			}
+			if httpStatus != tt.httpStatus || serviceCode != tt.serviceCode || caseCode != tt.caseCode {
+				t.Errorf("Expected (%d, %s, %s), got (%d, %s, %s)", tt.httpStatus, tt.serviceCode, tt.caseCode, httpStatus, serviceCode, caseCode)
+			}
+		})
+	}
+}
+
+// TestCheckResponse tests mapping response status and body into *Error values
+func TestCheckResponse(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		if err := checkResponse(http.StatusOK, []byte(`{"responseCode":"2001800","responseMessage":"Successful"}`)); err != nil {
+			t.Errorf("Expected no error, got %v", err)
+		}
+	})
+
+	t.Run("LegacySuccessCode", func(t *testing.T) {
+		if err := checkResponse(http.StatusOK, []byte(`{"responseCode":"00","responseMessage":"Success"}`)); err != nil {
+			t.Errorf("Expected no error, got %v", err)
+		}
+	})
+
+	t.Run("FailureCodeWithHTTP200", func(t *testing.T) {
+		err := checkResponse(http.StatusOK, []byte(`{"responseCode":"4031814","responseMessage":"Insufficient Funds"}`))
+		if err == nil {
+			t.Fatal("Expected error, got nil")
+		}
+		if err.StatusCode != http.StatusForbidden {
+			t.Errorf("Expected StatusCode to be 403, got %d", err.StatusCode)
+		}
+		if err.ServiceCode != "18" || err.CaseCode != "14" {
+			t.Errorf("Expected service code 18 and case code 14, got %s and %s", err.ServiceCode, err.CaseCode)
+		}
+		if err.Message != "Insufficient Funds" {
+			t.Errorf("Expected Message to be 'Insufficient Funds', got '%s'", err.Message)
+		}
+	})
+
+	t.Run("NonJSONBody", func(t *testing.T) {
+		err := checkResponse(http.StatusBadGateway, []byte(`<html>Bad Gateway</html>`))
+		if err == nil {
+			t.Fatal("Expected error, got nil")
+		}
+		if err.StatusCode != http.StatusBadGateway {
+			t.Errorf("Expected StatusCode to be 502, got %d", err.StatusCode)
+		}
+		if err.Message != http.StatusText(http.StatusBadGateway) {
+			t.Errorf("Expected Message to be '%s', got '%s'", http.StatusText(http.StatusBadGateway), err.Message)
+		}
+	})
+}
+
+// TestServiceReturnsAPIError tests that service methods surface *Error values
+func TestServiceReturnsAPIError(t *testing.T) {
+	mockHTTPClient := NewMockClient(func(req *http.Request) (*http.Response, error) {
+		return MockResponse(http.StatusUnauthorized, `{"responseCode":"4011800","responseMessage":"Unauthorized. Signature"}`), nil
+	})
+
+	client, err := NewClient("99999", getTestPrivateKey(), nil, WithHTTPClient(mockHTTPClient))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	_, err = client.InquiryBalance(context.Background(), &InquiryBalanceRequest{AccountNo: "9920017573"})
+	if !IsAuthenticationError(err) {
+		t.Fatalf("Expected IsAuthenticationError to return true, got false (%v)", err)
+	}
+	apiErr := err.(*Error)
+	if apiErr.Code != "4011800" || apiErr.ServiceCode != "18" || apiErr.CaseCode != "00" {
+		t.Errorf("Expected code 4011800 with service code 18 and case code 00, got %s, %s, %s", apiErr.Code, apiErr.ServiceCode, apiErr.CaseCode)
+	}
+}
+
+// TestLookupResponseCode tests the response code catalog
+func TestLookupResponseCode(t *testing.T) {
+	tests := []struct {
+		code      string
+		category  ResponseCategory
+		retryable bool
+	}{
+		{code: "2001800", category: CategorySuccess},
+		{code: "2021800", category: CategoryPending},
+		{code: "4011800", category: CategoryAuth},
+		{code: "4091800", category: CategoryDuplicate},
+		{code: "4091801", category: CategoryDuplicate},
+		{code: "4033814", category: CategoryClientError},
+		{code: "5001800", category: CategoryServer, retryable: true},
+		{code: "5041800", category: CategoryTimeout, retryable: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			info, ok := LookupResponseCode(tt.code)
+			if !ok {
+				t.Fatalf("Expected %s to be in the catalog", tt.code)
+			}
+			if info.Category != tt.category {
+				t.Errorf("Expected Category to be %s, got %s", tt.category, info.Category)
+			}
+			if info.Retryable != tt.retryable {
+				t.Errorf("Expected Retryable to be %v, got %v", tt.retryable, info.Retryable)
+			}
+		})
+	}
+
+	if _, ok := LookupResponseCode("4999999"); ok {
+		t.Error("Expected unknown code to be missing from the catalog")
+	}
+}
+
+// TestErrorPredicates tests the category predicates on *Error
+func TestErrorPredicates(t *testing.T) {
+	duplicate := NewError(http.StatusConflict, "4091800", "Conflict", "")
+	if !duplicate.IsDuplicate() || duplicate.Retryable() {
+		t.Errorf("Expected 4091800 to be a non-retryable duplicate")
+	}
+
+	timeout := NewError(http.StatusGatewayTimeout, "5041800", "Timeout", "")
+	if timeout.Category() != CategoryTimeout || timeout.Retryable() {
+		t.Errorf("Expected 5041800 to be a timeout that is not blindly retryable")
+	}
+	if uncatalogued := NewError(http.StatusGatewayTimeout, "", "Gateway Timeout", ""); uncatalogued.Retryable() {
+		t.Errorf("Expected an uncatalogued 504 not to be blindly retryable")
+	}
+
+	pending := NewError(http.StatusAccepted, "2021800", "Request In Progress", "")
+	if !pending.IsPending() || errors.Is(pending, ErrPending) {
+		t.Errorf("Expected 2021800 to be pending without matching ErrPending")
+	}
+
+	unknown := NewError(http.StatusBadGateway, "", "Bad Gateway", "")
+	if unknown.Category() != CategoryServer || !unknown.Retryable() {
+		t.Errorf("Expected uncatalogued 502 to be a retryable server error")
+	}
+}
+
+// TestErrorsIs tests matching wrapped errors against the sentinel errors
+func TestErrorsIs(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		sentinel error
+		want     bool
+	}{
+		{name: "Unauthorized", err: NewError(http.StatusUnauthorized, "4011800", "Unauthorized. Client", ""), sentinel: ErrUnauthorized, want: true},
+		{name: "Signature", err: NewError(http.StatusUnauthorized, "4011800", "Unauthorized. Signature", ""), sentinel: ErrSignature, want: true},
+		{name: "NotSignature", err: NewError(http.StatusUnauthorized, "4011800", "Unauthorized. Client", ""), sentinel: ErrSignature, want: false},
+		{name: "Duplicate", err: NewError(http.StatusConflict, "4091800", "Conflict", ""), sentinel: ErrDuplicateReference, want: true},
+		{name: "InsufficientFunds", err: NewError(http.StatusForbidden, "4031814", "Insufficient Funds", ""), sentinel: ErrInsufficientFunds, want: true},
+		{name: "OtherForbidden", err: NewError(http.StatusForbidden, "4031815", "Transaction Not Permitted", ""), sentinel: ErrInsufficientFunds, want: false},
+		{name: "SigningError", err: &SigningError{Err: errors.New("bad key")}, sentinel: ErrSignature, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wrapped := fmt.Errorf("payout failed: %w", tt.err)
+			if got := errors.Is(wrapped, tt.sentinel); got != tt.want {
+				t.Errorf("Expected errors.Is to return %v, got %v", tt.want, got)
+			}
+		})
+	}
+
+	wrapped := fmt.Errorf("payout failed: %w", NewError(http.StatusUnauthorized, "4011800", "Unauthorized", ""))
+	if !IsAPIError(wrapped) || !IsAuthenticationError(wrapped) {
+		t.Error("Expected helpers to see through wrapped errors")
+	}
+}
+
+// TestRequestErrors tests the typed errors returned before a response is received
+func TestRequestErrors(t *testing.T) {
+	t.Run("TransportError", func(t *testing.T) {
+		mockHTTPClient := NewMockClient(func(req *http.Request) (*http.Response, error) {
+			return nil, context.DeadlineExceeded
+		})
+
+		client, err := NewClient("99999", getTestPrivateKey(), nil, WithHTTPClient(mockHTTPClient))
+		if err != nil {
+			t.Fatalf("Failed to create client: %v", err)
+		}
+
+		_, err = client.InquiryBalance(context.Background(), &InquiryBalanceRequest{AccountNo: "9920017573"})
+		var transportErr *TransportError
+		if !errors.As(err, &transportErr) {
+			t.Fatalf("Expected *TransportError, got %T", err)
+		}
+		if !errors.Is(err, ErrTimeout) {
+			t.Error("Expected errors.Is(err, ErrTimeout) to return true")
+		}
+	})
+
+	t.Run("SigningError", func(t *testing.T) {
+		client, err := NewClient("99999", nil, nil, WithHTTPClient(NewMockClient(nil)), WithSigner(failingSigner{}))
+		if err != nil {
+			t.Fatalf("Failed to create client: %v", err)
+		}
+
+		_, err = client.InquiryBalance(context.Background(), &InquiryBalanceRequest{AccountNo: "9920017573"})
+		var signingErr *SigningError
+		if !errors.As(err, &signingErr) {
+			t.Fatalf("Expected *SigningError, got %T", err)
+		}
+		if !errors.Is(err, ErrSignature) {
+			t.Error("Expected errors.Is(err, ErrSignature) to return true")
+		}
+	})
+}
@@ -0,0 +1,79 @@
+package snap
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// MaxExternalIDLength is the longest X-EXTERNAL-ID SNAP accepts
+const MaxExternalIDLength = 36
+
+// ExternalIDGenerator returns the X-EXTERNAL-ID of a request. Faspay rejects an ID
+// that was already used on the same day, so every call must return a new value of at
+// most MaxExternalIDLength characters.
+type ExternalIDGenerator func() string
+
+// WithClock sets the function the client reads the current time from, for the
+// X-TIMESTAMP header and the default external ID. It lets tests produce exact
+// timestamps and signatures. time.Now is used by default.
+func WithClock(now func() time.Time) ClientOption {
+	return func(c *Client) {
+		c.now = now
+	}
+}
+
+// WithExternalIDGenerator sets the function that generates the X-EXTERNAL-ID of each
+// request attempt. A request whose generated ID is empty or longer than
+// MaxExternalIDLength fails without being sent.
+func WithExternalIDGenerator(generate ExternalIDGenerator) ClientOption {
+	return func(c *Client) {
+		c.externalID = generate
+	}
+}
+
+// externalIDSequence numbers the IDs generated by this process, so that IDs generated
+// in the same millisecond differ even when the random part collides
+var externalIDSequence atomic.Uint32
+
+// Widths of the parts of a default external ID
+const (
+	externalIDMillisDigits   = 13
+	externalIDSequenceDigits = 6
+	externalIDRandomDigits   = 8
+)
+
+// newExternalIDGenerator returns the default generator: a numeric ID made of prefix,
+// the clock's Unix time in milliseconds, a per-process sequence number and random
+// digits. The prefix is dropped when it would make the ID longer than
+// MaxExternalIDLength.
+func newExternalIDGenerator(prefix string, now func() time.Time) ExternalIDGenerator {
+	if len(prefix)+externalIDMillisDigits+externalIDSequenceDigits+externalIDRandomDigits > MaxExternalIDLength {
+		prefix = ""
+	}
+	return func() string {
+		sequence := externalIDSequence.Add(1) % 1_000_000
+		return fmt.Sprintf("%s%013d%06d%08d", prefix, now().UnixMilli()%1e13, sequence, randomDigits())
+	}
+}
+
+// randomDigits returns a cryptographically random number below 10^externalIDRandomDigits
+func randomDigits() uint64 {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		// crypto/rand does not fail on supported platforms; the sequence still keeps IDs apart
+		return 0
+	}
+	return binary.BigEndian.Uint64(b[:]) % 100_000_000
+}
+
+// nextExternalID returns the external ID of a request attempt
+func (c *Client) nextExternalID() (string, error) {
+	id := c.externalID()
+	if id == "" || len(id) > MaxExternalIDLength {
+		return "", fmt.Errorf("invalid external ID %q: must be 1 to %d characters", id, MaxExternalIDLength)
+	}
+	return id, nil
+}
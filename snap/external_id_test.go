@@ -0,0 +1,167 @@
+package snap
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// goldenTime is the fixed clock of the golden signature tests
+var goldenTime = time.Date(2025, 6, 9, 3, 30, 3, 0, time.UTC)
+
+// TestGoldenSignatures tests the exact headers produced for fixed inputs, so that any
+// change to body minification, the string to sign or the signing itself is caught
+func TestGoldenSignatures(t *testing.T) {
+	tests := []struct {
+		name       string
+		call       func(Services) error
+		externalID string
+		signature  string
+	}{
+		{
+			name: "TransferInterBank",
+			call: func(client Services) error {
+				_, err := client.TransferInterBank(context.Background(), &TransferInterBankRequest{
+					PartnerReferenceNo:     "TRX123456789",
+					Amount:                 &Amount{Value: "10000.00", Currency: "IDR"},
+					BeneficiaryAccountName: "John Doe",
+					BeneficiaryAccountNo:   "60004400184",
+					BeneficiaryBankCode:    "008",
+					SourceAccountNo:        "9920017573",
+					TransactionDate:        "2025-06-09T10:30:03+07:00",
+				})
+				return err
+			},
+			externalID: "99999174943740300001",
+			signature:  "HpzYwhLMA0Lo2+L4qqu7Z0/yJGnxz9sh9/OZZNtUyx+TulSmiCM3sIrkJeePPqvoFLVCXohLQdqwN9Lmb2cO8O41Li3dws7ln3G5rZJwkiVuqyabGOVFq+4h71JlublC/fYmz9+QedjeVYJTs/E7Mn8fA8rwKlWjuolkHpKWtWsdIZaSI3zT58YNDUMYurHpk9iLyI9NS7gJHVX9PIMCDdL4QIIIKel4P9S/68KbAxcNovL5sFQbFoE2SzJ+DQt7MM18R9xuX52cLeJyAUhVPL6okXqKR7RaP1E3L47dZA7FuNGoiPey3SFI/ospK/zHksrAZ5f2m/AAzAH/JIyEGA==",
+		},
+		{
+			name: "StatusTransfer",
+			call: func(client Services) error {
+				_, err := client.StatusTransfer(context.Background(), NewStatusTransferRequest("20250609103003235", "53883"))
+				return err
+			},
+			externalID: "99999174943740300002",
+			signature:  "t06ClPI7GeS36NQunQgjDWMNNakjjEhj2O0IGSpwrOcLzaXTyZiOUhRwSTUpkDFs/iJNd88cb5Sv7L3v160wVHG9Uaukj0IAUQAACVbUFu5dvGTJfSmVH4ppefqYbtVBQXdyNDF9OaQMSUd7ajJUG5hDdrzRc/8Ka2HoXVUAAkMVlMiVvSQX/bu9lUPYIJY0xa76x8sxere0Igr++sSrmZbpe+eDcQrdOoXQ7adG21OL7lH+nBnRj1BNckeaHxsAApAFBkpRr0Zacue1Y9h6lGWJ5gCXby/6Tmz5YDyNzJ0J3pzvihg4+LpIWmvfjxg5sH4iVf0/qockasbO8T4NgQ==",
+		},
+		{
+			name: "InquiryBalance",
+			call: func(client Services) error {
+				_, err := client.InquiryBalance(context.Background(), &InquiryBalanceRequest{AccountNo: "9920017573"})
+				return err
+			},
+			externalID: "99999174943740300003",
+			signature:  "PNxC3XTUFw8qtly+lWqnGYGiwyTHc5VgsBwKjSlYZxiwiKz5qGytPbSDvOFU0aR+asJQXAJM1J5qi290I9Cn+je+OJLuFoPte/zFG0UGZ1cvqc1P8MAt034c+qWIcfIS2vV3Iqq+v7yhgKgLUe6hqLB54eoE8nB0aCUjdIV6M39zAy9O45gtq5oWl5sZj9b+aBJbIhxWvB6Sor7BDuInG1vViN/MlXB3V5EATG3dCX9p/7cY5jlUPoocYXodmfZwZN/PFKrYWiQR0z9v/vCA4x4DkhwBgVaDhUZ3ryVtZZQ/JcVmq4+SWpNeXFNaO/OoAomQX+1c3sHK4OcgAEuhNg==",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var header http.Header
+			var path string
+			var body []byte
+			mockHTTPClient := NewMockClient(func(req *http.Request) (*http.Response, error) {
+				header, path = req.Header, req.URL.Path
+				body, _ = io.ReadAll(req.Body)
+				return MockSuccessResponse(), nil
+			})
+			client, err := NewClient("99999", getTestPrivateKey(), nil,
+				WithHTTPClient(mockHTTPClient),
+				WithClock(func() time.Time { return goldenTime }),
+				WithExternalIDGenerator(func() string { return tt.externalID }),
+			)
+			if err != nil {
+				t.Fatalf("Failed to create client: %v", err)
+			}
+
+			_ = tt.call(client)
+			if header == nil {
+				t.Fatal("Expected the request to be sent")
+			}
+			if got := header.Get("X-TIMESTAMP"); got != "2025-06-09T10:30:03+07:00" {
+				t.Errorf("X-TIMESTAMP = %q", got)
+			}
+			if got := header.Get("X-EXTERNAL-ID"); got != tt.externalID {
+				t.Errorf("X-EXTERNAL-ID = %q, expected %q", got, tt.externalID)
+			}
+			if got := header.Get("X-SIGNATURE"); got != tt.signature {
+				t.Errorf("X-SIGNATURE = %q, expected %q", got, tt.signature)
+			}
+
+			publicKey, err := ParsePublicKey(getTestPublicKey())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := VerifySignature(publicKey, http.MethodPost, path, body, header.Get("X-TIMESTAMP"), tt.signature); err != nil {
+				t.Errorf("Expected the golden signature to verify, got %v", err)
+			}
+		})
+	}
+}
+
+// TestDefaultExternalID tests that generated IDs are numeric, short enough and unique under concurrency
+func TestDefaultExternalID(t *testing.T) {
+	generate := newExternalIDGenerator("99999", func() time.Time { return goldenTime })
+
+	const goroutines, perGoroutine = 16, 500
+	var mu sync.Mutex
+	seen := make(map[string]bool, goroutines*perGoroutine)
+	var wg sync.WaitGroup
+	for range goroutines {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range perGoroutine {
+				id := generate()
+				mu.Lock()
+				seen[id] = true
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(seen) != goroutines*perGoroutine {
+		t.Errorf("Expected %d unique IDs with a frozen clock, got %d", goroutines*perGoroutine, len(seen))
+	}
+	for id := range seen {
+		if len(id) > MaxExternalIDLength || !isDigits(id) || !strings.HasPrefix(id, "999991749439803000") {
+			t.Fatalf("Unexpected ID %q", id)
+		}
+		break
+	}
+
+	long := newExternalIDGenerator(strings.Repeat("1", 12), time.Now)()
+	if len(long) > MaxExternalIDLength || strings.HasPrefix(long, "111111111111") {
+		t.Errorf("Expected a long prefix to be dropped, got %q", long)
+	}
+}
+
+// TestWithExternalIDGenerator tests that an invalid generated ID stops the request
+func TestWithExternalIDGenerator(t *testing.T) {
+	sent := false
+	mockHTTPClient := NewMockClient(func(req *http.Request) (*http.Response, error) {
+		sent = true
+		return MockSuccessResponse(), nil
+	})
+
+	for _, id := range []string{"", strings.Repeat("9", MaxExternalIDLength+1)} {
+		client, err := NewClient("99999", getTestPrivateKey(), nil,
+			WithHTTPClient(mockHTTPClient),
+			WithExternalIDGenerator(func() string { return id }),
+		)
+		if err != nil {
+			t.Fatalf("Failed to create client: %v", err)
+		}
+		if _, err := client.InquiryBalance(context.Background(), &InquiryBalanceRequest{AccountNo: "9920017573"}); err == nil {
+			t.Errorf("Expected an error for external ID %q", id)
+		}
+	}
+	if sent {
+		t.Error("Expected no request to be sent")
+	}
+}
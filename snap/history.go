@@ -0,0 +1,170 @@
+package snap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"time"
+)
+
+// DefaultHistoryWindow is the longest date range HistoryAll asks for in one HistoryList call
+const DefaultHistoryWindow = 7 * 24 * time.Hour
+
+// ErrHistoryTruncated is returned by HistoryAll when a one-second window still returns
+// MaxRecords records, so that records may be missing
+var ErrHistoryTruncated = errors.New("snap: history truncated")
+
+// HistoryAllRequest describes the transactions HistoryAll walks
+type HistoryAllRequest struct {
+	AccountNo string
+	From      time.Time // Start of the range, inclusive
+	To        time.Time // End of the range, inclusive
+	// Window is the longest date range requested in one HistoryList call. Use a
+	// shorter window when busy accounts time out. DefaultHistoryWindow is used when
+	// Window is not positive.
+	Window time.Duration
+	// MaxRecords is the number of records at which a HistoryList response is assumed
+	// to be cut off by the API. Such a window is halved and requested again. When it
+	// is zero, responses are taken as complete, and records beyond the API's limit in
+	// a window are silently missing.
+	MaxRecords int
+}
+
+// HistoryAll returns the transactions of an account between req.From and req.To. The
+// range is split into windows of at most req.Window that are requested with
+// client.HistoryList one at a time, oldest first, as the sequence is consumed.
+// Windows do not overlap, but a server that rounds or widens the requested range may
+// return a record at the edge of two windows twice. History entries carry no
+// reference number, so a record equal in every field to one of the previous window
+// is skipped, as many times as it appeared there.
+//
+// The sequence stops after yielding an error: a validation error for the request,
+// the error of a HistoryList call, ErrHistoryTruncated, or the context error when ctx
+// is done. Breaking out of the loop stops further requests.
+//
+//	request := &snap.HistoryAllRequest{AccountNo: "9920017573", From: from, To: to}
+//	for entry, err := range snap.HistoryAll(ctx, client, request) {
+//		if err != nil {
+//			return err
+//		}
+//		fmt.Println(entry.DateTime, entry.Amount.Value)
+//	}
+func HistoryAll(ctx context.Context, client Services, req *HistoryAllRequest) iter.Seq2[*DetailData, error] {
+	return func(yield func(*DetailData, error) bool) {
+		if req == nil {
+			yield(nil, missingRequest())
+			return
+		}
+		window := req.Window
+		if window <= 0 {
+			window = DefaultHistoryWindow
+		}
+
+		from, to := NewTime(req.From).Time, NewTime(req.To).Time
+		request := &HistoryListRequest{AdditionalInfo: &AdditionalHistoryListRequest{AccountNo: req.AccountNo}}
+		request.SetRange(from, to)
+		if err := request.Validate(); err != nil {
+			yield(nil, err)
+			return
+		}
+
+		var previous map[historyKey]int
+		for start := from; !start.After(to); {
+			// Each window ends one second before the next one starts
+			end := start.Add(window - time.Second)
+			if end.After(to) || end.Before(start) {
+				end = to
+			}
+
+			var response *HistoryListResponse
+			for {
+				if err := ctx.Err(); err != nil {
+					yield(nil, err)
+					return
+				}
+				windowRequest := &HistoryListRequest{AdditionalInfo: &AdditionalHistoryListRequest{AccountNo: req.AccountNo}}
+				windowRequest.SetRange(start, end)
+				var err error
+				response, err = client.HistoryList(ctx, windowRequest)
+				if err != nil {
+					yield(nil, err)
+					return
+				}
+				if req.MaxRecords <= 0 || len(response.DetailData) < req.MaxRecords {
+					break
+				}
+				if !end.After(start) {
+					yield(nil, fmt.Errorf("%w: %d records at %s", ErrHistoryTruncated, len(response.DetailData), windowRequest.FromDateTime))
+					return
+				}
+				end = start.Add((end.Sub(start) / 2).Truncate(time.Second))
+			}
+
+			seen := make(map[historyKey]int, len(response.DetailData))
+			for _, entry := range response.DetailData {
+				if entry == nil {
+					continue
+				}
+				key := newHistoryKey(entry)
+				seen[key]++
+				if previous[key] > 0 {
+					previous[key]--
+					continue
+				}
+				if !yield(entry, nil) {
+					return
+				}
+			}
+			previous = seen
+			start = end.Add(time.Second)
+		}
+	}
+}
+
+// HistoryAll returns the transactions of accountNo between from and to, both
+// inclusive, in windows of DefaultHistoryWindow. It calls the HistoryAll function,
+// which takes a HistoryAllRequest to set the window and MaxRecords.
+//
+//	for entry, err := range client.HistoryAll(ctx, "9920017573", from, to) {
+//		if err != nil {
+//			return err
+//		}
+//		fmt.Println(entry.DateTime, entry.Amount.Value)
+//	}
+func (c *Client) HistoryAll(ctx context.Context, accountNo string, from, to time.Time) iter.Seq2[*DetailData, error] {
+	return HistoryAll(ctx, c, &HistoryAllRequest{AccountNo: accountNo, From: from, To: to})
+}
+
+// historyKey identifies a history record when deduplicating window edges
+type historyKey struct {
+	dateTime    string
+	value       string
+	currency    string
+	remark      string
+	status      string
+	kind        string
+	debitCredit string
+	sources     string
+}
+
+func newHistoryKey(entry *DetailData) historyKey {
+	key := historyKey{
+		dateTime: entry.DateTime,
+		remark:   entry.Remark,
+		status:   entry.Status,
+		kind:     entry.Type,
+	}
+	if entry.Amount != nil {
+		key.value, key.currency = entry.Amount.Value, entry.Amount.Currency
+	}
+	if entry.AdditionalInfo != nil {
+		key.debitCredit = entry.AdditionalInfo.DebitCredit
+	}
+	for _, source := range entry.SourceOfFunds {
+		if source != nil {
+			key.sources += source.Source + "\x00"
+		}
+	}
+	return key
+}
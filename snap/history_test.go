@@ -0,0 +1,237 @@
+package snap
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// historyServer answers HistoryList calls with the records of a fixed list that fall
+// in the requested range, cut off at limit records when limit is set. A positive
+// widen makes it return records up to widen outside the requested range, like a
+// server that rounds the range.
+type historyServer struct {
+	mu      sync.Mutex
+	records []time.Time
+	limit   int
+	widen   time.Duration
+	ranges  [][2]string
+}
+
+func (s *historyServer) roundTrip(req *http.Request) (*http.Response, error) {
+	body, _ := io.ReadAll(req.Body)
+	var request HistoryListRequest
+	if err := json.Unmarshal(body, &request); err != nil {
+		return nil, err
+	}
+	from, _ := time.Parse(time.RFC3339, request.FromDateTime)
+	to, _ := time.Parse(time.RFC3339, request.ToDateTime)
+	from, to = from.Add(-s.widen), to.Add(s.widen)
+
+	s.mu.Lock()
+	s.ranges = append(s.ranges, [2]string{request.FromDateTime, request.ToDateTime})
+	s.mu.Unlock()
+
+	var entries []string
+	for _, at := range s.records {
+		if !at.Before(from) && !at.After(to) && (s.limit == 0 || len(entries) < s.limit) {
+			entries = append(entries, fmt.Sprintf(`{"dateTime": %q, "amount": {"value": "10000.00", "currency": "IDR"}, "remark": "TRF", "status": "SUCCESS", "type": "TRANSFER", "additionalInfo": {"debitCredit": "CREDIT"}}`, at.Format(TimestampLayout)))
+		}
+	}
+	return MockResponse(http.StatusOK, `{"responseCode": "2001200", "responseMessage": "Successful", "detailData": [`+strings.Join(entries, ",")+`]}`), nil
+}
+
+func newHistoryClient(t *testing.T, server *historyServer) Services {
+	t.Helper()
+	client, err := NewClient("99999", getTestPrivateKey(), nil, WithHTTPClient(NewMockClient(server.roundTrip)))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	return client
+}
+
+// TestHistoryAll tests that a range is split into windows and boundary records are yielded once
+func TestHistoryAll(t *testing.T) {
+	zone := time.FixedZone("WIB", 7*3600)
+	from := time.Date(2024, 12, 1, 0, 0, 0, 0, zone)
+	to := time.Date(2024, 12, 10, 23, 59, 59, 0, zone)
+
+	server := &historyServer{records: []time.Time{
+		from,
+		time.Date(2024, 12, 2, 12, 0, 0, 0, zone),
+		time.Date(2024, 12, 3, 23, 59, 59, 0, zone), // Last second of the first window
+		time.Date(2024, 12, 4, 0, 0, 0, 0, zone),    // First second of the second window
+		time.Date(2024, 12, 9, 8, 0, 0, 0, zone),
+		to,
+	}}
+	client := newHistoryClient(t, server)
+
+	var got []string
+	request := &HistoryAllRequest{AccountNo: "9920017573", From: from, To: to, Window: 3 * 24 * time.Hour}
+	for entry, err := range HistoryAll(context.Background(), client, request) {
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		got = append(got, entry.DateTime)
+	}
+
+	if len(got) != len(server.records) {
+		t.Fatalf("Expected %d records, got %d: %v", len(server.records), len(got), got)
+	}
+	for i, at := range server.records {
+		if got[i] != at.Format(TimestampLayout) {
+			t.Errorf("Record %d: expected %s, got %s", i, at.Format(TimestampLayout), got[i])
+		}
+	}
+
+	expected := [][2]string{
+		{"2024-12-01T00:00:00+07:00", "2024-12-03T23:59:59+07:00"},
+		{"2024-12-04T00:00:00+07:00", "2024-12-06T23:59:59+07:00"},
+		{"2024-12-07T00:00:00+07:00", "2024-12-09T23:59:59+07:00"},
+		{"2024-12-10T00:00:00+07:00", "2024-12-10T23:59:59+07:00"},
+	}
+	if fmt.Sprint(server.ranges) != fmt.Sprint(expected) {
+		t.Errorf("Expected windows %v, got %v", expected, server.ranges)
+	}
+}
+
+// TestHistoryAllOverlap tests that records returned by two adjacent windows are yielded once
+func TestHistoryAllOverlap(t *testing.T) {
+	zone := time.FixedZone("WIB", 7*3600)
+	from := time.Date(2024, 12, 1, 0, 0, 0, 0, zone)
+	to := from.Add(2*DefaultHistoryWindow - time.Second)
+
+	server := &historyServer{widen: time.Second, records: []time.Time{
+		from.Add(time.Hour),
+		from.Add(DefaultHistoryWindow - time.Second), // Also returned by the second window
+		from.Add(DefaultHistoryWindow),               // Also returned by the first window
+		from.Add(DefaultHistoryWindow + time.Hour),
+	}}
+	client := newHistoryClient(t, server)
+
+	var got []string
+	for entry, err := range client.HistoryAll(context.Background(), "9920017573", from, to) {
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		got = append(got, entry.DateTime)
+	}
+	if len(server.ranges) != 2 {
+		t.Fatalf("Expected 2 windows, got %v", server.ranges)
+	}
+	if len(got) != len(server.records) {
+		t.Fatalf("Expected %d records, got %d: %v", len(server.records), len(got), got)
+	}
+	for i, at := range server.records {
+		if got[i] != at.Format(TimestampLayout) {
+			t.Errorf("Record %d: expected %s, got %s", i, at.Format(TimestampLayout), got[i])
+		}
+	}
+}
+
+// TestHistoryAllTruncated tests that windows returning MaxRecords records are split
+// until they are complete
+func TestHistoryAllTruncated(t *testing.T) {
+	zone := time.FixedZone("WIB", 7*3600)
+	from := time.Date(2024, 12, 1, 0, 0, 0, 0, zone)
+	to := time.Date(2024, 12, 4, 23, 59, 59, 0, zone)
+
+	server := &historyServer{limit: 2, records: []time.Time{
+		from,
+		from.Add(time.Hour),
+		from.Add(2 * time.Hour),
+		time.Date(2024, 12, 3, 8, 0, 0, 0, zone),
+	}}
+	client := newHistoryClient(t, server)
+
+	var got int
+	request := &HistoryAllRequest{AccountNo: "9920017573", From: from, To: to, MaxRecords: 2}
+	for _, err := range HistoryAll(context.Background(), client, request) {
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		got++
+	}
+	if got != len(server.records) {
+		t.Errorf("Expected %d records, got %d from windows %v", len(server.records), got, server.ranges)
+	}
+
+	server = &historyServer{limit: 2, records: []time.Time{from, from}}
+	client = newHistoryClient(t, server)
+	var lastErr error
+	for _, err := range HistoryAll(context.Background(), client, request) {
+		lastErr = err
+	}
+	if !errors.Is(lastErr, ErrHistoryTruncated) {
+		t.Errorf("Expected ErrHistoryTruncated for a full one-second window, got %v", lastErr)
+	}
+}
+
+// TestHistoryAllStops tests that breaking, cancelling and invalid arguments stop the sequence
+func TestHistoryAllStops(t *testing.T) {
+	zone := time.FixedZone("WIB", 7*3600)
+	from := time.Date(2024, 12, 1, 0, 0, 0, 0, zone)
+	to := from.Add(30 * 24 * time.Hour)
+	records := []time.Time{from.Add(time.Hour), from.Add(2 * time.Hour), from.Add(10 * 24 * time.Hour)}
+
+	t.Run("Break", func(t *testing.T) {
+		server := &historyServer{records: records}
+		client := newHistoryClient(t, server)
+		for range HistoryAll(context.Background(), client, &HistoryAllRequest{AccountNo: "9920017573", From: from, To: to}) {
+			break
+		}
+		if len(server.ranges) != 1 {
+			t.Errorf("Expected 1 call, got %d", len(server.ranges))
+		}
+	})
+
+	t.Run("Cancel", func(t *testing.T) {
+		server := &historyServer{records: records}
+		client := newHistoryClient(t, server)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		var entries int
+		var lastErr error
+		for _, err := range HistoryAll(ctx, client, &HistoryAllRequest{AccountNo: "9920017573", From: from, To: to}) {
+			if err != nil {
+				lastErr = err
+				continue
+			}
+			entries++
+			cancel()
+		}
+		if !errors.Is(lastErr, context.Canceled) {
+			t.Errorf("Expected context.Canceled, got %v", lastErr)
+		}
+		if entries != 2 || len(server.ranges) != 1 {
+			t.Errorf("Expected the first window only, got %d records from %d calls", entries, len(server.ranges))
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		server := &historyServer{records: records}
+		client := newHistoryClient(t, server)
+		var errs []error
+		for _, err := range HistoryAll(context.Background(), client, &HistoryAllRequest{From: to, To: from}) {
+			errs = append(errs, err)
+		}
+		var validationErr *ValidationError
+		if len(errs) != 1 || !errors.As(errs[0], &validationErr) {
+			t.Fatalf("Expected a single validation error, got %v", errs)
+		}
+		if len(validationErr.Field("toDateTime")) == 0 || len(validationErr.Field("additionalInfo.accountNo")) == 0 {
+			t.Errorf("Expected toDateTime and accountNo errors, got %v", validationErr)
+		}
+		if len(server.ranges) != 0 {
+			t.Errorf("Expected no call, got %d", len(server.ranges))
+		}
+	})
+}
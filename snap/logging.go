@@ -0,0 +1,201 @@
+package snap
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// RedactionPolicy controls how sensitive values are masked before they are logged
+type RedactionPolicy struct {
+	// Fields lists the JSON field names, matched case-insensitively, whose values are masked
+	Fields []string
+	// Mask returns the masked form of value for the given field
+	Mask func(field, value string) string
+}
+
+// DefaultRedactionPolicy masks account numbers, customer numbers, names, emails and
+// signatures using MaskValue
+func DefaultRedactionPolicy() *RedactionPolicy {
+	return &RedactionPolicy{
+		Fields: []string{
+			"accountNo", "beneficiaryAccountNo", "sourceAccountNo", "sourceAccount",
+			"customerNo", "customerNumber", "virtualAccountNo",
+			"beneficiaryAccountName", "virtualAccountName", "customerName",
+			"beneficiaryEmail", "signature",
+		},
+		Mask: MaskValue,
+	}
+}
+
+// MaskValue masks a sensitive value: emails keep the first character of the local part
+// and the domain, names keep their first character and everything else keeps the last
+// four characters. Characters are counted in runes so that masked values stay valid UTF-8.
+func MaskValue(field, value string) string {
+	if value == "" {
+		return ""
+	}
+	runes := []rune(value)
+	if at := strings.LastIndex(value, "@"); at > 0 {
+		return string(runes[:1]) + "****" + value[at:]
+	}
+	if strings.Contains(strings.ToLower(field), "name") {
+		return string(runes[:1]) + "****"
+	}
+	if len(runes) <= 4 {
+		return "****"
+	}
+	return "****" + string(runes[len(runes)-4:])
+}
+
+// Redact returns the masked form of value when field is covered by the policy
+func (p *RedactionPolicy) Redact(field, value string) string {
+	if p == nil || !p.covers(field) {
+		return value
+	}
+	if p.Mask == nil {
+		return MaskValue(field, value)
+	}
+	return p.Mask(field, value)
+}
+
+// RedactJSON returns a copy of body with the values of covered fields masked at any
+// depth. Bodies that are not valid JSON are replaced by a placeholder so that nothing
+// sensitive can slip through.
+func (p *RedactionPolicy) RedactJSON(body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+
+	var doc any
+	if err := decoder.Decode(&doc); err != nil {
+		return fmt.Sprintf("<non-JSON body omitted, %d bytes>", len(body))
+	}
+
+	redacted, err := json.Marshal(p.redactValue("", doc))
+	if err != nil {
+		return fmt.Sprintf("<body omitted, %d bytes>", len(body))
+	}
+	return string(redacted)
+}
+
+func (p *RedactionPolicy) redactValue(field string, v any) any {
+	switch value := v.(type) {
+	case map[string]any:
+		for key, nested := range value {
+			value[key] = p.redactValue(key, nested)
+		}
+		return value
+	case []any:
+		for i, nested := range value {
+			value[i] = p.redactValue(field, nested)
+		}
+		return value
+	case string:
+		return p.Redact(field, value)
+	case json.Number:
+		if p != nil && p.covers(field) {
+			return p.Redact(field, value.String())
+		}
+		return value
+	default:
+		return value
+	}
+}
+
+func (p *RedactionPolicy) covers(field string) bool {
+	if p == nil || field == "" {
+		return false
+	}
+	for _, f := range p.Fields {
+		if strings.EqualFold(f, field) {
+			return true
+		}
+	}
+	return false
+}
+
+// loggedResponse holds the parts of a response that end up in log records
+type loggedResponse struct {
+	statusCode int
+	body       []byte
+}
+
+// logExchange writes one record per API call: Info on success, Warn when Faspay
+// rejected the request and Error when no response was received. Redacted request
+// and response bodies are attached at Debug level.
+func (c *Client) logExchange(ctx context.Context, path, externalID string, attempt int, reqBody []byte, resp *loggedResponse, latency time.Duration, err error) {
+	level := slog.LevelInfo
+	var apiErr *Error
+	switch {
+	case err == nil:
+	case resp != nil && errors.As(err, &apiErr):
+		level = slog.LevelWarn
+	default:
+		level = slog.LevelError
+	}
+
+	if !c.logger.Enabled(ctx, level) && !c.logger.Enabled(ctx, slog.LevelDebug) {
+		return
+	}
+
+	var reference struct {
+		PartnerReferenceNo         string `json:"partnerReferenceNo"`
+		OriginalPartnerReferenceNo string `json:"originalPartnerReferenceNo"`
+	}
+	_ = json.Unmarshal(reqBody, &reference)
+	if reference.PartnerReferenceNo == "" {
+		reference.PartnerReferenceNo = reference.OriginalPartnerReferenceNo
+	}
+
+	environment, _ := c.target()
+	attrs := []slog.Attr{
+		slog.String("endpoint", path),
+		slog.String("environment", string(environment)),
+		slog.String("external_id", externalID),
+		slog.String("partner_reference_no", reference.PartnerReferenceNo),
+		slog.Int("attempt", attempt),
+		slog.Duration("latency", latency),
+	}
+	if resp != nil {
+		var status responseStatus
+		_ = json.Unmarshal(resp.body, &status)
+		attrs = append(attrs,
+			slog.Int("http_status", resp.statusCode),
+			slog.String("response_code", status.ResponseCode),
+		)
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+
+	c.logger.LogAttrs(ctx, level, "snap request completed", attrs...)
+
+	if c.logger.Enabled(ctx, slog.LevelDebug) {
+		debugAttrs := []slog.Attr{
+			slog.String("endpoint", path),
+			slog.String("external_id", externalID),
+			slog.String("request_body", c.redaction.RedactJSON(reqBody)),
+		}
+		if resp != nil {
+			debugAttrs = append(debugAttrs, slog.String("response_body", c.redaction.RedactJSON(resp.body)))
+		}
+		c.logger.LogAttrs(ctx, slog.LevelDebug, "snap request payload", debugAttrs...)
+	}
+}
+
+// discardHandler is a slog.Handler that drops every record
+type discardHandler struct{}
+
+func (discardHandler) Enabled(context.Context, slog.Level) bool  { return false }
+func (discardHandler) Handle(context.Context, slog.Record) error { return nil }
+func (d discardHandler) WithAttrs([]slog.Attr) slog.Handler      { return d }
+func (d discardHandler) WithGroup(string) slog.Handler           { return d }
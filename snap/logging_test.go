@@ -0,0 +1,93 @@
+package snap
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// TestMaskValue tests the default masking rules
+func TestMaskValue(t *testing.T) {
+	tests := []struct {
+		field string
+		value string
+		want  string
+	}{
+		{field: "beneficiaryAccountNo", value: "60004400184", want: "****0184"},
+		{field: "beneficiaryAccountName", value: "JOHN DOE", want: "J****"},
+		{field: "beneficiaryEmail", value: "john@example.com", want: "j****@example.com"},
+		{field: "accountNo", value: "123", want: "****"},
+		{field: "customerName", value: "Ádám", want: "Á****"},
+		{field: "beneficiaryEmail", value: "élise@example.com", want: "é****@example.com"},
+		{field: "virtualAccountNo", value: "ID-日本語テスト", want: "****語テスト"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.field, func(t *testing.T) {
+			if got := MaskValue(tt.field, tt.value); got != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+// TestRedactJSON tests masking of nested JSON bodies
+func TestRedactJSON(t *testing.T) {
+	policy := DefaultRedactionPolicy()
+
+	redacted := policy.RedactJSON([]byte(`{"partnerReferenceNo":"TRX1","beneficiaryAccountNo":"60004400184","additionalInfo":{"sourceAccount":"9920017573"}}`))
+	if strings.Contains(redacted, "60004400184") || strings.Contains(redacted, "9920017573") {
+		t.Errorf("Expected account numbers to be masked, got %s", redacted)
+	}
+	if !strings.Contains(redacted, "TRX1") {
+		t.Errorf("Expected partnerReferenceNo to be kept, got %s", redacted)
+	}
+
+	if redacted := policy.RedactJSON([]byte("JOHN DOE 60004400184")); strings.Contains(redacted, "60004400184") {
+		t.Errorf("Expected non-JSON body to be omitted, got %s", redacted)
+	}
+}
+
+// TestWithLogger tests the structured records written for each request
+func TestWithLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	mockHTTPClient := NewMockClient(func(req *http.Request) (*http.Response, error) {
+		return MockTransferInterBankSuccessResponse(), nil
+	})
+
+	client, err := NewClient("99999", getTestPrivateKey(), nil, WithHTTPClient(mockHTTPClient), WithLogger(logger))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	_, err = client.TransferInterBank(context.Background(), &TransferInterBankRequest{
+		PartnerReferenceNo:     "TRX123456789",
+		Amount:                 &Amount{Value: "10000.00", Currency: "IDR"},
+		BeneficiaryAccountName: "John Doe",
+		BeneficiaryAccountNo:   "60004400184",
+		BeneficiaryBankCode:    "008",
+		BeneficiaryEmail:       "john@example.com",
+		SourceAccountNo:        "9920017573",
+		TransactionDate:        "2025-06-09T10:30:03+07:00",
+	})
+	if err != nil {
+		t.Fatalf("Failed to call TransferInterBank: %v", err)
+	}
+
+	output := buf.String()
+	for _, want := range []string{`"endpoint":"` + EndpointTransferInterbank + `"`, `"partner_reference_no":"TRX123456789"`, `"response_code":"00"`, `"latency"`, `"external_id":"99999`} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected log output to contain %s, got %s", want, output)
+		}
+	}
+	for _, secret := range []string{"60004400184", "John Doe", "john@example.com", "9920017573"} {
+		if strings.Contains(output, secret) {
+			t.Errorf("Expected %s to be redacted from log output", secret)
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"io"
 	"net/http"
+	"os"
 )
 
 // MockTransport is a mock implementation of http.RoundTripper for testing
@@ -94,3 +95,13 @@ func MockServerErrorResponse() *http.Response {
 func MockNotFoundErrorResponse() *http.Response {
 	return MockErrorResponse(http.StatusNotFound, "404", "Not found", "Resource not found")
 }
+
+// getTestPrivateKey returns the throwaway RSA key stored in testdata
+func getTestPrivateKey() []byte {
+	privateKey, err := os.ReadFile("testdata/private_key.pem")
+	if err != nil {
+		panic(err)
+	}
+
+	return privateKey
+}
@@ -0,0 +1,373 @@
+package snap
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// CurrencyIDR is the currency code of the Indonesian rupiah
+const CurrencyIDR = "IDR"
+
+// Money errors
+var (
+	// ErrCurrencyMismatch is returned when combining or comparing amounts in different currencies
+	ErrCurrencyMismatch = errors.New("snap: currency mismatch")
+	// ErrAmountOutOfRange is returned when an amount does not fit in int64 minor units
+	ErrAmountOutOfRange = errors.New("snap: amount out of range")
+)
+
+// Money is an exact amount of a currency stored in minor units (1/100), so it never
+// suffers from float rounding. The zero value is zero with no currency.
+type Money struct {
+	minor    int64
+	currency string
+}
+
+// IDR returns an amount of whole rupiah, e.g. IDR(150000) is "150000.00" IDR. The
+// error matches ErrAmountOutOfRange when the amount does not fit in minor units.
+func IDR(rupiah int64) (Money, error) {
+	minor, ok := mulMinor(rupiah, 100)
+	if !ok {
+		return Money{}, fmt.Errorf("%w: %d IDR", ErrAmountOutOfRange, rupiah)
+	}
+	return Money{minor: minor, currency: CurrencyIDR}, nil
+}
+
+// MustIDR is like IDR but panics when the amount is out of range. It is meant for
+// constant amounts such as limits and test fixtures.
+func MustIDR(rupiah int64) Money {
+	m, err := IDR(rupiah)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// NewMoney returns an amount of minorUnits hundredths of currency
+func NewMoney(minorUnits int64, currency string) Money {
+	return Money{minor: minorUnits, currency: currency}
+}
+
+// ParseMoney parses an amount in the SNAP wire format ("10000.00"). Values without
+// decimals or with one decimal are accepted; thousand separators, exponents and more
+// than two decimals are rejected.
+func ParseMoney(value, currency string) (Money, error) {
+	minor, err := parseMinorUnits(value)
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{minor: minor, currency: currency}, nil
+}
+
+func parseMinorUnits(value string) (int64, error) {
+	s := strings.TrimSpace(value)
+	negative := strings.HasPrefix(s, "-")
+	if negative {
+		s = s[1:]
+	}
+
+	whole, fraction, hasFraction := strings.Cut(s, ".")
+	if whole == "" || !isDigits(whole) || (hasFraction && (len(fraction) == 0 || len(fraction) > 2 || !isDigits(fraction))) {
+		return 0, fmt.Errorf("invalid amount %q: expected digits with up to two decimals, e.g. \"10000.00\"", value)
+	}
+
+	units, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q: %w", value, ErrAmountOutOfRange)
+	}
+
+	fraction = (fraction + "00")[:2]
+	cents, _ := strconv.ParseInt(fraction, 10, 64)
+
+	// Negative amounts are built downwards so that the smallest int64 can be parsed
+	var minor int64
+	ok := true
+	if negative {
+		minor, ok = mulMinor(-units, 100)
+		if ok {
+			minor, ok = subMinor(minor, cents)
+		}
+	} else {
+		minor, ok = mulMinor(units, 100)
+		if ok {
+			minor, ok = addMinor(minor, cents)
+		}
+	}
+	if !ok {
+		return 0, fmt.Errorf("invalid amount %q: %w", value, ErrAmountOutOfRange)
+	}
+	return minor, nil
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// MinorUnits returns the amount in hundredths of the currency
+func (m Money) MinorUnits() int64 {
+	return m.minor
+}
+
+// Currency returns the ISO 4217 currency code
+func (m Money) Currency() string {
+	return m.currency
+}
+
+// Value returns the amount in the SNAP wire format, e.g. "150000.00"
+func (m Money) Value() string {
+	// The magnitude is unsigned so that the smallest int64 has one
+	minor := uint64(m.minor)
+	sign := ""
+	if m.minor < 0 {
+		sign = "-"
+		minor = -minor
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, minor/100, minor%100)
+}
+
+// String returns the amount followed by its currency, e.g. "150000.00 IDR"
+func (m Money) String() string {
+	if m.currency == "" {
+		return m.Value()
+	}
+	return m.Value() + " " + m.currency
+}
+
+// Add returns m + other
+func (m Money) Add(other Money) (Money, error) {
+	if err := m.sameCurrency(other); err != nil {
+		return Money{}, err
+	}
+	minor, ok := addMinor(m.minor, other.minor)
+	if !ok {
+		return Money{}, fmt.Errorf("%w: %s + %s", ErrAmountOutOfRange, m.Value(), other.Value())
+	}
+	return Money{minor: minor, currency: m.currency}, nil
+}
+
+// Sub returns m - other
+func (m Money) Sub(other Money) (Money, error) {
+	if err := m.sameCurrency(other); err != nil {
+		return Money{}, err
+	}
+	minor, ok := subMinor(m.minor, other.minor)
+	if !ok {
+		return Money{}, fmt.Errorf("%w: %s - %s", ErrAmountOutOfRange, m.Value(), other.Value())
+	}
+	return Money{minor: minor, currency: m.currency}, nil
+}
+
+// Mul returns m multiplied by n. The error matches ErrAmountOutOfRange when the
+// product does not fit in minor units.
+func (m Money) Mul(n int64) (Money, error) {
+	minor, ok := mulMinor(m.minor, n)
+	if !ok {
+		return Money{}, fmt.Errorf("%w: %s * %d", ErrAmountOutOfRange, m.Value(), n)
+	}
+	return Money{minor: minor, currency: m.currency}, nil
+}
+
+// addMinor returns a + b, reporting false when the sum overflows int64
+func addMinor(a, b int64) (int64, bool) {
+	sum := a + b
+	return sum, (b >= 0) == (sum >= a)
+}
+
+// subMinor returns a - b, reporting false when the difference overflows int64
+func subMinor(a, b int64) (int64, bool) {
+	diff := a - b
+	return diff, (b >= 0) == (diff <= a)
+}
+
+// mulMinor returns a * b, reporting false when the product overflows int64
+func mulMinor(a, b int64) (int64, bool) {
+	if a == 0 || b == 0 {
+		return 0, true
+	}
+	product := a * b
+	if product/b != a || (a == -1 && b == math.MinInt64) || (b == -1 && a == math.MinInt64) {
+		return 0, false
+	}
+	return product, true
+}
+
+// Neg returns -m. The error matches ErrAmountOutOfRange for the smallest amount,
+// whose negation does not fit in minor units.
+func (m Money) Neg() (Money, error) {
+	if m.minor == math.MinInt64 {
+		return Money{}, fmt.Errorf("%w: -(%s)", ErrAmountOutOfRange, m.Value())
+	}
+	return Money{minor: -m.minor, currency: m.currency}, nil
+}
+
+// Cmp compares m and other and returns -1, 0 or +1
+func (m Money) Cmp(other Money) (int, error) {
+	if err := m.sameCurrency(other); err != nil {
+		return 0, err
+	}
+	switch {
+	case m.minor < other.minor:
+		return -1, nil
+	case m.minor > other.minor:
+		return 1, nil
+	default:
+		return 0, nil
+	}
+}
+
+// Equal reports whether m and other have the same amount and currency
+func (m Money) Equal(other Money) bool {
+	return m == other
+}
+
+// IsZero reports whether the amount is zero
+func (m Money) IsZero() bool {
+	return m.minor == 0
+}
+
+// IsNegative reports whether the amount is below zero
+func (m Money) IsNegative() bool {
+	return m.minor < 0
+}
+
+// IsPositive reports whether the amount is above zero
+func (m Money) IsPositive() bool {
+	return m.minor > 0
+}
+
+func (m Money) sameCurrency(other Money) error {
+	if m.currency != other.currency {
+		return fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.currency, other.currency)
+	}
+	return nil
+}
+
+// Amount returns m as a SNAP amount object
+func (m Money) Amount() *Amount {
+	return &Amount{Value: m.Value(), Currency: m.currency}
+}
+
+// MarshalJSON encodes m as a SNAP amount object: {"value":"150000.00","currency":"IDR"}
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(m.Amount())
+}
+
+// UnmarshalJSON decodes a SNAP amount object into m
+func (m *Money) UnmarshalJSON(data []byte) error {
+	var amount Amount
+	if err := json.Unmarshal(data, &amount); err != nil {
+		return err
+	}
+	parsed, err := amount.Money()
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// NewAmount returns a SNAP amount object for m
+func NewAmount(m Money) *Amount {
+	return m.Amount()
+}
+
+// Money parses the amount into an exact Money value
+func (a *Amount) Money() (Money, error) {
+	if a == nil {
+		return Money{}, errors.New("amount is missing")
+	}
+	return ParseMoney(a.Value, a.Currency)
+}
+
+// Money parses the available balance into an exact Money value
+func (a *AvailableBalance) Money() (Money, error) {
+	if a == nil {
+		return Money{}, errors.New("available balance is missing")
+	}
+	return ParseMoney(a.Value, a.Currency)
+}
+
+// amountJSON is the wire form of Amount and AvailableBalance
+type amountJSON struct {
+	Value    string `json:"value"`
+	Currency string `json:"currency"`
+}
+
+// MarshalJSON encodes the amount, normalizing valid values to the two-decimal SNAP
+// format so "10000" is sent as "10000.00"
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return json.Marshal(amountJSON{Value: canonicalValue(a.Value), Currency: a.Currency})
+}
+
+// UnmarshalJSON decodes the amount, accepting values sent as strings or numbers
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	value, currency, err := decodeAmount(data)
+	if err != nil {
+		return err
+	}
+	a.Value, a.Currency = value, currency
+	return nil
+}
+
+// MarshalJSON encodes the balance, normalizing valid values to the two-decimal SNAP format
+func (a AvailableBalance) MarshalJSON() ([]byte, error) {
+	return json.Marshal(amountJSON{Value: canonicalValue(a.Value), Currency: a.Currency})
+}
+
+// UnmarshalJSON decodes the balance, accepting values sent as strings or numbers
+func (a *AvailableBalance) UnmarshalJSON(data []byte) error {
+	value, currency, err := decodeAmount(data)
+	if err != nil {
+		return err
+	}
+	a.Value, a.Currency = value, currency
+	return nil
+}
+
+// decodeAmount reads a SNAP amount object whose value may be a string or a number
+func decodeAmount(data []byte) (string, string, error) {
+	var raw struct {
+		Value    json.RawMessage `json:"value"`
+		Currency string          `json:"currency"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return "", "", err
+	}
+
+	var value string
+	switch {
+	case len(raw.Value) == 0 || bytes.Equal(raw.Value, []byte("null")):
+	case raw.Value[0] == '"':
+		if err := json.Unmarshal(raw.Value, &value); err != nil {
+			return "", "", err
+		}
+	default:
+		var number json.Number
+		if err := json.Unmarshal(raw.Value, &number); err != nil {
+			return "", "", fmt.Errorf("invalid amount value %s", raw.Value)
+		}
+		value = number.String()
+	}
+
+	return canonicalValue(value), raw.Currency, nil
+}
+
+// canonicalValue normalizes value to the two-decimal SNAP format when it parses,
+// leaving anything else untouched for validation to report
+func canonicalValue(value string) string {
+	minor, err := parseMinorUnits(value)
+	if err != nil {
+		return value
+	}
+	return Money{minor: minor}.Value()
+}
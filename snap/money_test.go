@@ -0,0 +1,163 @@
+package snap
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+)
+
+// TestParseMoney tests parsing amounts in the SNAP wire format
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		value   string
+		minor   int64
+		wantErr bool
+	}{
+		{value: "10000.00", minor: 1000000},
+		{value: "10000", minor: 1000000},
+		{value: "10000.5", minor: 1000050},
+		{value: "0.01", minor: 1},
+		{value: "-250.75", minor: -25075},
+		{value: "10,000.00", wantErr: true},
+		{value: "10000.001", wantErr: true},
+		{value: "1e5", wantErr: true},
+		{value: "", wantErr: true},
+		{value: "10000.", wantErr: true},
+		{value: "92233720368547758.07", minor: math.MaxInt64},
+		{value: "92233720368547758.08", wantErr: true},
+		{value: "92233720368547758.99", wantErr: true},
+		{value: "92233720368547759", wantErr: true},
+		{value: "-92233720368547758.08", minor: math.MinInt64},
+		{value: "-92233720368547758.09", wantErr: true},
+		{value: "9223372036854775808", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			m, err := ParseMoney(tt.value, CurrencyIDR)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Expected %q to be rejected", tt.value)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Failed to parse %q: %v", tt.value, err)
+			}
+			if m.MinorUnits() != tt.minor {
+				t.Errorf("Expected %d minor units, got %d", tt.minor, m.MinorUnits())
+			}
+		})
+	}
+}
+
+// TestMoneyArithmetic tests arithmetic, comparison and formatting
+func TestMoneyArithmetic(t *testing.T) {
+	fee := NewMoney(650, CurrencyIDR)
+	total, err := MustIDR(150000).Add(fee)
+	if err != nil {
+		t.Fatalf("Failed to add: %v", err)
+	}
+	if total.Value() != "150006.50" {
+		t.Errorf("Expected 150006.50, got %s", total.Value())
+	}
+	if total.String() != "150006.50 IDR" {
+		t.Errorf("Expected '150006.50 IDR', got %s", total.String())
+	}
+
+	diff, _ := MustIDR(100).Sub(MustIDR(250))
+	if !diff.IsNegative() || diff.Value() != "-150.00" {
+		t.Errorf("Expected -150.00, got %s", diff.Value())
+	}
+
+	if cmp, _ := MustIDR(5).Cmp(MustIDR(10)); cmp != -1 {
+		t.Errorf("Expected -1, got %d", cmp)
+	}
+	if product, err := MustIDR(3).Mul(4); err != nil || !product.Equal(MustIDR(12)) {
+		t.Errorf("Expected IDR(3)*4 to equal IDR(12), got %v, %v", product, err)
+	}
+
+	if _, err := MustIDR(1).Add(NewMoney(100, "USD")); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("Expected ErrCurrencyMismatch, got %v", err)
+	}
+}
+
+// TestMoneyOverflow tests that arithmetic beyond int64 minor units fails instead of wrapping
+func TestMoneyOverflow(t *testing.T) {
+	largest := NewMoney(math.MaxInt64, CurrencyIDR)
+	smallest := NewMoney(math.MinInt64, CurrencyIDR)
+	if _, err := IDR(math.MaxInt64 / 10); !errors.Is(err, ErrAmountOutOfRange) {
+		t.Errorf("IDR: expected ErrAmountOutOfRange, got %v", err)
+	}
+	if _, err := MustIDR(1 << 40).Mul(1 << 30); !errors.Is(err, ErrAmountOutOfRange) {
+		t.Errorf("Mul: expected ErrAmountOutOfRange, got %v", err)
+	}
+	if _, err := smallest.Mul(-1); !errors.Is(err, ErrAmountOutOfRange) {
+		t.Errorf("Mul: expected ErrAmountOutOfRange for the smallest amount, got %v", err)
+	}
+	if _, err := largest.Add(NewMoney(1, CurrencyIDR)); !errors.Is(err, ErrAmountOutOfRange) {
+		t.Errorf("Add: expected ErrAmountOutOfRange, got %v", err)
+	}
+	if _, err := smallest.Sub(NewMoney(1, CurrencyIDR)); !errors.Is(err, ErrAmountOutOfRange) {
+		t.Errorf("Sub: expected ErrAmountOutOfRange, got %v", err)
+	}
+	if diff, err := NewMoney(-1, CurrencyIDR).Sub(smallest); err != nil || diff != largest {
+		t.Errorf("Sub: expected the largest amount, got %v, %v", diff, err)
+	}
+	if _, err := IDR(math.MaxInt64 / 100); err != nil {
+		t.Errorf("IDR: expected the largest whole amount to fit, got %v", err)
+	}
+	if _, err := smallest.Neg(); !errors.Is(err, ErrAmountOutOfRange) {
+		t.Errorf("Neg: expected ErrAmountOutOfRange, got %v", err)
+	}
+	if negated, err := largest.Neg(); err != nil || negated.MinorUnits() != -math.MaxInt64 {
+		t.Errorf("Neg: expected the negated largest amount, got %v, %v", negated, err)
+	}
+	if value := smallest.Value(); value != "-92233720368547758.08" {
+		t.Errorf("Value: expected -92233720368547758.08 for the smallest amount, got %s", value)
+	}
+	if _, err := ParseMoney("92233720368547758.99", CurrencyIDR); !errors.Is(err, ErrAmountOutOfRange) {
+		t.Errorf("ParseMoney: expected ErrAmountOutOfRange, got %v", err)
+	}
+}
+
+// TestAmountJSON tests that Amount keeps its wire format and accepts Money
+func TestAmountJSON(t *testing.T) {
+	data, err := json.Marshal(MustIDR(150000).Amount())
+	if err != nil {
+		t.Fatalf("Failed to marshal: %v", err)
+	}
+	if string(data) != `{"value":"150000.00","currency":"IDR"}` {
+		t.Errorf("Unexpected JSON %s", data)
+	}
+
+	// Existing callers building Amount by hand keep working and get normalized values
+	data, _ = json.Marshal(&TransferInterBankRequest{Amount: &Amount{Value: "10000", Currency: "IDR"}})
+	var decoded struct {
+		Amount Amount `json:"amount"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Failed to unmarshal: %v", err)
+	}
+	if decoded.Amount.Value != "10000.00" {
+		t.Errorf("Expected 10000.00, got %s", decoded.Amount.Value)
+	}
+
+	var numeric Amount
+	if err := json.Unmarshal([]byte(`{"value":76860,"currency":"IDR"}`), &numeric); err != nil {
+		t.Fatalf("Failed to unmarshal numeric value: %v", err)
+	}
+	m, err := numeric.Money()
+	if err != nil || !m.Equal(MustIDR(76860)) {
+		t.Errorf("Expected IDR 76860, got %v (%v)", m, err)
+	}
+
+	var money Money
+	if err := json.Unmarshal([]byte(`{"value":"41454.00","currency":"IDR"}`), &money); err != nil {
+		t.Fatalf("Failed to unmarshal Money: %v", err)
+	}
+	if !money.Equal(MustIDR(41454)) {
+		t.Errorf("Expected IDR 41454, got %s", money)
+	}
+}
@@ -0,0 +1,118 @@
+package snap
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// NameMatcher reports whether the account name returned by Faspay matches the name
+// the caller expects
+type NameMatcher func(expected, actual string) bool
+
+// DefaultNameMatchThreshold is the similarity FuzzyNameMatcher requires when no other
+// threshold is given
+const DefaultNameMatchThreshold = 0.85
+
+// minTruncatedNameLength is the shortest inquired name accepted as a bank-truncated
+// prefix of the expected name
+const minTruncatedNameLength = 10
+
+// honorifics are dropped before names are compared
+var honorifics = map[string]bool{
+	"BPK": true, "BAPAK": true, "IBU": true, "SDR": true, "SDRI": true,
+	"MR": true, "MRS": true, "MS": true, "TN": true, "NY": true, "NN": true,
+	"PT": true, "CV": true, "TBK": true, "UD": true,
+}
+
+// ExactNameMatcher matches names that are equal after normalization: case, punctuation,
+// repeated spaces and honorifics such as "Bpk" or "PT" are ignored
+func ExactNameMatcher(expected, actual string) bool {
+	return normalizeName(expected) == normalizeName(actual)
+}
+
+// FuzzyNameMatcher returns a matcher that accepts names whose similarity is at least
+// threshold, between 0 and 1. Names are normalized as in ExactNameMatcher, word order
+// is ignored, and an inquired name that the bank truncated is accepted when the
+// expected name starts with it.
+func FuzzyNameMatcher(threshold float64) NameMatcher {
+	return func(expected, actual string) bool {
+		return NameSimilarity(expected, actual) >= threshold
+	}
+}
+
+// NameSimilarity scores how alike two account names are, from 0 for nothing in common
+// to 1 for names that are equal after normalization
+func NameSimilarity(expected, actual string) float64 {
+	a, b := normalizeName(expected), normalizeName(actual)
+	if a == "" || b == "" {
+		return 0
+	}
+	if a == b {
+		return 1
+	}
+	if len(b) >= minTruncatedNameLength && strings.HasPrefix(a, b) {
+		return 1
+	}
+
+	score := similarity(a, b)
+	if sorted := similarity(sortWords(a), sortWords(b)); sorted > score {
+		score = sorted
+	}
+	return score
+}
+
+// normalizeName upper-cases name, replaces punctuation by spaces and drops honorifics
+func normalizeName(name string) string {
+	words := strings.FieldsFunc(strings.ToUpper(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	kept := words[:0]
+	for _, word := range words {
+		if !honorifics[word] {
+			kept = append(kept, word)
+		}
+	}
+	return strings.Join(kept, " ")
+}
+
+func sortWords(name string) string {
+	words := strings.Fields(name)
+	slices.Sort(words)
+	return strings.Join(words, " ")
+}
+
+// similarity is 1 minus the Levenshtein distance divided by the longer length
+func similarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	longest := len(ra)
+	if len(rb) > longest {
+		longest = len(rb)
+	}
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(levenshtein(ra, rb))/float64(longest)
+}
+
+func levenshtein(a, b []rune) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
@@ -0,0 +1,262 @@
+package snap
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// ErrUnknownPlatform is returned for a platformCode a PlatformRegistry does not list
+var ErrUnknownPlatform = errors.New("unknown e-money platform")
+
+// PlatformCode identifies the e-money platform of a CustomerTopup, sent as
+// additionalInfo.platformCode
+type PlatformCode string
+
+// E-money platforms supported by Faspay
+const (
+	PlatformGoPay     PlatformCode = "gpy"
+	PlatformOVO       PlatformCode = "ovo"
+	PlatformDANA      PlatformCode = "dna"
+	PlatformShopeePay PlatformCode = "spy"
+	PlatformLinkAja   PlatformCode = "lja"
+)
+
+// String returns the display name of the platform, or the raw code when it is unknown
+func (p PlatformCode) String() string {
+	if platform, ok := LookupPlatform(p); ok {
+		return platform.Name
+	}
+	return string(p)
+}
+
+// CustomerNumberFormat is the phone number format a platform expects as customerNumber
+type CustomerNumberFormat string
+
+// Customer number formats
+const (
+	CustomerNumberLocal         CustomerNumberFormat = "local"         // 08xx
+	CustomerNumberInternational CustomerNumberFormat = "international" // 628xx
+)
+
+// Mobile numbers are 08 followed by 8 to 11 digits
+const (
+	minMobileNumberDigits = 10
+	maxMobileNumberDigits = 13
+)
+
+// EmoneyPlatform describes an e-money platform that can be topped up with CustomerTopup
+type EmoneyPlatform struct {
+	Code                 PlatformCode
+	Name                 string
+	CustomerNumberFormat CustomerNumberFormat // Format of customerNumber; the zero value is CustomerNumberLocal
+	MinAmount            Money                // Smallest top-up accepted; zero for no minimum
+	MaxAmount            Money                // Largest top-up accepted; zero for no maximum
+}
+
+// NormalizeCustomerNumber returns number in the format the platform expects. Spaces,
+// dashes, dots and a leading "+" are removed, and 08xx, 628xx, +628xx and 8xx numbers
+// are converted to the platform's format.
+func (p EmoneyPlatform) NormalizeCustomerNumber(number string) (string, error) {
+	subscriber, ok := mobileSubscriberNumber(number)
+	if !ok {
+		return "", fmt.Errorf("invalid mobile number %q for %s", number, p.Name)
+	}
+	if p.CustomerNumberFormat == CustomerNumberInternational {
+		return "62" + subscriber, nil
+	}
+	return "0" + subscriber, nil
+}
+
+// CheckAmount returns an error when amount is outside the platform's top-up limits
+func (p EmoneyPlatform) CheckAmount(amount Money) error {
+	if !p.MinAmount.IsZero() {
+		if cmp, err := amount.Cmp(p.MinAmount); err != nil {
+			return err
+		} else if cmp < 0 {
+			return fmt.Errorf("is below the %s minimum of %s", p.Name, p.MinAmount)
+		}
+	}
+	if !p.MaxAmount.IsZero() {
+		if cmp, err := amount.Cmp(p.MaxAmount); err != nil {
+			return err
+		} else if cmp > 0 {
+			return fmt.Errorf("is above the %s maximum of %s", p.Name, p.MaxAmount)
+		}
+	}
+	return nil
+}
+
+// PlatformRegistry is an immutable set of e-money platforms indexed by code. It is
+// safe for concurrent use. A top-up to a platform the registry does not list is sent
+// as is, without customer number or amount checks.
+type PlatformRegistry struct {
+	platforms map[PlatformCode]EmoneyPlatform
+}
+
+// defaultPlatforms lists the platforms Faspay documents. Faspay publishes neither
+// per-platform top-up limits nor customer number formats, so no limits are checked
+// and every platform takes the local 08xx format used in Faspay's examples.
+var defaultPlatforms = &PlatformRegistry{platforms: map[PlatformCode]EmoneyPlatform{
+	PlatformGoPay:     {Code: PlatformGoPay, Name: "GoPay"},
+	PlatformOVO:       {Code: PlatformOVO, Name: "OVO"},
+	PlatformDANA:      {Code: PlatformDANA, Name: "DANA"},
+	PlatformShopeePay: {Code: PlatformShopeePay, Name: "ShopeePay"},
+	PlatformLinkAja:   {Code: PlatformLinkAja, Name: "LinkAja"},
+}}
+
+// DefaultPlatforms returns the registry of the platforms built into the SDK. It knows
+// the platform codes and names only: it checks no top-up limits and sends every
+// customer number in the local 08xx format. Callers that need limits, or that top up
+// a platform expecting 628xx numbers, must register them with Extend and pass the
+// result to WithPlatformRegistry.
+func DefaultPlatforms() *PlatformRegistry {
+	return defaultPlatforms
+}
+
+// NewPlatformRegistry builds a registry from platforms. Codes must be set and unique.
+func NewPlatformRegistry(platforms ...EmoneyPlatform) (*PlatformRegistry, error) {
+	registry := &PlatformRegistry{platforms: make(map[PlatformCode]EmoneyPlatform, len(platforms))}
+	for _, platform := range platforms {
+		if _, ok := registry.platforms[platform.Code]; ok {
+			return nil, fmt.Errorf("duplicate platform code %q", platform.Code)
+		}
+		if err := registry.add(platform); err != nil {
+			return nil, err
+		}
+	}
+	return registry, nil
+}
+
+// Extend returns a new registry holding the platforms of r plus platforms, for
+// example when Faspay enables a platform before the SDK lists it or when the top-up
+// limits of a merchant account are known. A platform whose code is already known
+// replaces the existing entry. r is not modified.
+func (r *PlatformRegistry) Extend(platforms ...EmoneyPlatform) (*PlatformRegistry, error) {
+	extended := &PlatformRegistry{platforms: maps.Clone(r.platforms)}
+	if extended.platforms == nil {
+		extended.platforms = make(map[PlatformCode]EmoneyPlatform, len(platforms))
+	}
+	for _, platform := range platforms {
+		if err := extended.add(platform); err != nil {
+			return nil, err
+		}
+	}
+	return extended, nil
+}
+
+func (r *PlatformRegistry) add(platform EmoneyPlatform) error {
+	if platform.Code == "" {
+		return errors.New("platform code is required")
+	}
+	if platform.Name == "" {
+		platform.Name = string(platform.Code)
+	}
+	switch platform.CustomerNumberFormat {
+	case "", CustomerNumberLocal, CustomerNumberInternational:
+	default:
+		return fmt.Errorf("unknown customer number format %q for platform %q", platform.CustomerNumberFormat, platform.Code)
+	}
+	r.platforms[platform.Code] = platform
+	return nil
+}
+
+// Lookup returns the platform with the given code
+func (r *PlatformRegistry) Lookup(code PlatformCode) (EmoneyPlatform, bool) {
+	platform, ok := r.platforms[code]
+	return platform, ok
+}
+
+// All returns the platforms ordered by code
+func (r *PlatformRegistry) All() []EmoneyPlatform {
+	list := slices.Collect(maps.Values(r.platforms))
+	slices.SortFunc(list, func(a, b EmoneyPlatform) int { return strings.Compare(string(a.Code), string(b.Code)) })
+	return list
+}
+
+// NormalizeCustomerNumber returns number in the format the platform with the given
+// code expects as customerNumber. It returns an error matching ErrUnknownPlatform
+// when the registry does not list the platform.
+func (r *PlatformRegistry) NormalizeCustomerNumber(code PlatformCode, number string) (string, error) {
+	platform, ok := r.Lookup(code)
+	if !ok {
+		return "", fmt.Errorf("%w %q", ErrUnknownPlatform, code)
+	}
+	return platform.NormalizeCustomerNumber(number)
+}
+
+// normalizeTopup returns request with its customer number in the format of its
+// platform. request is copied rather than modified; it is returned unchanged when
+// the platform is not listed or the number cannot be normalized.
+func (r *PlatformRegistry) normalizeTopup(request *CustomerTopupRequest) *CustomerTopupRequest {
+	if request.AdditionalInfo == nil {
+		return request
+	}
+	normalized, err := r.NormalizeCustomerNumber(PlatformCode(request.AdditionalInfo.PlatformCode), request.CustomerNumber)
+	if err != nil || normalized == request.CustomerNumber {
+		return request
+	}
+	normalizedRequest := *request
+	normalizedRequest.CustomerNumber = normalized
+	return &normalizedRequest
+}
+
+// WithPlatformRegistry checks and normalizes CustomerTopup requests with the platforms
+// of registry instead of DefaultPlatforms
+func WithPlatformRegistry(registry *PlatformRegistry) ClientOption {
+	return func(c *Client) {
+		c.platforms = registry
+	}
+}
+
+// LookupPlatform returns the platform with the given code from DefaultPlatforms
+func LookupPlatform(code PlatformCode) (EmoneyPlatform, bool) {
+	return defaultPlatforms.Lookup(code)
+}
+
+// Platforms returns the platforms of DefaultPlatforms ordered by code
+func Platforms() []EmoneyPlatform {
+	return defaultPlatforms.All()
+}
+
+// NormalizeCustomerNumber returns number in the format the platform with the given
+// code from DefaultPlatforms expects as customerNumber
+func NormalizeCustomerNumber(platformCode PlatformCode, number string) (string, error) {
+	return defaultPlatforms.NormalizeCustomerNumber(platformCode, number)
+}
+
+// compactNumber returns a phone number without a leading "+" and without the spaces,
+// dashes, dots and brackets people write between digits
+func compactNumber(number string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-', '.', '(', ')':
+			return -1
+		}
+		return r
+	}, strings.TrimPrefix(strings.TrimSpace(number), "+"))
+}
+
+// mobileSubscriberNumber returns an Indonesian mobile number without its 0 or 62 prefix
+func mobileSubscriberNumber(number string) (string, bool) {
+	digits := compactNumber(number)
+	if !isDigits(digits) {
+		return "", false
+	}
+
+	var subscriber string
+	switch {
+	case strings.HasPrefix(digits, "62"):
+		subscriber = digits[2:]
+	case strings.HasPrefix(digits, "0"):
+		subscriber = digits[1:]
+	default:
+		subscriber = digits
+	}
+	if !strings.HasPrefix(subscriber, "8") || len(subscriber)+1 < minMobileNumberDigits || len(subscriber)+1 > maxMobileNumberDigits {
+		return "", false
+	}
+	return subscriber, true
+}
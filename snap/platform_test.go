@@ -0,0 +1,202 @@
+package snap
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"testing"
+)
+
+// TestNormalizeCustomerNumber tests conversion between 08xx and 628xx phone formats
+func TestNormalizeCustomerNumber(t *testing.T) {
+	local := EmoneyPlatform{Code: "loc", Name: "Local"}
+	international := EmoneyPlatform{Code: "int", Name: "International", CustomerNumberFormat: CustomerNumberInternational}
+
+	tests := []struct {
+		number        string
+		local         string
+		international string
+	}{
+		{"081234567890", "081234567890", "6281234567890"},
+		{"6281234567890", "081234567890", "6281234567890"},
+		{"+62 812-3456-7890", "081234567890", "6281234567890"},
+		{"81234567890", "081234567890", "6281234567890"},
+		{"0812254830", "0812254830", "62812254830"},
+	}
+	for _, tt := range tests {
+		if got, err := local.NormalizeCustomerNumber(tt.number); err != nil || got != tt.local {
+			t.Errorf("local(%q) = %q, %v; expected %q", tt.number, got, err, tt.local)
+		}
+		if got, err := international.NormalizeCustomerNumber(tt.number); err != nil || got != tt.international {
+			t.Errorf("international(%q) = %q, %v; expected %q", tt.number, got, err, tt.international)
+		}
+	}
+
+	for _, number := range []string{"", "0212345678", "08123", "08123456789012345", "08a234567890"} {
+		if _, err := local.NormalizeCustomerNumber(number); err == nil {
+			t.Errorf("Expected an error for %q", number)
+		}
+	}
+
+	if got, err := NormalizeCustomerNumber(PlatformGoPay, "+6281234567890"); err != nil || got != "081234567890" {
+		t.Errorf("Expected the GoPay number in 08xx format, got %q, %v", got, err)
+	}
+	if _, err := NormalizeCustomerNumber("xyz", "081234567890"); !errors.Is(err, ErrUnknownPlatform) {
+		t.Errorf("Expected ErrUnknownPlatform, got %v", err)
+	}
+}
+
+// TestPlatformRegistry tests the built-in platforms and extending a registry
+func TestPlatformRegistry(t *testing.T) {
+	list := Platforms()
+	if len(list) < 5 {
+		t.Fatalf("Expected the built-in platforms, got %v", list)
+	}
+	for i, platform := range list {
+		if i > 0 && list[i-1].Code >= platform.Code {
+			t.Errorf("Expected platforms ordered by code, got %s before %s", list[i-1].Code, platform.Code)
+		}
+		if !platform.MinAmount.IsZero() || !platform.MaxAmount.IsZero() {
+			t.Errorf("Expected no top-up limits for %s, got %s - %s", platform.Code, platform.MinAmount, platform.MaxAmount)
+		}
+	}
+	if PlatformGoPay.String() != "GoPay" || PlatformCode("xyz").String() != "xyz" {
+		t.Errorf("Unexpected names %q and %q", PlatformGoPay, PlatformCode("xyz"))
+	}
+
+	if _, err := DefaultPlatforms().Extend(EmoneyPlatform{Code: "tst", CustomerNumberFormat: "email"}); err == nil {
+		t.Error("Expected an unknown format to be rejected")
+	}
+	if _, err := NewPlatformRegistry(EmoneyPlatform{Code: "tst"}, EmoneyPlatform{Code: "tst"}); err == nil {
+		t.Error("Expected a duplicate code to be rejected")
+	}
+	registry, err := DefaultPlatforms().Extend(EmoneyPlatform{Code: "tst", Name: "Test", MaxAmount: MustIDR(50_000)})
+	if err != nil {
+		t.Fatalf("Extend failed: %v", err)
+	}
+	if _, ok := LookupPlatform("tst"); ok {
+		t.Error("Expected the default registry to be left unchanged")
+	}
+	if _, ok := registry.Lookup(PlatformGoPay); !ok {
+		t.Error("Expected the extended registry to keep the built-in platforms")
+	}
+
+	platform, ok := registry.Lookup("tst")
+	if !ok {
+		t.Fatal("Expected the added platform to be found")
+	}
+	if err := platform.CheckAmount(MustIDR(50_000)); err != nil {
+		t.Errorf("Expected the maximum to be accepted, got %v", err)
+	}
+	if err := platform.CheckAmount(MustIDR(50_001)); err == nil {
+		t.Error("Expected an amount above the maximum to be rejected")
+	}
+}
+
+// TestCustomerTopupPlatformValidation tests the platform rules of a top-up request
+func TestCustomerTopupPlatformValidation(t *testing.T) {
+	request := func(platform PlatformCode, number, value string) *CustomerTopupRequest {
+		return &CustomerTopupRequest{
+			PartnerReferenceNo: "20250609150352617",
+			CustomerNumber:     number,
+			Amount:             &Amount{Value: value, Currency: "IDR"},
+			TransactionDate:    "2025-06-09T15:03:52+07:00",
+			AdditionalInfo:     &AdditionalInfoCustomerTopupRequest{PlatformCode: string(platform)},
+		}
+	}
+	registry, err := DefaultPlatforms().Extend(
+		EmoneyPlatform{Code: PlatformShopeePay, Name: "ShopeePay", MinAmount: MustIDR(10_000)},
+		EmoneyPlatform{Code: PlatformLinkAja, Name: "LinkAja", MaxAmount: MustIDR(20_000_000)},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, valid := range []*CustomerTopupRequest{
+		request(PlatformOVO, "081234567890", "50000.00"),
+		request(PlatformOVO, "6281234567890", "50000.00"),
+		request(PlatformGoPay, "081234567890", "1.00"),
+		request("xyz", "customer@example.com", "50000.00"),
+	} {
+		if err := valid.ValidatePlatform(registry); err != nil {
+			t.Errorf("Expected %s %s to be valid, got %v", valid.AdditionalInfo.PlatformCode, valid.CustomerNumber, err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		request *CustomerTopupRequest
+		field   string
+	}{
+		{"NotAMobileNumber", request(PlatformDANA, "0212345678", "50000.00"), "customerNumber"},
+		{"BelowMinimum", request(PlatformShopeePay, "081234567890", "5000.00"), "amount.value"},
+		{"AboveMaximum", request(PlatformLinkAja, "081234567890", "20000000.01"), "amount.value"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var validationErr *ValidationError
+			if !errors.As(tt.request.ValidatePlatform(registry), &validationErr) {
+				t.Fatal("Expected *ValidationError")
+			}
+			if len(validationErr.Field(tt.field)) == 0 {
+				t.Errorf("Expected an error for %s, got %v", tt.field, validationErr)
+			}
+		})
+	}
+}
+
+// TestCustomerTopupNormalizesCustomerNumber tests that the client sends the customer
+// number in the format of the platform without modifying the request
+func TestCustomerTopupNormalizesCustomerNumber(t *testing.T) {
+	var sent []string
+	mockHTTPClient := NewMockClient(func(req *http.Request) (*http.Response, error) {
+		var body CustomerTopupRequest
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+			t.Errorf("Failed to decode request: %v", err)
+		}
+		sent = append(sent, body.CustomerNumber)
+		return MockResponse(http.StatusOK, `{"responseCode":"2003800","responseMessage":"Successful"}`), nil
+	})
+	registry, err := DefaultPlatforms().Extend(EmoneyPlatform{Code: "int", Name: "International", CustomerNumberFormat: CustomerNumberInternational})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := NewClient("99999", getTestPrivateKey(), nil,
+		WithHTTPClient(mockHTTPClient),
+		WithEnvironment(Sandbox),
+		WithPlatformRegistry(registry),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	for _, tt := range []struct {
+		platform PlatformCode
+		number   string
+	}{
+		{PlatformGoPay, "+62 812-3456-7890"},
+		{"int", "081234567890"},
+		{"xyz", "6281234567890"},
+	} {
+		request := &CustomerTopupRequest{
+			PartnerReferenceNo: "20250609150352617",
+			CustomerNumber:     tt.number,
+			Amount:             MustIDR(50_000).Amount(),
+			TransactionDate:    "2025-06-09T15:03:52+07:00",
+			AdditionalInfo:     &AdditionalInfoCustomerTopupRequest{PlatformCode: string(tt.platform)},
+		}
+		if _, err := client.CustomerTopup(context.Background(), request); err != nil {
+			t.Fatalf("CustomerTopup(%s) failed: %v", tt.platform, err)
+		}
+		if request.CustomerNumber != tt.number {
+			t.Errorf("Expected the request to be left unchanged, got %q", request.CustomerNumber)
+		}
+	}
+
+	expected := []string{"081234567890", "6281234567890", "6281234567890"}
+	if !slices.Equal(sent, expected) {
+		t.Errorf("Sent customer numbers %v, expected %v", sent, expected)
+	}
+}
@@ -0,0 +1,209 @@
+package snap
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+)
+
+// PollPolicy configures how the status of a transaction is checked until it is final
+type PollPolicy struct {
+	InitialInterval time.Duration // Wait before the first status check
+	MaxInterval     time.Duration // Upper bound for the wait between checks
+	Multiplier      float64       // Factor applied to the wait after every check
+	MaxWait         time.Duration // Stop when the transaction is still not final after this long; 0 waits until the context is done
+}
+
+// DefaultPollPolicy returns a policy that checks after 2s, backs off up to 30s and gives up after 5 minutes
+func DefaultPollPolicy() PollPolicy {
+	return PollPolicy{
+		InitialInterval: 2 * time.Second,
+		MaxInterval:     30 * time.Second,
+		Multiplier:      1.5,
+		MaxWait:         5 * time.Minute,
+	}
+}
+
+// orDefault returns DefaultPollPolicy when p is the zero value
+func (p PollPolicy) orDefault() PollPolicy {
+	if p == (PollPolicy{}) {
+		return DefaultPollPolicy()
+	}
+	return p
+}
+
+// interval returns the wait before the given status check, starting at 1
+func (p PollPolicy) interval(check int) time.Duration {
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+
+	wait := float64(p.InitialInterval) * math.Pow(multiplier, float64(check-1))
+	if p.MaxInterval > 0 && wait > float64(p.MaxInterval) {
+		wait = float64(p.MaxInterval)
+	}
+	return time.Duration(wait)
+}
+
+// PollRequest identifies the transaction PollStatus checks and how
+type PollRequest struct {
+	OriginalPartnerReferenceNo string // partnerReferenceNo of the original request
+	OriginalReferenceNo        string // referenceNo returned by Faspay, if known
+	// ServiceCode is the SNAP service code of the original operation: "18" for an
+	// interbank transfer, checked with StatusTransfer, or "38" for an e-money top-up,
+	// checked with CustomerTopupStatus. Other codes are checked with StatusTransfer.
+	ServiceCode ServiceCode
+	// Policy controls the wait between checks. The zero value uses DefaultPollPolicy.
+	Policy PollPolicy
+	// StopWhenNotFound treats "07" (transaction not found) as final. Use it only when
+	// the original request is known not to have reached Faspay, such as after a DNS
+	// or dial failure. After a timeout or a server error the transaction may not be
+	// visible yet, so it must keep being polled.
+	StopWhenNotFound bool
+	// OnUpdate, if set, is called after every status check
+	OnUpdate func(update StatusUpdate)
+	// Updates, if set, receives every status check. Sends block until the update is
+	// received or the context is done. The channel is not closed by PollStatus.
+	Updates chan<- StatusUpdate
+}
+
+// TransactionStatus is the status of a transaction as reported by a status endpoint
+type TransactionStatus struct {
+	OriginalPartnerReferenceNo string
+	OriginalReferenceNo        string
+	ServiceCode                ServiceCode
+	LatestTransactionStatus    TransactionStatusCode
+	TransactionStatusDesc      string
+
+	Transfer *StatusTransferResponse      // Set when the status came from StatusTransfer
+	Topup    *CustomerTopupStatusResponse // Set when the status came from CustomerTopupStatus
+}
+
+// IsFinal reports whether the status will not change anymore
+func (s *TransactionStatus) IsFinal() bool {
+	return s != nil && s.LatestTransactionStatus.IsTerminal()
+}
+
+// StatusUpdate reports one status check made by PollStatus
+type StatusUpdate struct {
+	Check  int                // Number of the check, starting at 1
+	Status *TransactionStatus // Status reported by Faspay; nil when the check failed
+	Err    error              // Error of the check, if any
+}
+
+// PollStatus checks the status of a transfer or top-up until LatestTransactionStatus
+// is final, waiting between checks according to the poll policy. Checks that fail
+// with a timeout or a server error are retried. The status is checked at least once,
+// and a last time when the policy's MaxWait elapses; if it is still not final then,
+// the last status is returned with an error matching ErrPending.
+func PollStatus(ctx context.Context, client Services, req *PollRequest) (*TransactionStatus, error) {
+	if req == nil {
+		return nil, missingRequest()
+	}
+	policy := req.Policy.orDefault()
+
+	var deadline time.Time
+	if policy.MaxWait > 0 {
+		deadline = time.Now().Add(policy.MaxWait)
+	}
+
+	var last *TransactionStatus
+	for check := 1; ; check++ {
+		// The wait is capped at the time left so that the last check happens at MaxWait
+		wait, lastCheck := policy.interval(check), false
+		if !deadline.IsZero() {
+			if remaining := time.Until(deadline); wait >= remaining {
+				wait, lastCheck = max(remaining, 0), true
+			}
+		}
+		if err := sleepContext(ctx, wait); err != nil {
+			return last, err
+		}
+
+		status, err := checkStatus(ctx, client, req)
+		if err != nil && isTransactionNotFound(err) {
+			status = &TransactionStatus{
+				OriginalPartnerReferenceNo: req.OriginalPartnerReferenceNo,
+				OriginalReferenceNo:        req.OriginalReferenceNo,
+				ServiceCode:                req.ServiceCode,
+				LatestTransactionStatus:    StatusNotFound,
+			}
+			err = nil
+		}
+		if status != nil {
+			last = status
+		}
+
+		update := StatusUpdate{Check: check, Status: status, Err: err}
+		if req.OnUpdate != nil {
+			req.OnUpdate(update)
+		}
+		if req.Updates != nil {
+			select {
+			case req.Updates <- update:
+			case <-ctx.Done():
+				return last, ctx.Err()
+			}
+		}
+
+		switch {
+		case err != nil && outcomeUnknown(err):
+			// Checked again after the next wait
+		case err != nil:
+			return last, err
+		case status.IsFinal():
+			return status, nil
+		case req.StopWhenNotFound && status.LatestTransactionStatus == StatusNotFound:
+			return status, nil
+		}
+
+		if lastCheck {
+			status := "unknown"
+			if last != nil {
+				status = string(last.LatestTransactionStatus)
+			}
+			return last, fmt.Errorf("%w: status %s after %s", ErrPending, status, policy.MaxWait)
+		}
+	}
+}
+
+// checkStatus calls the status endpoint that matches the request's service code
+func checkStatus(ctx context.Context, client Services, req *PollRequest) (*TransactionStatus, error) {
+	if req.ServiceCode == ServiceCodeEmoneyTopup {
+		resp, err := client.CustomerTopupStatus(ctx, &CustomerTopupStatusRequest{
+			OriginalPartnerReferenceNo: req.OriginalPartnerReferenceNo,
+			OriginalReferenceNo:        req.OriginalReferenceNo,
+			ServiceCode:                string(req.ServiceCode),
+		})
+		if err != nil {
+			return nil, err
+		}
+		return &TransactionStatus{
+			OriginalPartnerReferenceNo: resp.OriginalPartnerReferenceNo,
+			OriginalReferenceNo:        resp.OriginalReferenceNo,
+			ServiceCode:                ServiceCode(resp.ServiceCode),
+			LatestTransactionStatus:    resp.Status(),
+			TransactionStatusDesc:      resp.TransactionStatusDesc,
+			Topup:                      resp,
+		}, nil
+	}
+
+	resp, err := client.StatusTransfer(ctx, &StatusTransferRequest{
+		OriginalPartnerReferenceNo: req.OriginalPartnerReferenceNo,
+		OriginalReferenceNo:        req.OriginalReferenceNo,
+		ServiceCode:                string(req.ServiceCode),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &TransactionStatus{
+		OriginalPartnerReferenceNo: resp.OriginalPartnerReferenceNo,
+		OriginalReferenceNo:        resp.OriginalReferenceNo,
+		ServiceCode:                ServiceCode(resp.ServiceCode),
+		LatestTransactionStatus:    resp.Status(),
+		TransactionStatusDesc:      resp.TransactionStatusDesc,
+		Transfer:                   resp,
+	}, nil
+}
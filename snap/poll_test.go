@@ -0,0 +1,153 @@
+package snap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newStatusClient returns a client whose status endpoints answer with the given
+// responses in turn; the last one repeats
+func newStatusClient(t *testing.T, responses ...func(req *http.Request) *http.Response) (Services, *[]string) {
+	var mu sync.Mutex
+	var paths []string
+	mockHTTPClient := NewMockClient(func(req *http.Request) (*http.Response, error) {
+		mu.Lock()
+		paths = append(paths, req.URL.Path)
+		n := len(paths)
+		mu.Unlock()
+		return responses[min(n, len(responses))-1](req), nil
+	})
+
+	client, err := NewClient("99999", getTestPrivateKey(), nil, WithHTTPClient(mockHTTPClient))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	return client, &paths
+}
+
+func statusResponse(status string) func(req *http.Request) *http.Response {
+	return func(req *http.Request) *http.Response {
+		return MockResponse(http.StatusOK, fmt.Sprintf(`{"responseCode":"2003600","responseMessage":"Successful","originalPartnerReferenceNo":"TOPUP1","serviceCode":"38","latestTransactionStatus":%q}`, status))
+	}
+}
+
+// TestPollStatus tests polling until a final status
+func TestPollStatus(t *testing.T) {
+	t.Run("TopupUntilSuccess", func(t *testing.T) {
+		client, paths := newStatusClient(t,
+			statusResponse("01"),
+			func(*http.Request) *http.Response { return MockServerErrorResponse() },
+			statusResponse("03"),
+			statusResponse("00"),
+		)
+
+		updates := make(chan StatusUpdate, 10)
+		var callbacks int
+		status, err := PollStatus(context.Background(), client, &PollRequest{
+			OriginalPartnerReferenceNo: "TOPUP1",
+			ServiceCode:                "38",
+			Policy:                     testPollPolicy(),
+			OnUpdate:                   func(StatusUpdate) { callbacks++ },
+			Updates:                    updates,
+		})
+		if err != nil {
+			t.Fatalf("Failed to poll: %v", err)
+		}
+		if status.LatestTransactionStatus != "00" || status.Topup == nil || !status.IsFinal() {
+			t.Errorf("Expected final top-up status 00, got %+v", status)
+		}
+		for _, path := range *paths {
+			if path != EndpointCustomerTopupStatus {
+				t.Errorf("Expected top-up status checks only, got %s", path)
+			}
+		}
+
+		close(updates)
+		var seen []string
+		for update := range updates {
+			if update.Err != nil {
+				seen = append(seen, "error")
+			} else {
+				seen = append(seen, string(update.Status.LatestTransactionStatus))
+			}
+		}
+		if fmt.Sprint(seen) != "[01 error 03 00]" || callbacks != 4 {
+			t.Errorf("Unexpected updates %v (%d callbacks)", seen, callbacks)
+		}
+	})
+
+	t.Run("TransferUsesStatusTransfer", func(t *testing.T) {
+		client, paths := newStatusClient(t, statusResponse("06"))
+		status, err := PollStatus(context.Background(), client, &PollRequest{OriginalPartnerReferenceNo: "TRX1", ServiceCode: "18", Policy: testPollPolicy()})
+		if err != nil {
+			t.Fatalf("Failed to poll: %v", err)
+		}
+		if status.Transfer == nil || (*paths)[0] != EndpointInquiryStatus {
+			t.Errorf("Expected a StatusTransfer check, got %v", *paths)
+		}
+	})
+
+	t.Run("MaxWait", func(t *testing.T) {
+		client, _ := newStatusClient(t, statusResponse("03"))
+		policy := testPollPolicy()
+		policy.MaxWait = 10 * time.Millisecond
+		status, err := PollStatus(context.Background(), client, &PollRequest{OriginalPartnerReferenceNo: "TRX1", ServiceCode: "18", Policy: policy})
+		if !errors.Is(err, ErrPending) {
+			t.Fatalf("Expected ErrPending, got %v", err)
+		}
+		if status == nil || status.LatestTransactionStatus != "03" {
+			t.Errorf("Expected the last status, got %+v", status)
+		}
+	})
+
+	t.Run("IntervalLongerThanMaxWait", func(t *testing.T) {
+		client, paths := newStatusClient(t, statusResponse("00"))
+		policy := PollPolicy{InitialInterval: time.Second, MaxWait: 10 * time.Millisecond}
+		status, err := PollStatus(context.Background(), client, &PollRequest{OriginalPartnerReferenceNo: "TRX1", ServiceCode: "18", Policy: policy})
+		if err != nil || status.LatestTransactionStatus != "00" || len(*paths) != 1 {
+			t.Errorf("Expected one check at MaxWait, got %+v, %v after %d checks", status, err, len(*paths))
+		}
+	})
+
+	t.Run("ContextCanceled", func(t *testing.T) {
+		client, _ := newStatusClient(t, statusResponse("03"))
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		policy := testPollPolicy()
+		policy.MaxWait = 0
+		_, err := PollStatus(ctx, client, &PollRequest{OriginalPartnerReferenceNo: "TRX1", ServiceCode: "18", Policy: policy})
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("Expected context.DeadlineExceeded, got %v", err)
+		}
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		notFound := func(*http.Request) *http.Response {
+			return MockResponse(http.StatusNotFound, `{"responseCode":"4043601","responseMessage":"Transaction Not Found"}`)
+		}
+		client, paths := newStatusClient(t, notFound, statusResponse("00"))
+		status, err := PollStatus(context.Background(), client, &PollRequest{OriginalPartnerReferenceNo: "TRX1", ServiceCode: "18", Policy: testPollPolicy()})
+		if err != nil || status.LatestTransactionStatus != "00" || len(*paths) != 2 {
+			t.Errorf("Expected polling to continue past not found, got %+v, %v", status, err)
+		}
+
+		client, _ = newStatusClient(t, notFound)
+		status, err = PollStatus(context.Background(), client, &PollRequest{OriginalPartnerReferenceNo: "TRX1", ServiceCode: "18", Policy: testPollPolicy(), StopWhenNotFound: true})
+		if err != nil || status.LatestTransactionStatus != StatusNotFound {
+			t.Errorf("Expected not found to be final, got %+v, %v", status, err)
+		}
+	})
+
+	t.Run("PermanentError", func(t *testing.T) {
+		client, paths := newStatusClient(t, func(*http.Request) *http.Response { return MockAuthenticationErrorResponse() })
+		_, err := PollStatus(context.Background(), client, &PollRequest{OriginalPartnerReferenceNo: "TRX1", ServiceCode: "18", Policy: testPollPolicy()})
+		if !IsAuthenticationError(err) || len(*paths) != 1 {
+			t.Errorf("Expected to stop on the authentication error, got %v after %d checks", err, len(*paths))
+		}
+	})
+}
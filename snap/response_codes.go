@@ -0,0 +1,119 @@
+package snap
+
+import "net/http"
+
+// ResponseCategory classifies a SNAP response code by how callers should react to it
+type ResponseCategory string
+
+// Response categories
+const (
+	CategorySuccess     ResponseCategory = "success"      // The request was processed
+	CategoryPending     ResponseCategory = "pending"      // The request was accepted and is still being processed
+	CategoryClientError ResponseCategory = "client_error" // The request was rejected and must be fixed before resending
+	CategoryAuth        ResponseCategory = "auth"         // The request failed authentication or signature checks
+	CategoryDuplicate   ResponseCategory = "duplicate"    // The partnerReferenceNo or external ID was already used
+	CategoryServer      ResponseCategory = "server"       // Faspay or a downstream bank failed to process the request
+	CategoryTimeout     ResponseCategory = "timeout"      // Faspay did not get an answer in time; the outcome is unknown
+)
+
+// ResponseCodeInfo describes a SNAP response code. The service code part of the
+// code varies per endpoint, so an entry is identified by HTTP status and case code.
+type ResponseCodeInfo struct {
+	HTTPStatus  int              // HTTP status part of the code (e.g. 401)
+	CaseCode    string           // 2-digit case code (e.g. "00")
+	Description string           // Human readable description from the SNAP BI standard
+	Category    ResponseCategory // How the code should be handled
+	// Retryable reports whether resending the same request is safe. It is false for
+	// timeouts: the outcome of the request is unknown, so a transfer or top-up may have
+	// been processed and its status must be checked before it is sent again.
+	Retryable bool
+}
+
+// responseCodes is the catalog of SNAP BI response codes Faspay returns, keyed by
+// HTTP status followed by case code
+var responseCodes = map[string]ResponseCodeInfo{
+	"20000": {http.StatusOK, "00", "Successful", CategorySuccess, false},
+	"20200": {http.StatusAccepted, "00", "Request In Progress", CategoryPending, false},
+
+	"40000": {http.StatusBadRequest, "00", "Bad Request", CategoryClientError, false},
+	"40001": {http.StatusBadRequest, "01", "Invalid Field Format", CategoryClientError, false},
+	"40002": {http.StatusBadRequest, "02", "Invalid Mandatory Field", CategoryClientError, false},
+
+	"40100": {http.StatusUnauthorized, "00", "Unauthorized", CategoryAuth, false},
+	"40101": {http.StatusUnauthorized, "01", "Invalid Token (B2B)", CategoryAuth, false},
+	"40102": {http.StatusUnauthorized, "02", "Invalid Customer Token", CategoryAuth, false},
+	"40103": {http.StatusUnauthorized, "03", "Token Not Found (B2B)", CategoryAuth, false},
+	"40104": {http.StatusUnauthorized, "04", "Customer Token Not Found", CategoryAuth, false},
+
+	"40300": {http.StatusForbidden, "00", "Transaction Expired", CategoryClientError, false},
+	"40301": {http.StatusForbidden, "01", "Feature Not Allowed", CategoryClientError, false},
+	"40302": {http.StatusForbidden, "02", "Exceeds Transaction Amount Limit", CategoryClientError, false},
+	"40303": {http.StatusForbidden, "03", "Suspected Fraud", CategoryClientError, false},
+	"40304": {http.StatusForbidden, "04", "Activity Count Limit Exceeded", CategoryClientError, false},
+	"40305": {http.StatusForbidden, "05", "Do Not Honor", CategoryClientError, false},
+	"40306": {http.StatusForbidden, "06", "Feature Not Allowed At This Time", CategoryClientError, false},
+	"40309": {http.StatusForbidden, "09", "Dormant Account", CategoryClientError, false},
+	"40314": {http.StatusForbidden, "14", "Insufficient Funds", CategoryClientError, false},
+	"40315": {http.StatusForbidden, "15", "Transaction Not Permitted", CategoryClientError, false},
+	"40316": {http.StatusForbidden, "16", "Suspend Transaction", CategoryClientError, false},
+	"40318": {http.StatusForbidden, "18", "Inactive Card/Account/Customer", CategoryClientError, false},
+	"40323": {http.StatusForbidden, "23", "Account Limit Exceed", CategoryClientError, false},
+
+	"40400": {http.StatusNotFound, "00", "Invalid Transaction Status", CategoryClientError, false},
+	"40401": {http.StatusNotFound, "01", "Transaction Not Found", CategoryClientError, false},
+	"40402": {http.StatusNotFound, "02", "Invalid Routing", CategoryClientError, false},
+	"40403": {http.StatusNotFound, "03", "Bank Not Supported By Switch", CategoryClientError, false},
+	"40404": {http.StatusNotFound, "04", "Transaction Cancelled", CategoryClientError, false},
+	"40408": {http.StatusNotFound, "08", "Invalid Merchant", CategoryClientError, false},
+	"40411": {http.StatusNotFound, "11", "Invalid Card/Account/Customer/Virtual Account", CategoryClientError, false},
+	"40412": {http.StatusNotFound, "12", "Invalid Bill/Virtual Account", CategoryClientError, false},
+	"40413": {http.StatusNotFound, "13", "Invalid Amount", CategoryClientError, false},
+	"40414": {http.StatusNotFound, "14", "Paid Bill", CategoryClientError, false},
+	"40416": {http.StatusNotFound, "16", "Partner Not Found", CategoryClientError, false},
+	"40418": {http.StatusNotFound, "18", "Inconsistent Request", CategoryClientError, false},
+
+	"40500": {http.StatusMethodNotAllowed, "00", "Requested Function Is Not Supported", CategoryClientError, false},
+	"40501": {http.StatusMethodNotAllowed, "01", "Requested Operation Is Not Allowed", CategoryClientError, false},
+
+	"40900": {http.StatusConflict, "00", "Conflict", CategoryDuplicate, false},
+	"40901": {http.StatusConflict, "01", "Duplicate partnerReferenceNo", CategoryDuplicate, false},
+
+	"42900": {http.StatusTooManyRequests, "00", "Too Many Requests", CategoryClientError, true},
+
+	"50000": {http.StatusInternalServerError, "00", "General Error", CategoryServer, true},
+	"50001": {http.StatusInternalServerError, "01", "Internal Server Error", CategoryServer, true},
+	"50002": {http.StatusInternalServerError, "02", "External Server Error", CategoryServer, true},
+
+	"50400": {http.StatusGatewayTimeout, "00", "Timeout", CategoryTimeout, false},
+}
+
+// LookupResponseCode returns the catalog entry for a 7-digit SNAP response code
+// such as "4091800". The service code part is ignored, so "4011800" and "4013800"
+// resolve to the same entry. ok is false for codes the catalog does not know.
+func LookupResponseCode(code string) (info ResponseCodeInfo, ok bool) {
+	if _, _, _, valid := ParseResponseCode(code); !valid {
+		return ResponseCodeInfo{}, false
+	}
+	info, ok = responseCodes[code[:3]+code[5:]]
+	return info, ok
+}
+
+// categoryForStatus classifies codes missing from the catalog by their HTTP status
+func categoryForStatus(statusCode int) ResponseCategory {
+	switch {
+	case statusCode == http.StatusAccepted:
+		return CategoryPending
+	case statusCode >= 200 && statusCode < 300:
+		return CategorySuccess
+	case statusCode == http.StatusUnauthorized:
+		return CategoryAuth
+	case statusCode == http.StatusConflict:
+		return CategoryDuplicate
+	case statusCode == http.StatusGatewayTimeout:
+		return CategoryTimeout
+	case statusCode >= 500:
+		return CategoryServer
+	default:
+		return CategoryClientError
+	}
+}
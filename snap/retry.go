@@ -0,0 +1,179 @@
+package snap
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	mathRand "math/rand"
+	"net"
+	"time"
+)
+
+// RetryPolicy configures automatic retries of failed requests. Every retry is sent
+// with a fresh timestamp, signature and external ID but the same body, so the
+// partnerReferenceNo never changes between attempts.
+type RetryPolicy struct {
+	MaxAttempts    int           // Total number of attempts including the first one; values below 2 disable retries
+	InitialBackoff time.Duration // Wait before the first retry
+	MaxBackoff     time.Duration // Upper bound for the wait between attempts
+	Multiplier     float64       // Factor applied to the wait after every attempt
+	Jitter         float64       // Fraction of the wait, between 0 and 1, that is randomized
+}
+
+// DefaultRetryPolicy returns a policy with 3 attempts and exponential backoff starting at 500ms
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: 500 * time.Millisecond,
+		MaxBackoff:     5 * time.Second,
+		Multiplier:     2,
+		Jitter:         0.2,
+	}
+}
+
+// WithRetryPolicy enables automatic retries. Read-only endpoints are retried on
+// transport errors and retryable responses. Money-moving endpoints are only retried
+// when the request provably never reached Faspay, or when a status check confirms
+// that Faspay has no transaction for the partnerReferenceNo.
+func WithRetryPolicy(policy RetryPolicy) ClientOption {
+	return func(c *Client) {
+		c.retryPolicy = policy
+	}
+}
+
+// backoff returns the wait before the given retry, starting at 1
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+
+	wait := float64(p.InitialBackoff) * math.Pow(multiplier, float64(retry-1))
+	if p.MaxBackoff > 0 && wait > float64(p.MaxBackoff) {
+		wait = float64(p.MaxBackoff)
+	}
+
+	if jitter := math.Min(math.Max(p.Jitter, 0), 1); jitter > 0 {
+		wait = wait * (1 - jitter + 2*jitter*mathRand.Float64())
+	}
+	return time.Duration(wait)
+}
+
+// retrySafety describes when an endpoint may be retried
+type retrySafety int
+
+const (
+	// retryReadOnly endpoints have no side effects and can be retried freely
+	retryReadOnly retrySafety = iota
+	// retryMoneyMoving endpoints move funds and are only retried when it is certain
+	// the original request did not create a transaction
+	retryMoneyMoving
+)
+
+// endpointRetrySafety is the per-endpoint safety matrix used by the retry loop
+var endpointRetrySafety = map[string]retrySafety{
+	EndpointAccountInquiry:      retryReadOnly,
+	EndpointInquiryBalance:      retryReadOnly,
+	EndpointHistoryList:         retryReadOnly,
+	EndpointInquiryStatus:       retryReadOnly,
+	EndpointCustomerTopupStatus: retryReadOnly,
+	EndpointBillInquiry:         retryReadOnly,
+	EndpointTransferInterbank:   retryMoneyMoving,
+	EndpointCustomerTopup:       retryMoneyMoving,
+	EndpointBillPayment:         retryMoneyMoving,
+}
+
+// shouldRetry decides whether a failed attempt against path may be sent again
+func (c *Client) shouldRetry(ctx context.Context, path string, jsonBody []byte, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	var marshalErr *MarshalError
+	var signingErr *SigningError
+	var verificationErr *SignatureVerificationError
+	if errors.As(err, &marshalErr) || errors.As(err, &signingErr) || errors.As(err, &verificationErr) {
+		return false
+	}
+
+	var apiErr *Error
+	isAPIErr := errors.As(err, &apiErr)
+	if isAPIErr && !apiErr.retryCandidate() {
+		return false
+	}
+
+	safety, ok := endpointRetrySafety[path]
+	if !ok {
+		safety = retryMoneyMoving
+	}
+	if safety == retryReadOnly {
+		return true
+	}
+
+	if !isAPIErr && neverSent(err) {
+		return true
+	}
+	return c.confirmNotProcessed(ctx, path, jsonBody)
+}
+
+// neverSent reports whether a transport error happened before any byte of the
+// request could reach Faspay, such as a DNS failure or a refused connection
+func neverSent(err error) bool {
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return true
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// confirmNotProcessed asks Faspay for the status of the transaction created by a
+// money-moving request and reports whether Faspay confirms it does not exist
+func (c *Client) confirmNotProcessed(ctx context.Context, path string, jsonBody []byte) bool {
+	var original struct {
+		PartnerReferenceNo string `json:"partnerReferenceNo"`
+	}
+	if err := json.Unmarshal(jsonBody, &original); err != nil || original.PartnerReferenceNo == "" {
+		return false
+	}
+
+	var status TransactionStatusCode
+	var err error
+	switch path {
+	case EndpointTransferInterbank:
+		var resp *StatusTransferResponse
+		resp, err = c.StatusTransfer(ctx, NewStatusTransferRequest(original.PartnerReferenceNo, ""))
+		if resp != nil {
+			status = resp.Status()
+		}
+	case EndpointCustomerTopup:
+		var resp *CustomerTopupStatusResponse
+		resp, err = c.CustomerTopupStatus(ctx, NewCustomerTopupStatusRequest(original.PartnerReferenceNo, ""))
+		if resp != nil {
+			status = resp.Status()
+		}
+	default:
+		// There is no status endpoint to confirm the outcome, so the request
+		// must not be sent again.
+		return false
+	}
+
+	if IsAPIError(err) {
+		return isTransactionNotFound(err)
+	}
+	return err == nil && status == StatusNotFound
+}
+
+// sleepContext waits for d or until ctx is done, whichever comes first
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
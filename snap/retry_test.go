@@ -0,0 +1,204 @@
+package snap
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testRetryPolicy retries quickly so tests stay fast
+func testRetryPolicy() RetryPolicy {
+	return RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: 5 * time.Millisecond, Multiplier: 2}
+}
+
+// recordedRequest captures what the mock transport received
+type recordedRequest struct {
+	path       string
+	externalID string
+	body       string
+}
+
+// newRecordingClient creates a client whose transport records requests and answers with respond
+func newRecordingClient(t *testing.T, respond func(n int, req *http.Request) (*http.Response, error)) (Services, *[]recordedRequest) {
+	var mu sync.Mutex
+	var requests []recordedRequest
+
+	mockHTTPClient := NewMockClient(func(req *http.Request) (*http.Response, error) {
+		body, _ := io.ReadAll(req.Body)
+
+		mu.Lock()
+		requests = append(requests, recordedRequest{path: req.URL.Path, externalID: req.Header.Get("X-EXTERNAL-ID"), body: string(body)})
+		n := len(requests)
+		mu.Unlock()
+
+		return respond(n, req)
+	})
+
+	client, err := NewClient("99999", getTestPrivateKey(), nil, WithHTTPClient(mockHTTPClient), WithRetryPolicy(testRetryPolicy()))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	return client, &requests
+}
+
+func testTransferRequest() *TransferInterBankRequest {
+	return &TransferInterBankRequest{
+		PartnerReferenceNo:     "TRX123456789",
+		Amount:                 &Amount{Value: "10000.00", Currency: "IDR"},
+		BeneficiaryAccountName: "John Doe",
+		BeneficiaryAccountNo:   "60004400184",
+		BeneficiaryBankCode:    "008",
+		SourceAccountNo:        "9920017573",
+		TransactionDate:        "2025-06-09T10:30:03+07:00",
+	}
+}
+
+// TestRetryReadOnly tests retries of read-only endpoints
+func TestRetryReadOnly(t *testing.T) {
+	client, requests := newRecordingClient(t, func(n int, req *http.Request) (*http.Response, error) {
+		if n == 1 {
+			return MockServerErrorResponse(), nil
+		}
+		return MockInquiryBalanceSuccessResponse(), nil
+	})
+
+	_, err := client.InquiryBalance(context.Background(), &InquiryBalanceRequest{AccountNo: "9920017573"})
+	if err != nil {
+		t.Fatalf("Expected retry to succeed, got %v", err)
+	}
+	if len(*requests) != 2 {
+		t.Fatalf("Expected 2 attempts, got %d", len(*requests))
+	}
+	if (*requests)[0].externalID == (*requests)[1].externalID {
+		t.Error("Expected every attempt to use a fresh external ID")
+	}
+}
+
+// TestRetryNonRetryable tests that rejected requests are not resent
+func TestRetryNonRetryable(t *testing.T) {
+	client, requests := newRecordingClient(t, func(n int, req *http.Request) (*http.Response, error) {
+		return MockValidationErrorResponse(), nil
+	})
+
+	_, err := client.InquiryBalance(context.Background(), &InquiryBalanceRequest{AccountNo: "9920017573"})
+	if !IsValidationError(err) {
+		t.Fatalf("Expected validation error, got %v", err)
+	}
+	if len(*requests) != 1 {
+		t.Errorf("Expected 1 attempt, got %d", len(*requests))
+	}
+}
+
+// TestRetryMoneyMoving tests the idempotency rules for money-moving endpoints
+func TestRetryMoneyMoving(t *testing.T) {
+	t.Run("StatusCheckConfirmsNotFound", func(t *testing.T) {
+		client, requests := newRecordingClient(t, func(n int, req *http.Request) (*http.Response, error) {
+			switch n {
+			case 1:
+				return MockResponse(http.StatusGatewayTimeout, `{"responseCode":"5041800","responseMessage":"Timeout"}`), nil
+			case 2:
+				return MockResponse(http.StatusNotFound, `{"responseCode":"4043601","responseMessage":"Transaction Not Found"}`), nil
+			default:
+				return MockTransferInterBankSuccessResponse(), nil
+			}
+		})
+
+		_, err := client.TransferInterBank(context.Background(), testTransferRequest())
+		if err != nil {
+			t.Fatalf("Expected retry to succeed, got %v", err)
+		}
+
+		wantPaths := []string{EndpointTransferInterbank, EndpointInquiryStatus, EndpointTransferInterbank}
+		if len(*requests) != len(wantPaths) {
+			t.Fatalf("Expected %d requests, got %d", len(wantPaths), len(*requests))
+		}
+		for i, want := range wantPaths {
+			if (*requests)[i].path != want {
+				t.Errorf("Expected request %d to be %s, got %s", i, want, (*requests)[i].path)
+			}
+		}
+		if (*requests)[0].body != (*requests)[2].body {
+			t.Error("Expected the retried transfer to keep the same body and partnerReferenceNo")
+		}
+	})
+
+	t.Run("StatusCheckFindsTransaction", func(t *testing.T) {
+		client, requests := newRecordingClient(t, func(n int, req *http.Request) (*http.Response, error) {
+			if n == 1 {
+				return nil, errors.New("connection reset by peer")
+			}
+			return MockStatusTransferSuccessResponse(), nil
+		})
+
+		_, err := client.TransferInterBank(context.Background(), testTransferRequest())
+		var transportErr *TransportError
+		if !errors.As(err, &transportErr) {
+			t.Fatalf("Expected the original transport error, got %v", err)
+		}
+		if len(*requests) != 2 {
+			t.Errorf("Expected transfer and status check only, got %d requests", len(*requests))
+		}
+	})
+
+	t.Run("NeverSent", func(t *testing.T) {
+		client, requests := newRecordingClient(t, func(n int, req *http.Request) (*http.Response, error) {
+			if n == 1 {
+				return nil, &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+			}
+			return MockTransferInterBankSuccessResponse(), nil
+		})
+
+		if _, err := client.TransferInterBank(context.Background(), testTransferRequest()); err != nil {
+			t.Fatalf("Expected retry to succeed, got %v", err)
+		}
+		if len(*requests) != 2 || (*requests)[1].path != EndpointTransferInterbank {
+			t.Errorf("Expected the transfer to be resent without a status check, got %+v", *requests)
+		}
+	})
+
+	t.Run("BillPaymentWithoutStatusEndpoint", func(t *testing.T) {
+		client, requests := newRecordingClient(t, func(n int, req *http.Request) (*http.Response, error) {
+			return MockServerErrorResponse(), nil
+		})
+
+		_, err := client.BillPayment(context.Background(), &BillPaymentRequest{
+			PartnerReferenceNo: "BILL1",
+			PartnerServiceId:   "    7008",
+			CustomerNo:         "08000047816",
+			VirtualAccountNo:   "700808000047816",
+			SourceAccount:      "9920017573",
+			PaidAmount:         &Amount{Value: "41454.00", Currency: "IDR"},
+			TrxDateTime:        "2025-06-09T16:29:21+07:00",
+		})
+		if !IsServerError(err) {
+			t.Fatalf("Expected server error, got %v", err)
+		}
+		if len(*requests) != 1 {
+			t.Errorf("Expected 1 attempt, got %d", len(*requests))
+		}
+	})
+}
+
+// TestRetryBackoff tests that the backoff grows and stays within bounds
+func TestRetryBackoff(t *testing.T) {
+	policy := RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: 300 * time.Millisecond, Multiplier: 2}
+
+	want := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 300 * time.Millisecond, 300 * time.Millisecond}
+	for i, w := range want {
+		if got := policy.backoff(i + 1); got != w {
+			t.Errorf("Expected backoff %d to be %s, got %s", i+1, w, got)
+		}
+	}
+
+	policy.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		if got := policy.backoff(1); got < 50*time.Millisecond || got > 150*time.Millisecond {
+			t.Fatalf("Expected jittered backoff within 50ms-150ms, got %s", got)
+		}
+	}
+}
@@ -3,47 +3,57 @@ package snap
 import (
 	"context"
 	"fmt"
-	"net/http"
+	"iter"
+	"time"
 )
 
 type Services interface {
+	// Deprecated: use WithEnvironment or WithBaseURL when calling NewClient.
 	SetEnv(envType string) error
+	Environment() Environment
 	AccountInquiry(ctx context.Context, request *ExternalAccountInquiryRequest) (*ExternalAccountInquiryResponse, error)
 	TransferInterBank(ctx context.Context, request *TransferInterBankRequest) (*TransferInterBankResponse, error)
 	StatusTransfer(ctx context.Context, request *StatusTransferRequest) (*StatusTransferResponse, error)
 	InquiryBalance(ctx context.Context, request *InquiryBalanceRequest) (*InquiryBalanceResponse, error)
 	HistoryList(ctx context.Context, request *HistoryListRequest) (*HistoryListResponse, error)
+	HistoryAll(ctx context.Context, accountNo string, from, to time.Time) iter.Seq2[*DetailData, error]
 	CustomerTopup(ctx context.Context, request *CustomerTopupRequest) (*CustomerTopupResponse, error)
 	CustomerTopupStatus(ctx context.Context, request *CustomerTopupStatusRequest) (*CustomerTopupStatusResponse, error)
 	BillInquiry(ctx context.Context, request *BillInquiryRequest) (*BillInquiryResponse, error)
 	BillPayment(ctx context.Context, request *BillPaymentRequest) (*BillPaymentResponse, error)
 }
 
-// SetEnv sets the environment for the client, switching the base URL between "sandbox" and "prod" environments.
+// SetEnv sets the environment for the client, switching the base URL between the "dev", "sandbox" and "prod"
+// environments. It changes the environment for every goroutine sharing the client; requests already in flight
+// keep the base URL they started with.
+//
+// Deprecated: choose the environment when constructing the client with WithEnvironment or WithBaseURL.
 func (c *Client) SetEnv(envType string) error {
-	if envType == "sandbox" {
-		c.baseURL = baseUrlSandbox
-		c.environment = "sandbox"
-	} else if envType == "prod" {
-		c.baseURL = baseUrlProd
-		c.environment = "prod"
-	} else {
+	env := Environment(envType)
+	baseURL, ok := environmentBaseURLs[env]
+	if !ok {
 		return fmt.Errorf("invalid env type")
 	}
+	if err := c.checkPartnerID(env); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.environment = env
+	c.baseURL = baseURL
 	return nil
 }
 
 // AccountInquiry performs an inquiry for external account details
 func (c *Client) AccountInquiry(ctx context.Context, request *ExternalAccountInquiryRequest) (*ExternalAccountInquiryResponse, error) {
-	resp, err := c.doRequest(ctx, http.MethodPost, EndpointAccountInquiry, request)
-	if err != nil {
-		return nil, err
-	}
-
 	// The API directly returns the account inquiry response without a wrapper
 	var response ExternalAccountInquiryResponse
 
-	if err := c.parseResponse(resp, &response); err != nil {
+	if err := request.ValidateBankCode(c.bankCodeValidator); err != nil {
+		return nil, err
+	}
+	if err := c.call(ctx, EndpointAccountInquiry, request, &response); err != nil {
 		return nil, err
 	}
 
@@ -51,14 +61,12 @@ func (c *Client) AccountInquiry(ctx context.Context, request *ExternalAccountInq
 }
 
 func (c *Client) TransferInterBank(ctx context.Context, request *TransferInterBankRequest) (*TransferInterBankResponse, error) {
-	resp, err := c.doRequest(ctx, http.MethodPost, EndpointTransferInterbank, request)
-	if err != nil {
-		return nil, err
-	}
-
 	var response TransferInterBankResponse
 
-	if err := c.parseResponse(resp, &response); err != nil {
+	if err := request.ValidateBankCode(c.bankCodeValidator); err != nil {
+		return nil, err
+	}
+	if err := c.call(ctx, EndpointTransferInterbank, request, &response); err != nil {
 		return nil, err
 	}
 
@@ -66,15 +74,12 @@ func (c *Client) TransferInterBank(ctx context.Context, request *TransferInterBa
 }
 
 func (c *Client) StatusTransfer(ctx context.Context, request *StatusTransferRequest) (*StatusTransferResponse, error) {
-	resp, err := c.doRequest(ctx, http.MethodPost, EndpointInquiryStatus, request)
-	if err != nil {
-		return nil, err
-	}
-
 	var response StatusTransferResponse
 
-	err = c.parseResponse(resp, &response)
-	if err != nil {
+	if err := request.Validate(); err != nil {
+		return nil, err
+	}
+	if err := c.call(ctx, EndpointInquiryStatus, request, &response); err != nil {
 		return nil, err
 	}
 
@@ -82,15 +87,12 @@ func (c *Client) StatusTransfer(ctx context.Context, request *StatusTransferRequ
 }
 
 func (c *Client) InquiryBalance(ctx context.Context, request *InquiryBalanceRequest) (*InquiryBalanceResponse, error) {
-	resp, err := c.doRequest(ctx, http.MethodPost, EndpointInquiryBalance, request)
-	if err != nil {
-		return nil, err
-	}
-
 	var response InquiryBalanceResponse
 
-	err = c.parseResponse(resp, &response)
-	if err != nil {
+	if err := request.Validate(); err != nil {
+		return nil, err
+	}
+	if err := c.call(ctx, EndpointInquiryBalance, request, &response); err != nil {
 		return nil, err
 	}
 
@@ -98,15 +100,12 @@ func (c *Client) InquiryBalance(ctx context.Context, request *InquiryBalanceRequ
 }
 
 func (c *Client) HistoryList(ctx context.Context, request *HistoryListRequest) (*HistoryListResponse, error) {
-	resp, err := c.doRequest(ctx, http.MethodPost, EndpointHistoryList, request)
-	if err != nil {
-		return nil, err
-	}
-
 	var response HistoryListResponse
 
-	err = c.parseResponse(resp, &response)
-	if err != nil {
+	if err := request.Validate(); err != nil {
+		return nil, err
+	}
+	if err := c.call(ctx, EndpointHistoryList, request, &response); err != nil {
 		return nil, err
 	}
 
@@ -114,15 +113,12 @@ func (c *Client) HistoryList(ctx context.Context, request *HistoryListRequest) (
 }
 
 func (c *Client) CustomerTopup(ctx context.Context, request *CustomerTopupRequest) (*CustomerTopupResponse, error) {
-	resp, err := c.doRequest(ctx, http.MethodPost, EndpointCustomerTopup, request)
-	if err != nil {
-		return nil, err
-	}
-
 	var response CustomerTopupResponse
 
-	err = c.parseResponse(resp, &response)
-	if err != nil {
+	if err := request.ValidatePlatform(c.platforms); err != nil {
+		return nil, err
+	}
+	if err := c.call(ctx, EndpointCustomerTopup, c.platforms.normalizeTopup(request), &response); err != nil {
 		return nil, err
 	}
 
@@ -130,15 +126,12 @@ func (c *Client) CustomerTopup(ctx context.Context, request *CustomerTopupReques
 }
 
 func (c *Client) CustomerTopupStatus(ctx context.Context, request *CustomerTopupStatusRequest) (*CustomerTopupStatusResponse, error) {
-	resp, err := c.doRequest(ctx, http.MethodPost, EndpointCustomerTopupStatus, request)
-	if err != nil {
-		return nil, err
-	}
-
 	var response CustomerTopupStatusResponse
 
-	err = c.parseResponse(resp, &response)
-	if err != nil {
+	if err := request.Validate(); err != nil {
+		return nil, err
+	}
+	if err := c.call(ctx, EndpointCustomerTopupStatus, request, &response); err != nil {
 		return nil, err
 	}
 
@@ -146,15 +139,12 @@ func (c *Client) CustomerTopupStatus(ctx context.Context, request *CustomerTopup
 }
 
 func (c *Client) BillInquiry(ctx context.Context, request *BillInquiryRequest) (*BillInquiryResponse, error) {
-	resp, err := c.doRequest(ctx, http.MethodPost, EndpointBillInquiry, request)
-	if err != nil {
-		return nil, err
-	}
-
 	var response BillInquiryResponse
 
-	err = c.parseResponse(resp, &response)
-	if err != nil {
+	if err := request.Validate(); err != nil {
+		return nil, err
+	}
+	if err := c.call(ctx, EndpointBillInquiry, request, &response); err != nil {
 		return nil, err
 	}
 
@@ -162,15 +152,12 @@ func (c *Client) BillInquiry(ctx context.Context, request *BillInquiryRequest) (
 }
 
 func (c *Client) BillPayment(ctx context.Context, request *BillPaymentRequest) (*BillPaymentResponse, error) {
-	resp, err := c.doRequest(ctx, http.MethodPost, EndpointBillPayment, request)
-	if err != nil {
-		return nil, err
-	}
-
 	var response BillPaymentResponse
 
-	err = c.parseResponse(resp, &response)
-	if err != nil {
+	if err := request.Validate(); err != nil {
+		return nil, err
+	}
+	if err := c.call(ctx, EndpointBillPayment, request, &response); err != nil {
 		return nil, err
 	}
 
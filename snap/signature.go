@@ -0,0 +1,132 @@
+package snap
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// SignatureVerificationError is returned when a response or notification signed by
+// Faspay is missing its signature or the signature does not match
+type SignatureVerificationError struct {
+	Reason string // Why verification failed
+	Err    error  // Underlying error, if any
+}
+
+// Error returns the error message
+func (e *SignatureVerificationError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("signature verification failed: %s: %v", e.Reason, e.Err)
+	}
+	return fmt.Sprintf("signature verification failed: %s", e.Reason)
+}
+
+// Unwrap returns the underlying error
+func (e *SignatureVerificationError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is ErrSignature
+func (e *SignatureVerificationError) Is(target error) bool {
+	return target == ErrSignature
+}
+
+// WithFaspayPublicKey enables verification of the X-SIGNATURE header of responses.
+// Every response that carries a signature is verified, error responses included.
+// Successful responses and retryable errors with a SNAP responseCode must carry one;
+// errors from gateways and proxies, which have no SNAP responseCode, are accepted
+// unsigned. A response that fails verification is reported as a
+// *SignatureVerificationError and never retried. publicKey is Faspay's PEM encoded
+// public key, either as a PKIX or PKCS#1 public key or as an X.509 certificate. It is
+// parsed by NewClient.
+func WithFaspayPublicKey(publicKey []byte) ClientOption {
+	return func(c *Client) {
+		c.faspayPublicKeyPEM = publicKey
+	}
+}
+
+// ParsePublicKey parses a PEM encoded RSA public key from a PKIX public key, a PKCS#1
+// public key or an X.509 certificate
+func ParsePublicKey(publicKeyPEM []byte) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode(publicKeyPEM)
+	if block == nil {
+		return nil, errors.New("failed to parse public key PEM")
+	}
+
+	var parsedKey any
+	var err error
+	switch block.Type {
+	case "CERTIFICATE":
+		var cert *x509.Certificate
+		cert, err = x509.ParseCertificate(block.Bytes)
+		if err == nil {
+			parsedKey = cert.PublicKey
+		}
+	case "RSA PUBLIC KEY":
+		parsedKey, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		parsedKey, err = x509.ParsePKIXPublicKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key: %w", err)
+	}
+
+	rsaKey, ok := parsedKey.(*rsa.PublicKey)
+	if !ok {
+		return nil, errors.New("not an RSA public key")
+	}
+	return rsaKey, nil
+}
+
+// StringToSign builds the SNAP asymmetric string to sign:
+// method:path:lowercase hex SHA-256 of the minified body:timestamp
+func StringToSign(method, path string, body []byte, timestamp string) string {
+	hashed := sha256.Sum256(MinifyBody(body))
+	return fmt.Sprintf("%s:%s:%x:%s", method, path, hashed[:], timestamp)
+}
+
+// MinifyBody returns body as it is hashed into the string to sign: insignificant
+// whitespace is removed from JSON bodies and slashes are unescaped (\/ → /)
+func MinifyBody(body []byte) []byte {
+	var compacted bytes.Buffer
+	if err := json.Compact(&compacted, body); err == nil {
+		body = compacted.Bytes()
+	}
+	return bytes.ReplaceAll(body, []byte(`\/`), []byte(`/`))
+}
+
+// VerifySignature checks a base64 encoded SHA256withRSA signature made by Faspay
+// over the SNAP string to sign of method, path, body and timestamp
+func VerifySignature(publicKey *rsa.PublicKey, method, path string, body []byte, timestamp, signature string) error {
+	if signature == "" {
+		return &SignatureVerificationError{Reason: "missing X-SIGNATURE"}
+	}
+	if timestamp == "" {
+		return &SignatureVerificationError{Reason: "missing X-TIMESTAMP"}
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return &SignatureVerificationError{Reason: "malformed X-SIGNATURE", Err: err}
+	}
+
+	digest := sha256.Sum256([]byte(StringToSign(method, path, body, timestamp)))
+	if err := rsa.VerifyPKCS1v15(publicKey, crypto.SHA256, digest[:], decoded); err != nil {
+		return &SignatureVerificationError{Reason: "signature mismatch", Err: err}
+	}
+	return nil
+}
+
+// VerifyRequest checks the X-SIGNATURE of an inbound notification from Faspay. body
+// is the raw request body, which the caller must have read from r.
+func VerifyRequest(publicKey *rsa.PublicKey, r *http.Request, body []byte) error {
+	return VerifySignature(publicKey, r.Method, r.URL.EscapedPath(), body, r.Header.Get("X-TIMESTAMP"), r.Header.Get("X-SIGNATURE"))
+}
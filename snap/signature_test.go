@@ -0,0 +1,227 @@
+package snap
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+// getTestPublicKey returns the public half of the throwaway key stored in testdata
+func getTestPublicKey() []byte {
+	publicKey, err := os.ReadFile("testdata/public_key.pem")
+	if err != nil {
+		panic(err)
+	}
+
+	return publicKey
+}
+
+// signAsFaspay signs method, path, body and timestamp with the test key the way Faspay does
+func signAsFaspay(t *testing.T, method, path string, body []byte, timestamp string) string {
+	t.Helper()
+
+	rsaKey, err := ParsePrivateKey(getTestPrivateKey())
+	if err != nil {
+		t.Fatalf("Failed to parse key: %v", err)
+	}
+	digest := sha256.Sum256([]byte(StringToSign(method, path, body, timestamp)))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, rsaKey, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatalf("Failed to sign: %v", err)
+	}
+	return base64.StdEncoding.EncodeToString(signature)
+}
+
+// TestParsePublicKey tests the supported public key encodings
+func TestParsePublicKey(t *testing.T) {
+	rsaKey, err := ParsePrivateKey(getTestPrivateKey())
+	if err != nil {
+		t.Fatalf("Failed to parse key: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "faspay-test"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, &rsaKey.PublicKey, rsaKey)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+
+	encodings := map[string][]byte{
+		"PKIX":        getTestPublicKey(),
+		"PKCS1":       pem.EncodeToMemory(&pem.Block{Type: "RSA PUBLIC KEY", Bytes: x509.MarshalPKCS1PublicKey(&rsaKey.PublicKey)}),
+		"Certificate": pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER}),
+	}
+	for name, data := range encodings {
+		t.Run(name, func(t *testing.T) {
+			publicKey, err := ParsePublicKey(data)
+			if err != nil {
+				t.Fatalf("Failed to parse public key: %v", err)
+			}
+			if !publicKey.Equal(&rsaKey.PublicKey) {
+				t.Error("Expected parsed key to match the test key")
+			}
+		})
+	}
+
+	if _, err := ParsePublicKey([]byte("garbage")); err == nil {
+		t.Error("Expected garbage to be rejected")
+	}
+}
+
+// TestVerifySignature tests verification of Faspay signatures
+func TestVerifySignature(t *testing.T) {
+	publicKey, err := ParsePublicKey(getTestPublicKey())
+	if err != nil {
+		t.Fatalf("Failed to parse public key: %v", err)
+	}
+
+	body := []byte(`{"responseCode": "2001800", "responseMessage": "Successful"}`)
+	timestamp := "2025-06-09T10:30:03+07:00"
+	signature := signAsFaspay(t, http.MethodPost, EndpointInquiryBalance, body, timestamp)
+
+	if err := VerifySignature(publicKey, http.MethodPost, EndpointInquiryBalance, body, timestamp, signature); err != nil {
+		t.Errorf("Expected signature to verify, got %v", err)
+	}
+
+	// Whitespace differences disappear once the body is minified
+	compact := []byte(`{"responseCode":"2001800","responseMessage":"Successful"}`)
+	if err := VerifySignature(publicKey, http.MethodPost, EndpointInquiryBalance, compact, timestamp, signature); err != nil {
+		t.Errorf("Expected signature over the minified body to verify, got %v", err)
+	}
+
+	tampered := bytes.Replace(body, []byte("2001800"), []byte("2001801"), 1)
+	err = VerifySignature(publicKey, http.MethodPost, EndpointInquiryBalance, tampered, timestamp, signature)
+	var verificationErr *SignatureVerificationError
+	if !errors.As(err, &verificationErr) || !errors.Is(err, ErrSignature) {
+		t.Errorf("Expected *SignatureVerificationError for tampered body, got %v", err)
+	}
+
+	if err := VerifySignature(publicKey, http.MethodPost, EndpointInquiryBalance, body, timestamp, ""); !errors.As(err, &verificationErr) {
+		t.Errorf("Expected *SignatureVerificationError for missing signature, got %v", err)
+	}
+}
+
+// TestVerifyRequest tests verification of inbound notifications
+func TestVerifyRequest(t *testing.T) {
+	publicKey, err := ParsePublicKey(getTestPublicKey())
+	if err != nil {
+		t.Fatalf("Failed to parse public key: %v", err)
+	}
+
+	body := []byte(`{"originalPartnerReferenceNo":"TRX1","latestTransactionStatus":"00"}`)
+	timestamp := "2025-06-09T10:30:03+07:00"
+	req := httptest.NewRequest(http.MethodPost, "/v1/snap/callback", bytes.NewReader(body))
+	req.Header.Set("X-TIMESTAMP", timestamp)
+	req.Header.Set("X-SIGNATURE", signAsFaspay(t, http.MethodPost, "/v1/snap/callback", body, timestamp))
+
+	if err := VerifyRequest(publicKey, req, body); err != nil {
+		t.Errorf("Expected notification to verify, got %v", err)
+	}
+}
+
+// TestWithFaspayPublicKey tests verification of response signatures by the client
+func TestWithFaspayPublicKey(t *testing.T) {
+	body := `{"responseCode":"2001100","responseMessage":"Successful","accountNo":"9920017573"}`
+	timestamp := "2025-06-09T10:30:03+07:00"
+
+	var attempts int
+	newClient := func(status int, body, signature string) Services {
+		attempts = 0
+		mockHTTPClient := NewMockClient(func(req *http.Request) (*http.Response, error) {
+			attempts++
+			resp := MockResponse(status, body)
+			resp.Header = http.Header{}
+			resp.Header.Set("X-TIMESTAMP", timestamp)
+			if signature != "" {
+				resp.Header.Set("X-SIGNATURE", signature)
+			}
+			return resp, nil
+		})
+		client, err := NewClient("99999", getTestPrivateKey(), nil,
+			WithHTTPClient(mockHTTPClient),
+			WithFaspayPublicKey(getTestPublicKey()),
+			WithRetryPolicy(testRetryPolicy()),
+		)
+		if err != nil {
+			t.Fatalf("Failed to create client: %v", err)
+		}
+		return client
+	}
+	inquiryBalance := func(client Services) error {
+		_, err := client.InquiryBalance(context.Background(), &InquiryBalanceRequest{AccountNo: "9920017573"})
+		return err
+	}
+
+	t.Run("Valid", func(t *testing.T) {
+		client := newClient(http.StatusOK, body, signAsFaspay(t, http.MethodPost, EndpointInquiryBalance, []byte(body), timestamp))
+		if err := inquiryBalance(client); err != nil {
+			t.Errorf("Expected signed response to be accepted, got %v", err)
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		client := newClient(http.StatusOK, body, signAsFaspay(t, http.MethodPost, EndpointHistoryList, []byte(body), timestamp))
+		var verificationErr *SignatureVerificationError
+		if err := inquiryBalance(client); !errors.As(err, &verificationErr) {
+			t.Errorf("Expected *SignatureVerificationError, got %v", err)
+		}
+	})
+
+	serverError := `{"responseCode":"5001100","responseMessage":"General Error"}`
+	notFound := `{"responseCode":"4041101","responseMessage":"Account Not Found"}`
+	tests := []struct {
+		name      string
+		status    int
+		body      string
+		signature string
+		verified  bool // Whether the error is a *SignatureVerificationError
+		attempts  int
+	}{
+		{"SignedError", http.StatusNotFound, notFound, signAsFaspay(t, http.MethodPost, EndpointInquiryBalance, []byte(notFound), timestamp), false, 1},
+		{"ForgedError", http.StatusInternalServerError, serverError, signAsFaspay(t, http.MethodPost, EndpointHistoryList, []byte(serverError), timestamp), true, 1},
+		{"UnsignedRetryableError", http.StatusInternalServerError, serverError, "", true, 1},
+		{"UnsignedFinalError", http.StatusNotFound, notFound, "", false, 1},
+		{"UnsignedGatewayError", http.StatusBadGateway, "<html>Bad Gateway</html>", "", false, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := inquiryBalance(newClient(tt.status, tt.body, tt.signature))
+			var verificationErr *SignatureVerificationError
+			var apiErr *Error
+			if tt.verified && !errors.As(err, &verificationErr) {
+				t.Errorf("Expected *SignatureVerificationError, got %v", err)
+			}
+			if !tt.verified && !errors.As(err, &apiErr) {
+				t.Errorf("Expected *Error, got %v", err)
+			}
+			if attempts != tt.attempts {
+				t.Errorf("Expected %d attempts, got %d", tt.attempts, attempts)
+			}
+		})
+	}
+
+	t.Run("MalformedKey", func(t *testing.T) {
+		_, err := NewClient("99999", getTestPrivateKey(), nil, WithFaspayPublicKey([]byte("garbage")))
+		if err == nil {
+			t.Error("Expected malformed Faspay public key to be rejected")
+		}
+	})
+}
@@ -0,0 +1,103 @@
+package snap
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+)
+
+// failingSigner is a crypto.Signer backed by an RSA key whose Sign always fails
+type failingSigner struct{}
+
+func (failingSigner) Public() crypto.PublicKey {
+	return &rsa.PublicKey{}
+}
+
+func (failingSigner) Sign(io.Reader, []byte, crypto.SignerOpts) ([]byte, error) {
+	return nil, errors.New("token removed")
+}
+
+// countingSigner wraps a crypto.Signer and counts Sign calls
+type countingSigner struct {
+	crypto.Signer
+	calls int
+}
+
+func (s *countingSigner) Sign(r io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	s.calls++
+	return s.Signer.Sign(r, digest, opts)
+}
+
+// TestNewClientPrivateKey tests that the private key is validated at construction time
+func TestNewClientPrivateKey(t *testing.T) {
+	if _, err := NewClient("99999", getTestPrivateKey(), nil); err != nil {
+		t.Errorf("Expected valid key to be accepted, got %v", err)
+	}
+
+	if _, err := NewClient("99999", []byte("not a key"), nil); err == nil {
+		t.Error("Expected malformed key to be rejected")
+	}
+
+	if _, err := NewClient("99999", nil, nil); err == nil {
+		t.Error("Expected missing key to be rejected")
+	}
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	if _, err := NewClient("99999", nil, nil, WithSigner(ecKey)); err == nil {
+		t.Error("Expected non-RSA signer to be rejected")
+	}
+}
+
+// TestWithSigner tests that requests are signed by the configured crypto.Signer
+func TestWithSigner(t *testing.T) {
+	rsaKey, err := ParsePrivateKey(getTestPrivateKey())
+	if err != nil {
+		t.Fatalf("Failed to parse key: %v", err)
+	}
+	signer := &countingSigner{Signer: rsaKey}
+
+	var signature, timestamp string
+	var body []byte
+	mockHTTPClient := NewMockClient(func(req *http.Request) (*http.Response, error) {
+		signature = req.Header.Get("X-SIGNATURE")
+		timestamp = req.Header.Get("X-TIMESTAMP")
+		body, _ = io.ReadAll(req.Body)
+		return MockInquiryBalanceSuccessResponse(), nil
+	})
+
+	client, err := NewClient("99999", nil, nil, WithHTTPClient(mockHTTPClient), WithSigner(signer))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	if _, err := client.InquiryBalance(context.Background(), &InquiryBalanceRequest{AccountNo: "9920017573"}); err != nil {
+		t.Fatalf("Failed to call InquiryBalance: %v", err)
+	}
+	if signer.calls != 1 {
+		t.Errorf("Expected signer to be called once, got %d", signer.calls)
+	}
+
+	bodyHash := sha256.Sum256(body)
+	stringToSign := fmt.Sprintf("POST:%s:%x:%s", EndpointInquiryBalance, bodyHash[:], timestamp)
+	digest := sha256.Sum256([]byte(stringToSign))
+	decoded, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		t.Fatalf("Failed to decode signature: %v", err)
+	}
+	if err := rsa.VerifyPKCS1v15(&rsaKey.PublicKey, crypto.SHA256, digest[:], decoded); err != nil {
+		t.Errorf("Expected signature to verify, got %v", err)
+	}
+}
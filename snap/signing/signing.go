@@ -0,0 +1,110 @@
+// Package signing shows how a SNAP asymmetric signature is computed, step by step, and
+// creates the RSA keypairs requests are signed with. It is meant for debugging
+// "Unauthorized. Signature" errors: compare each value of a Trace with the one your
+// code or Faspay computed to find the step that differs.
+package signing
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+
+	"github.com/andremaeshaa/faspay-sendme-snap-go/snap"
+)
+
+// Key sizes accepted by GenerateKey
+const (
+	KeySize2048 = 2048
+	KeySize4096 = 4096
+)
+
+// Trace holds the values the signature of a request is computed from, in order
+type Trace struct {
+	Method       string `json:"method"`
+	Path         string `json:"path"`
+	Timestamp    string `json:"timestamp"`
+	MinifiedBody string `json:"minifiedBody"` // Body as it is hashed
+	BodyHash     string `json:"bodySha256"`   // Lowercase hex SHA-256 of MinifiedBody
+	StringToSign string `json:"stringToSign"` // method:path:bodySha256:timestamp
+	Signature    string `json:"signature,omitempty"`
+}
+
+// NewTrace computes the minified body, its hash and the string to sign of a request
+// the way the client does. path is the endpoint path without host, such as
+// snap.EndpointTransferInterbank.
+func NewTrace(method, path string, body []byte, timestamp string) *Trace {
+	minified := snap.MinifyBody(body)
+	hashed := sha256.Sum256(minified)
+	return &Trace{
+		Method:       method,
+		Path:         path,
+		Timestamp:    timestamp,
+		MinifiedBody: string(minified),
+		BodyHash:     hex.EncodeToString(hashed[:]),
+		StringToSign: snap.StringToSign(method, path, body, timestamp),
+	}
+}
+
+// Sign signs the string to sign with SHA256withRSA and sets Signature to the base64
+// encoded result, the value of X-SIGNATURE
+func (t *Trace) Sign(signer crypto.Signer) error {
+	digest := sha256.Sum256([]byte(t.StringToSign))
+	signature, err := signer.Sign(rand.Reader, digest[:], crypto.SHA256)
+	if err != nil {
+		return fmt.Errorf("failed to sign: %w", err)
+	}
+	t.Signature = base64.StdEncoding.EncodeToString(signature)
+	return nil
+}
+
+// Verify checks signature against the string to sign and sets Signature. The error
+// is a *snap.SignatureVerificationError when the signature does not match.
+func (t *Trace) Verify(publicKey *rsa.PublicKey, signature string) error {
+	t.Signature = signature
+	return snap.VerifySignature(publicKey, t.Method, t.Path, []byte(t.MinifiedBody), t.Timestamp, signature)
+}
+
+// GenerateKey creates an RSA private key of KeySize2048 or KeySize4096 bits
+func GenerateKey(bits int) (*rsa.PrivateKey, error) {
+	if bits != KeySize2048 && bits != KeySize4096 {
+		return nil, fmt.Errorf("unsupported key size %d: expected %d or %d", bits, KeySize2048, KeySize4096)
+	}
+	return rsa.GenerateKey(rand.Reader, bits)
+}
+
+// EncodePrivateKey returns key as a PKCS#8 PEM block ("BEGIN PRIVATE KEY"), the format
+// NewClient expects
+func EncodePrivateKey(key *rsa.PrivateKey) ([]byte, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+// EncodePublicKey returns key as a PKIX PEM block ("BEGIN PUBLIC KEY"), the format
+// uploaded to the Faspay dashboard
+func EncodePublicKey(key *rsa.PublicKey) ([]byte, error) {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), nil
+}
+
+// Fingerprint returns the lowercase hex SHA-256 of the PKIX encoding of key, to tell
+// which key a file or the dashboard holds
+func Fingerprint(key *rsa.PublicKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		return "", err
+	}
+	hashed := sha256.Sum256(der)
+	return hex.EncodeToString(hashed[:]), nil
+}
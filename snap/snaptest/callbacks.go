@@ -0,0 +1,163 @@
+package snaptest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/andremaeshaa/faspay-sendme-snap-go/snap"
+	"github.com/andremaeshaa/faspay-sendme-snap-go/snap/callback"
+)
+
+// Callback is a notification the server sent to the callbackUrl of a transaction
+type Callback struct {
+	URL        string
+	ExternalID string
+	Body       []byte
+	StatusCode int   // HTTP status of the acknowledgment, 0 when the delivery failed
+	Err        error // Error of a failed delivery
+}
+
+// Callbacks returns the notifications delivered so far, in order
+func (s *Server) Callbacks() []Callback {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Callback(nil), s.callbacks...)
+}
+
+// WaitCallbacks waits until the notifications of the transactions settled so far,
+// including delayed ones, are delivered. It is safe to call while requests are still
+// being served; notifications started meanwhile are waited for too.
+func (s *Server) WaitCallbacks() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for s.deliveries > 0 {
+		s.delivered.Wait()
+	}
+}
+
+// notify sends the final status of trx to its callbackUrl, in the background and as
+// FaultCallback asks. s.mu must be held.
+func (s *Server) notify(trx *Transaction) {
+	if trx.CallbackURL == "" || s.closed {
+		return
+	}
+	body, err := json.Marshal(notification(trx))
+	if err != nil {
+		return
+	}
+
+	delivery := Fault{Copies: 1}
+	if trx.callback != nil {
+		delivery = *trx.callback
+	}
+	externalIDs := make([]string, max(delivery.Copies, 1))
+	for i := range externalIDs {
+		s.lastNotice++
+		externalIDs[i] = fmt.Sprintf("%s%d", trx.ReferenceNo, s.lastNotice)
+	}
+
+	callbackURL, partnerID := trx.CallbackURL, trx.PartnerID
+	s.deliveries++
+	go func() {
+		defer func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			if s.deliveries--; s.deliveries == 0 {
+				s.delivered.Broadcast()
+			}
+		}()
+		if delivery.Delay > 0 && !s.hold(context.Background(), delivery.Delay) {
+			return
+		}
+		for _, externalID := range externalIDs {
+			s.deliver(callbackURL, partnerID, externalID, body)
+		}
+	}()
+}
+
+// deliver posts a signed notification and records the outcome
+func (s *Server) deliver(callbackURL, partnerID, externalID string, body []byte) {
+	record := Callback{URL: callbackURL, ExternalID: externalID, Body: body}
+	defer func() {
+		s.mu.Lock()
+		s.callbacks = append(s.callbacks, record)
+		s.mu.Unlock()
+	}()
+
+	req, err := http.NewRequest(http.MethodPost, callbackURL, bytes.NewReader(body))
+	if err != nil {
+		record.Err = err
+		return
+	}
+	timestamp := snap.NewTime(s.clock()).String()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-TIMESTAMP", timestamp)
+	req.Header.Set("X-SIGNATURE", signFaspay(http.MethodPost, req.URL.EscapedPath(), body, timestamp))
+	req.Header.Set("X-PARTNER-ID", partnerID)
+	req.Header.Set("X-EXTERNAL-ID", externalID)
+
+	resp, err := s.callbackHTTP.Do(req)
+	if err != nil {
+		record.Err = err
+		return
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	record.StatusCode = resp.StatusCode
+}
+
+// notification returns the notification body of trx, in the format the callback
+// package decodes
+func notification(trx *Transaction) any {
+	switch trx.ServiceCode {
+	case snap.ServiceCodeEmoneyTopup:
+		return &callback.TopupEvent{
+			OriginalPartnerReferenceNo: trx.PartnerReferenceNo,
+			OriginalReferenceNo:        trx.ReferenceNo,
+			ServiceCode:                trx.ServiceCode,
+			CustomerNumber:             trx.CustomerNumber,
+			Amount:                     trx.Amount.Amount(),
+			LatestTransactionStatus:    trx.Status,
+			TransactionStatusDesc:      trx.Status.String(),
+			AdditionalInfo: &snap.AdditionalInfoTopupStatus{
+				SourceAccount:   trx.SourceAccount,
+				TransactionDate: snap.NewTime(trx.Date).String(),
+				PlatformCode:    string(trx.PlatformCode),
+				CustomerNumber:  trx.CustomerNumber,
+			},
+		}
+	case snap.ServiceCodeBillPayment:
+		return &callback.BillPaymentEvent{
+			PartnerReferenceNo:      trx.PartnerReferenceNo,
+			ReferenceNo:             trx.ReferenceNo,
+			ServiceCode:             trx.ServiceCode,
+			VirtualAccountNo:        trx.VirtualAccountNo,
+			SourceAccount:           trx.SourceAccount,
+			PaidAmount:              trx.Amount.Amount(),
+			TrxDateTime:             snap.NewTime(trx.Date).String(),
+			LatestTransactionStatus: trx.Status,
+			TransactionStatusDesc:   trx.Status.String(),
+		}
+	}
+	return &callback.TransferEvent{
+		OriginalPartnerReferenceNo: trx.PartnerReferenceNo,
+		OriginalReferenceNo:        trx.ReferenceNo,
+		ServiceCode:                trx.ServiceCode,
+		TransactionDate:            snap.NewTime(trx.Date).String(),
+		Amount:                     trx.Amount.Amount(),
+		BeneficiaryAccountNo:       trx.BeneficiaryAccountNo,
+		BeneficiaryBankCode:        trx.BeneficiaryBankCode,
+		SourceAccountNo:            trx.SourceAccount,
+		LatestTransactionStatus:    trx.Status,
+		TransactionStatusDesc:      trx.Status.String(),
+		AdditionalInfo: &snap.AdditionalInfoStatusTransferResponse{
+			BeneficiaryAccountName: trx.BeneficiaryAccountName,
+			BeneficiaryBankName:    bankName(trx.BeneficiaryBankCode),
+			CallbackUrl:            trx.CallbackURL,
+		},
+	}
+}
@@ -0,0 +1,294 @@
+package snaptest
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/andremaeshaa/faspay-sendme-snap-go/snap"
+)
+
+// FaultKind is the failure a Fault injects
+type FaultKind string
+
+// Fault kinds
+const (
+	// FaultTimeout processes the request, then holds the response for Delay, or until
+	// the client gives up when Delay is zero, and answers 504 Timeout. A transfer is
+	// accepted even though its response never arrives.
+	FaultTimeout FaultKind = "timeout"
+
+	// FaultServerError answers HTTPStatus, 500 by default, without processing the request
+	FaultServerError FaultKind = "server_error"
+
+	// FaultDuplicateReference answers 409 Duplicate partnerReferenceNo without
+	// processing the request
+	FaultDuplicateReference FaultKind = "duplicate_reference"
+
+	// FaultInsufficientFunds answers 403 Insufficient Funds without processing the request
+	FaultInsufficientFunds FaultKind = "insufficient_funds"
+
+	// FaultInProgress accepts a transfer, top-up or bill payment as in progress. The
+	// next Polls status polls report it in progress; the following one settles it to
+	// Status, success by default.
+	FaultInProgress FaultKind = "in_progress"
+
+	// FaultCallback delays the notification of a transfer, top-up or bill payment by
+	// Delay and delivers it Copies times
+	FaultCallback FaultKind = "callback"
+)
+
+// Fault is a failure injected into the requests it matches. A request matches when it
+// was sent to Endpoint and involves Account; empty fields match every request. Requests
+// that fail authentication or validation are rejected as such and never match.
+type Fault struct {
+	Kind     FaultKind `json:"kind"`
+	Endpoint string    `json:"endpoint,omitempty"` // Endpoint path, or a name: inquiry, transfer, status, balance, history, topup, topup-status, bill-inquiry, bill-pay
+	Account  string    `json:"account,omitempty"`  // Source, beneficiary or virtual account, or customer number the request involves
+	Skip     int       `json:"skip,omitempty"`     // Matching requests let through before the fault applies
+	Times    int       `json:"times,omitempty"`    // Matching requests the fault applies to after Skip; 0 means all of them
+
+	Delay      time.Duration              `json:"-"`                    // FaultTimeout and FaultCallback, "delay" in JSON, e.g. "2s"
+	HTTPStatus int                        `json:"httpStatus,omitempty"` // FaultServerError
+	Polls      int                        `json:"polls,omitempty"`      // FaultInProgress
+	Status     snap.TransactionStatusCode `json:"status,omitempty"`     // FaultInProgress
+	Copies     int                        `json:"copies,omitempty"`     // FaultCallback, 1 by default
+}
+
+// faultJSON carries the fields of a Fault with no direct JSON encoding
+type faultJSON struct {
+	Delay string `json:"delay,omitempty"`
+}
+
+// MarshalJSON encodes the fault with Delay as a duration string
+func (f Fault) MarshalJSON() ([]byte, error) {
+	type plain Fault
+	encoded := struct {
+		plain
+		faultJSON
+	}{plain: plain(f)}
+	if f.Delay != 0 {
+		encoded.faultJSON.Delay = f.Delay.String()
+	}
+	return json.Marshal(encoded)
+}
+
+// UnmarshalJSON decodes a fault whose delay is a duration string such as "1.5s"
+func (f *Fault) UnmarshalJSON(data []byte) error {
+	type plain Fault
+	var decoded struct {
+		plain
+		faultJSON
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	*f = Fault(decoded.plain)
+	if decoded.faultJSON.Delay != "" {
+		delay, err := time.ParseDuration(decoded.faultJSON.Delay)
+		if err != nil {
+			return fmt.Errorf("invalid fault delay: %w", err)
+		}
+		f.Delay = delay
+	}
+	return nil
+}
+
+// endpointNames are the short names a Fault may use for an endpoint
+var endpointNames = map[string]string{
+	"inquiry":      snap.EndpointAccountInquiry,
+	"transfer":     snap.EndpointTransferInterbank,
+	"status":       snap.EndpointInquiryStatus,
+	"balance":      snap.EndpointInquiryBalance,
+	"history":      snap.EndpointHistoryList,
+	"topup":        snap.EndpointCustomerTopup,
+	"topup-status": snap.EndpointCustomerTopupStatus,
+	"bill-inquiry": snap.EndpointBillInquiry,
+	"bill-pay":     snap.EndpointBillPayment,
+}
+
+// endpointPath returns the path of an endpoint given by path or name
+func endpointPath(endpoint string) (string, bool) {
+	if _, ok := endpoints[endpoint]; ok {
+		return endpoint, true
+	}
+	path, ok := endpointNames[endpoint]
+	return path, ok
+}
+
+// Validate checks that the fault can be applied
+func (f Fault) Validate() error {
+	switch f.Kind {
+	case FaultTimeout, FaultServerError, FaultDuplicateReference, FaultInsufficientFunds, FaultInProgress, FaultCallback:
+	default:
+		return fmt.Errorf("unknown fault kind %q", f.Kind)
+	}
+	if _, ok := endpointPath(f.Endpoint); f.Endpoint != "" && !ok {
+		return fmt.Errorf("%s fault: unknown endpoint %q", f.Kind, f.Endpoint)
+	}
+	if f.Skip < 0 || f.Times < 0 || f.Polls < 0 || f.Copies < 0 || f.Delay < 0 {
+		return fmt.Errorf("%s fault: negative skip, times, polls, copies or delay", f.Kind)
+	}
+	if f.HTTPStatus != 0 && (f.HTTPStatus < 500 || f.HTTPStatus > 599) {
+		return fmt.Errorf("%s fault: httpStatus %d is not a 5xx status", f.Kind, f.HTTPStatus)
+	}
+	if f.Status != "" && !f.Status.IsTerminal() {
+		return fmt.Errorf("%s fault: status %q is not a final status", f.Kind, f.Status)
+	}
+	return nil
+}
+
+// WithFaults injects faults into the requests they match. It panics when a fault is
+// invalid.
+func WithFaults(faults ...Fault) Option {
+	return func(s *Server) {
+		for _, f := range faults {
+			if err := s.addFault(f); err != nil {
+				panic("snaptest: " + err.Error())
+			}
+		}
+	}
+}
+
+// AddFault injects a fault into the requests received from now on
+func (s *Server) AddFault(f Fault) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.addFault(f)
+}
+
+// ClearFaults removes all faults
+func (s *Server) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = nil
+}
+
+// fault is an injected Fault with the number of requests it matched so far
+type fault struct {
+	Fault
+	path    string
+	matched int
+}
+
+func (s *Server) addFault(f Fault) error {
+	if err := f.Validate(); err != nil {
+		return err
+	}
+	path, _ := endpointPath(f.Endpoint)
+	s.faults = append(s.faults, &fault{Fault: f, path: path})
+	return nil
+}
+
+// matchFaults returns the faults that apply to a request, counting it against their
+// Skip and Times
+func (s *Server) matchFaults(path string, body []byte) []Fault {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var accounts []string
+	var matched []Fault
+	for _, f := range s.faults {
+		if f.path != "" && f.path != path {
+			continue
+		}
+		if f.Account != "" {
+			if accounts == nil {
+				accounts = s.accountsOf(body)
+			}
+			if !slices.Contains(accounts, f.Account) {
+				continue
+			}
+		}
+
+		f.matched++
+		if f.matched <= f.Skip || (f.Times > 0 && f.matched > f.Skip+f.Times) {
+			continue
+		}
+		matched = append(matched, f.Fault)
+	}
+	return matched
+}
+
+// accountsOf returns the accounts a request body involves. Status requests involve
+// the accounts of the transaction they look up. s.mu must be held.
+func (s *Server) accountsOf(body []byte) []string {
+	var probe struct {
+		AccountNo                  string `json:"accountNo"`
+		SourceAccountNo            string `json:"sourceAccountNo"`
+		SourceAccount              string `json:"sourceAccount"`
+		BeneficiaryAccountNo       string `json:"beneficiaryAccountNo"`
+		CustomerNumber             string `json:"customerNumber"`
+		VirtualAccountNo           string `json:"virtualAccountNo"`
+		OriginalPartnerReferenceNo string `json:"originalPartnerReferenceNo"`
+		AdditionalInfo             struct {
+			AccountNo     string `json:"accountNo"`
+			SourceAccount string `json:"sourceAccount"`
+		} `json:"additionalInfo"`
+	}
+	_ = json.Unmarshal(body, &probe)
+
+	accounts := []string{
+		probe.AccountNo, probe.SourceAccountNo, probe.SourceAccount, probe.BeneficiaryAccountNo,
+		probe.CustomerNumber, strings.TrimSpace(probe.VirtualAccountNo),
+		probe.AdditionalInfo.AccountNo, probe.AdditionalInfo.SourceAccount,
+	}
+	if probe.OriginalPartnerReferenceNo != "" {
+		for _, trx := range s.transactions {
+			if trx.PartnerReferenceNo == probe.OriginalPartnerReferenceNo {
+				accounts = append(accounts, trx.SourceAccount, trx.BeneficiaryAccountNo, trx.CustomerNumber, trx.VirtualAccountNo)
+			}
+		}
+	}
+	return slices.DeleteFunc(accounts, func(account string) bool { return account == "" })
+}
+
+// firstFault returns the first fault of the given kind
+func firstFault(faults []Fault, kind FaultKind) (Fault, bool) {
+	for _, f := range faults {
+		if f.Kind == kind {
+			return f, true
+		}
+	}
+	return Fault{}, false
+}
+
+// rejection returns the error of the first fault that stops a request before it is
+// processed
+func rejection(faults []Fault) *apiError {
+	for _, f := range faults {
+		switch f.Kind {
+		case FaultServerError:
+			return errorf(cmp.Or(f.HTTPStatus, http.StatusInternalServerError), "00", "")
+		case FaultDuplicateReference:
+			return errorf(http.StatusConflict, "01", "")
+		case FaultInsufficientFunds:
+			return errorf(http.StatusForbidden, "14", "")
+		}
+	}
+	return nil
+}
+
+// hold blocks for delay, or until ctx is done when delay is zero. It returns early,
+// reporting false, when the server is closed.
+func (s *Server) hold(ctx context.Context, delay time.Duration) bool {
+	var elapsed <-chan time.Time
+	if delay > 0 {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		elapsed = timer.C
+	}
+	select {
+	case <-elapsed:
+		return true
+	case <-ctx.Done():
+		return true
+	case <-s.done:
+		return false
+	}
+}
@@ -0,0 +1,248 @@
+package snaptest
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/andremaeshaa/faspay-sendme-snap-go/snap"
+	"github.com/andremaeshaa/faspay-sendme-snap-go/snap/callback"
+)
+
+// TestFaultTimeout tests that a transfer whose response times out is still applied
+// and found by a status inquiry
+func TestFaultTimeout(t *testing.T) {
+	server, _ := newTestServer(t)
+	if err := server.AddFault(Fault{Kind: FaultTimeout, Endpoint: "transfer", Times: 1}); err != nil {
+		t.Fatal(err)
+	}
+	client, err := server.NewClient(snap.WithClock(func() time.Time { return testTime }), snap.WithTimeout(200*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	if _, err := client.TransferInterBank(ctx, transferRequest("TRX0001", snap.MustIDR(10_000))); err == nil {
+		t.Fatal("Expected the transfer to time out")
+	}
+	expectBalance(t, server, snap.MustIDR(990_000))
+
+	status, err := client.StatusTransfer(ctx, snap.NewStatusTransferRequest("TRX0001", ""))
+	if err != nil || status.Status() != snap.StatusSuccess {
+		t.Errorf("Expected the transfer to be found, got %+v, %v", status, err)
+	}
+
+	if _, err := client.TransferInterBank(ctx, transferRequest("TRX0002", snap.MustIDR(10_000))); err != nil {
+		t.Errorf("Expected the fault to apply once, got %v", err)
+	}
+}
+
+// TestFaultInvalidRequest tests that faults leave requests failing validation to be
+// rejected as such, without counting them
+func TestFaultInvalidRequest(t *testing.T) {
+	server, _ := newTestServer(t)
+	if err := server.AddFault(Fault{Kind: FaultTimeout, Endpoint: "balance", Delay: time.Millisecond, Times: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	send := func(body string) (int, string) {
+		t.Helper()
+		timestamp := snap.NewTime(testTime).String()
+		digest := sha256.Sum256([]byte(snap.StringToSign(http.MethodPost, snap.EndpointInquiryBalance, []byte(body), timestamp)))
+		signature, err := rsa.SignPKCS1v15(rand.Reader, testKeys().partner, crypto.SHA256, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		req := httptest.NewRequest(http.MethodPost, snap.EndpointInquiryBalance, strings.NewReader(body))
+		req.Header.Set("X-TIMESTAMP", timestamp)
+		req.Header.Set("X-SIGNATURE", base64.StdEncoding.EncodeToString(signature))
+		req.Header.Set("X-PARTNER-ID", DefaultPartnerID)
+		req.Header.Set("X-EXTERNAL-ID", body)
+		req.Header.Set("CHANNEL-ID", "88001")
+		recorder := httptest.NewRecorder()
+		server.ServeHTTP(recorder, req)
+		var response errorResponse
+		_ = json.Unmarshal(recorder.Body.Bytes(), &response)
+		return recorder.Code, response.ResponseCode
+	}
+
+	if status, code := send(`{}`); status != http.StatusBadRequest || code != "4001102" {
+		t.Errorf("Expected a missing field error, got %d %s", status, code)
+	}
+	if status, code := send(`{"accountNo":"9920017573"}`); status != http.StatusGatewayTimeout {
+		t.Errorf("Expected the fault to apply to the next valid request, got %d %s", status, code)
+	}
+}
+
+// TestFaultRejections tests the faults that answer with an error without processing
+// the request
+func TestFaultRejections(t *testing.T) {
+	server, client := newTestServer(t)
+	ctx := context.Background()
+	server.SetBalance("1111111111", snap.MustIDR(1_000_000))
+
+	for _, f := range []Fault{
+		{Kind: FaultServerError, Endpoint: snap.EndpointInquiryBalance, Times: 1},
+		{Kind: FaultInsufficientFunds, Endpoint: "transfer", Account: "9920017573"},
+		{Kind: FaultDuplicateReference, Endpoint: "transfer", Account: "1111111111", Skip: 1, Times: 1},
+	} {
+		if err := server.AddFault(f); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	balanceRequest := &snap.InquiryBalanceRequest{AccountNo: "9920017573"}
+	if _, err := client.InquiryBalance(ctx, balanceRequest); !errors.Is(err, snap.ErrServer) {
+		t.Errorf("Expected a server error, got %v", err)
+	}
+	if _, err := client.InquiryBalance(ctx, balanceRequest); err != nil {
+		t.Errorf("Expected the fault to apply once, got %v", err)
+	}
+
+	_, err := client.TransferInterBank(ctx, transferRequest("TRX0001", snap.MustIDR(10_000)))
+	if !errors.Is(err, snap.ErrInsufficientFunds) {
+		t.Errorf("Expected insufficient funds for the faulty account, got %v", err)
+	}
+	expectBalance(t, server, snap.MustIDR(1_000_000))
+
+	other := transferRequest("TRX0002", snap.MustIDR(10_000))
+	other.SourceAccountNo = "1111111111"
+	if _, err := client.TransferInterBank(ctx, other); err != nil {
+		t.Errorf("Expected the transfer from another account to succeed, got %v", err)
+	}
+	other.PartnerReferenceNo = "TRX0003"
+	if _, err := client.TransferInterBank(ctx, other); !errors.Is(err, snap.ErrDuplicateReference) {
+		t.Errorf("Expected a duplicate reference error, got %v", err)
+	}
+	if _, ok := server.Transaction("TRX0003"); ok {
+		t.Error("Expected the rejected transfer not to be recorded")
+	}
+}
+
+// TestFaultInProgress tests a transfer held in progress for two polls that then fails,
+// with a delayed and duplicated notification
+func TestFaultInProgress(t *testing.T) {
+	server, client := newTestServer(t)
+	ctx := context.Background()
+	for _, f := range []Fault{
+		{Kind: FaultInProgress, Endpoint: "transfer", Polls: 2, Status: snap.StatusFailed},
+		{Kind: FaultCallback, Delay: 10 * time.Millisecond, Copies: 2},
+	} {
+		if err := server.AddFault(f); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var mu sync.Mutex
+	var events []*callback.TransferEvent
+	handler, err := callback.NewHandler(server.FaspayPublicKey(),
+		callback.WithClock(func() time.Time { return testTime }),
+		callback.OnTransfer(func(ctx context.Context, event *callback.TransferEvent) error {
+			mu.Lock()
+			defer mu.Unlock()
+			events = append(events, event)
+			return nil
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	receiver := httptest.NewServer(handler)
+	defer receiver.Close()
+
+	request := transferRequest("TRX0001", snap.MustIDR(10_000))
+	request.AdditionalInfo = &snap.AdditionalInfoTransferInterBank{CallbackUrl: receiver.URL + "/notify/partner%2Fsendme"} // Escaped path is signed
+	transfer, err := client.TransferInterBank(ctx, request)
+	if err != nil {
+		t.Fatalf("TransferInterBank failed: %v", err)
+	}
+	if transfer.ResponseCode != "2021800" || transfer.AdditionalInfo.Status() != snap.StatusInProgress {
+		t.Errorf("Unexpected response %s %q", transfer.ResponseCode, transfer.AdditionalInfo.LatestTransactionStatus)
+	}
+	expectBalance(t, server, snap.MustIDR(990_000))
+
+	for _, expected := range []snap.TransactionStatusCode{snap.StatusInProgress, snap.StatusInProgress, snap.StatusFailed, snap.StatusFailed} {
+		status, err := client.StatusTransfer(ctx, transfer.StatusRequest())
+		if err != nil || status.Status() != expected {
+			t.Fatalf("Expected status %q, got %+v, %v", expected, status, err)
+		}
+	}
+	expectBalance(t, server, snap.MustIDR(1_000_000))
+
+	server.WaitCallbacks()
+	callbacks := server.Callbacks()
+	if len(callbacks) != 2 || callbacks[0].StatusCode != http.StatusOK || callbacks[0].ExternalID == callbacks[1].ExternalID {
+		t.Fatalf("Unexpected callbacks %+v", callbacks)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(events) != 2 || events[1].OriginalPartnerReferenceNo != "TRX0001" || events[1].LatestTransactionStatus != snap.StatusFailed {
+		t.Errorf("Unexpected events %+v", events)
+	}
+}
+
+// TestWaitCallbacksConcurrent tests that WaitCallbacks can run while transfers start new deliveries
+func TestWaitCallbacksConcurrent(t *testing.T) {
+	server, client := newTestServer(t)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer receiver.Close()
+
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				server.WaitCallbacks()
+			}
+		}
+	}()
+	for i := range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			request := transferRequest(fmt.Sprintf("TRX%04d", i), snap.MustIDR(1_000))
+			request.AdditionalInfo = &snap.AdditionalInfoTransferInterBank{CallbackUrl: receiver.URL}
+			if _, err := client.TransferInterBank(context.Background(), request); err != nil {
+				t.Errorf("TransferInterBank failed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	close(stop)
+
+	server.WaitCallbacks()
+	if callbacks := server.Callbacks(); len(callbacks) != 10 {
+		t.Errorf("Expected 10 callbacks, got %d", len(callbacks))
+	}
+}
+
+// TestFaultValidate tests that invalid faults are rejected
+func TestFaultValidate(t *testing.T) {
+	server, _ := newTestServer(t)
+	for _, f := range []Fault{
+		{Kind: "flaky"},
+		{Kind: FaultTimeout, Endpoint: "refund"},
+		{Kind: FaultServerError, HTTPStatus: 404},
+		{Kind: FaultInProgress, Status: snap.StatusInProgress},
+		{Kind: FaultCallback, Copies: -1},
+	} {
+		if err := server.AddFault(f); err == nil {
+			t.Errorf("Expected an error for %+v", f)
+		}
+	}
+}
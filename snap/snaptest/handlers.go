@@ -0,0 +1,413 @@
+package snaptest
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/andremaeshaa/faspay-sendme-snap-go/snap"
+	"github.com/andremaeshaa/faspay-sendme-snap-go/snap/banks"
+)
+
+// validatable is a request body the snap package can check
+type validatable interface {
+	Validate() error
+}
+
+// requestOf returns a new request body of type T for an endpoint
+func requestOf[T any, P interface {
+	*T
+	validatable
+}]() validatable {
+	return P(new(T))
+}
+
+// decode unmarshals body into request and applies the same field rules as the client
+func decode(body []byte, request validatable) *apiError {
+	if err := json.Unmarshal(body, request); err != nil {
+		return errorf(http.StatusBadRequest, "01", "[Body]")
+	}
+
+	var validationErr *snap.ValidationError
+	if err := request.Validate(); errors.As(err, &validationErr) && len(validationErr.Fields) > 0 {
+		field := validationErr.Fields[0]
+		if field.Message == "is required" {
+			return errorf(http.StatusBadRequest, "02", "[%s]", field.Field)
+		}
+		return errorf(http.StatusBadRequest, "01", "[%s]", field.Field)
+	} else if err != nil {
+		return errorf(http.StatusBadRequest, "00", "")
+	}
+	return nil
+}
+
+// nextReferenceNo returns a new Faspay referenceNo. s.mu must be held.
+func (s *Server) nextReferenceNo() string {
+	s.lastReferenceNo++
+	return strconv.Itoa(s.lastReferenceNo)
+}
+
+func (s *Server) accountInquiry(c *call) (any, *apiError) {
+	request := c.request.(*snap.ExternalAccountInquiryRequest)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	name, ok := s.beneficiaries[beneficiaryKey(request.BeneficiaryBankCode, request.BeneficiaryAccountNo)]
+	if !ok {
+		return nil, errorf(http.StatusNotFound, "11", "[Beneficiary Account]")
+	}
+
+	return &snap.ExternalAccountInquiryResponse{
+		ResponseCode:           c.successCode(snap.ServiceCodeAccountInquiryExternal),
+		ResponseMessage:        c.successMessage(),
+		ReferenceNo:            s.nextReferenceNo(),
+		PartnerReferenceNo:     request.PartnerReferenceNo,
+		BeneficiaryAccountName: name,
+		BeneficiaryAccountNo:   request.BeneficiaryAccountNo,
+		BeneficiaryBankCode:    request.BeneficiaryBankCode,
+		BeneficiaryBankName:    bankName(request.BeneficiaryBankCode),
+		Currency:               snap.CurrencyIDR,
+	}, nil
+}
+
+func (s *Server) transferInterBank(c *call) (any, *apiError) {
+	request := c.request.(*snap.TransferInterBankRequest)
+	amount, err := request.Amount.Money()
+	if err != nil {
+		return nil, errorf(http.StatusBadRequest, "01", "[amount]")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.find(snap.ServiceCodeTransferInterbank, request.PartnerReferenceNo, "") != nil {
+		return nil, errorf(http.StatusConflict, "01", "")
+	}
+	name, ok := s.beneficiaries[beneficiaryKey(request.BeneficiaryBankCode, request.BeneficiaryAccountNo)]
+	if !ok {
+		return nil, errorf(http.StatusNotFound, "11", "[Beneficiary Account]")
+	}
+
+	trx := &Transaction{
+		ServiceCode:            snap.ServiceCodeTransferInterbank,
+		PartnerReferenceNo:     request.PartnerReferenceNo,
+		SourceAccount:          request.SourceAccountNo,
+		Amount:                 amount,
+		BeneficiaryBankCode:    request.BeneficiaryBankCode,
+		BeneficiaryAccountNo:   request.BeneficiaryAccountNo,
+		BeneficiaryAccountName: name,
+		Request:                c.body,
+	}
+	if request.AdditionalInfo != nil {
+		trx.CallbackURL = request.AdditionalInfo.CallbackUrl
+	}
+	if apiErr := s.accept(c, trx, "TRANSFER "+request.BeneficiaryAccountNo); apiErr != nil {
+		return nil, apiErr
+	}
+
+	response := &snap.TransferInterBankResponse{
+		ResponseCode:         c.successCode(snap.ServiceCodeTransferInterbank),
+		ResponseMessage:      c.successMessage(),
+		ReferenceNo:          trx.ReferenceNo,
+		PartnerReferenceNo:   trx.PartnerReferenceNo,
+		Amount:               request.Amount,
+		BeneficiaryAccountNo: trx.BeneficiaryAccountNo,
+		BeneficiaryBankCode:  trx.BeneficiaryBankCode,
+		SourceAccountNo:      trx.SourceAccount,
+		AdditionalInfo: &snap.AdditionalInfoTransferInterBankResponse{
+			BeneficiaryAccountName:  name,
+			BeneficiaryBankName:     bankName(trx.BeneficiaryBankCode),
+			LatestTransactionStatus: string(trx.Status),
+			TransactionStatusDesc:   trx.Status.String(),
+		},
+	}
+	if request.AdditionalInfo != nil {
+		response.AdditionalInfo.InstructDate = request.AdditionalInfo.InstructDate
+		response.AdditionalInfo.TransactionDescription = request.AdditionalInfo.TransactionDescription
+		response.AdditionalInfo.CallbackUrl = request.AdditionalInfo.CallbackUrl
+	}
+	return response, nil
+}
+
+func (s *Server) statusTransfer(c *call) (any, *apiError) {
+	request := c.request.(*snap.StatusTransferRequest)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	trx := s.find(snap.ServiceCodeTransferInterbank, request.OriginalPartnerReferenceNo, request.OriginalReferenceNo)
+	if trx == nil || request.ServiceCode != string(snap.ServiceCodeTransferInterbank) {
+		return nil, errorf(http.StatusNotFound, "01", "")
+	}
+	s.poll(trx)
+
+	return &snap.StatusTransferResponse{
+		ResponseCode:               c.successCode(snap.ServiceCodeTransferStatus),
+		ResponseMessage:            c.successMessage(),
+		OriginalReferenceNo:        trx.ReferenceNo,
+		OriginalPartnerReferenceNo: trx.PartnerReferenceNo,
+		ServiceCode:                string(trx.ServiceCode),
+		TransactionDate:            snap.NewTime(trx.Date).String(),
+		Amount:                     trx.Amount.Amount(),
+		BeneficiaryAccountNo:       trx.BeneficiaryAccountNo,
+		BeneficiaryBankCode:        trx.BeneficiaryBankCode,
+		ReferenceNumber:            trx.ReferenceNo,
+		SourceAccountNo:            trx.SourceAccount,
+		LatestTransactionStatus:    string(trx.Status),
+		TransactionStatusDesc:      trx.Status.String(),
+		AdditionalInfo: &snap.AdditionalInfoStatusTransferResponse{
+			BeneficiaryAccountName: trx.BeneficiaryAccountName,
+			BeneficiaryBankName:    bankName(trx.BeneficiaryBankCode),
+			TransactionStatusDate:  snap.NewTime(s.clock()).String(),
+		},
+	}, nil
+}
+
+func (s *Server) inquiryBalance(c *call) (any, *apiError) {
+	request := c.request.(*snap.InquiryBalanceRequest)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	acc, ok := s.accounts[request.AccountNo]
+	if !ok {
+		return nil, errorf(http.StatusNotFound, "11", "[Account]")
+	}
+
+	return &snap.InquiryBalanceResponse{
+		ResponseCode:    c.successCode(snap.ServiceCodeBalanceInquiry),
+		ResponseMessage: c.successMessage(),
+		AccountNo:       request.AccountNo,
+		AccountInfos: []*snap.AccountInfos{{
+			BalanceType: string(snap.BalanceTypeAvailable),
+			Amount:      acc.balance.Amount(),
+			AvailableBalance: &snap.AvailableBalance{
+				Value:    acc.balance.Value(),
+				Currency: acc.balance.Currency(),
+			},
+		}},
+	}, nil
+}
+
+func (s *Server) historyList(c *call) (any, *apiError) {
+	request := c.request.(*snap.HistoryListRequest)
+	from, _ := request.From()
+	to, _ := request.To()
+	accountNo := request.AdditionalInfo.AccountNo
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.accounts[accountNo]; !ok {
+		return nil, errorf(http.StatusNotFound, "11", "[Account]")
+	}
+
+	return &snap.HistoryListResponse{
+		ResponseCode:    c.successCode(snap.ServiceCodeHistoryList),
+		ResponseMessage: c.successMessage(),
+		DetailData:      s.history(accountNo, from.Time, to.Time),
+		AdditionalInfo: &snap.AdditionalInfoHistoryListResponse{
+			AccountNo:    accountNo,
+			FromDateTime: request.FromDateTime,
+			ToDateTime:   request.ToDateTime,
+		},
+	}, nil
+}
+
+func (s *Server) customerTopup(c *call) (any, *apiError) {
+	request := c.request.(*snap.CustomerTopupRequest)
+	amount, err := request.Amount.Money()
+	if err != nil {
+		return nil, errorf(http.StatusBadRequest, "01", "[amount]")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.find(snap.ServiceCodeEmoneyTopup, request.PartnerReferenceNo, "") != nil {
+		return nil, errorf(http.StatusConflict, "01", "")
+	}
+
+	trx := &Transaction{
+		ServiceCode:        snap.ServiceCodeEmoneyTopup,
+		PartnerReferenceNo: request.PartnerReferenceNo,
+		SourceAccount:      s.defaultAccount,
+		Amount:             amount,
+		CustomerNumber:     request.CustomerNumber,
+		PlatformCode:       snap.PlatformCode(request.AdditionalInfo.PlatformCode),
+		CallbackURL:        request.AdditionalInfo.CallbackUrl,
+		Request:            c.body,
+	}
+	if request.AdditionalInfo.SourceAccount != "" {
+		trx.SourceAccount = request.AdditionalInfo.SourceAccount
+	}
+	if apiErr := s.accept(c, trx, "TOPUP "+request.CustomerNumber); apiErr != nil {
+		return nil, apiErr
+	}
+
+	return &snap.CustomerTopupResponse{
+		ResponseCode:       c.successCode(snap.ServiceCodeEmoneyTopup),
+		ResponseMessage:    c.successMessage(),
+		ReferenceNo:        trx.ReferenceNo,
+		PartnerReferenceNo: trx.PartnerReferenceNo,
+		CustomerNumber:     trx.CustomerNumber,
+		Amount:             request.Amount,
+		AdditionalInfo: &snap.AdditionalInfoCustomerTopup{
+			SourceAccount:           trx.SourceAccount,
+			PlatformCode:            string(trx.PlatformCode),
+			BeneficiaryEmail:        request.AdditionalInfo.BeneficiaryEmail,
+			TransactionDate:         request.TransactionDate,
+			InstructDate:            request.AdditionalInfo.InstructDate,
+			TransactionDescription:  request.AdditionalInfo.TransactionDescription,
+			CallbackUrl:             request.AdditionalInfo.CallbackUrl,
+			TransactionReference:    trx.ReferenceNo,
+			LatestTransactionStatus: string(trx.Status),
+			TransactionStatusDesc:   trx.Status.String(),
+		},
+	}, nil
+}
+
+func (s *Server) customerTopupStatus(c *call) (any, *apiError) {
+	request := c.request.(*snap.CustomerTopupStatusRequest)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	trx := s.find(snap.ServiceCodeEmoneyTopup, request.OriginalPartnerReferenceNo, request.OriginalReferenceNo)
+	if trx == nil || request.ServiceCode != string(snap.ServiceCodeEmoneyTopup) {
+		return nil, errorf(http.StatusNotFound, "01", "")
+	}
+	s.poll(trx)
+
+	return &snap.CustomerTopupStatusResponse{
+		ResponseCode:               c.successCode(snap.ServiceCodeEmoneyTopupStatus),
+		ResponseMessage:            c.successMessage(),
+		OriginalReferenceNo:        trx.ReferenceNo,
+		OriginalPartnerReferenceNo: trx.PartnerReferenceNo,
+		ServiceCode:                string(trx.ServiceCode),
+		Amount:                     trx.Amount.Amount(),
+		LatestTransactionStatus:    string(trx.Status),
+		TransactionStatusDesc:      trx.Status.String(),
+		AdditionalInfo: &snap.AdditionalInfoTopupStatus{
+			SourceAccount:         trx.SourceAccount,
+			TransactionDate:       snap.NewTime(trx.Date).String(),
+			PlatformCode:          string(trx.PlatformCode),
+			PlatformName:          trx.PlatformCode.String(),
+			CustomerNumber:        trx.CustomerNumber,
+			TransactionStatusDate: snap.NewTime(s.clock()).String(),
+		},
+	}, nil
+}
+
+func (s *Server) billInquiry(c *call) (any, *apiError) {
+	request := c.request.(*snap.BillInquiryRequest)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	va, apiErr := s.unpaidBill(request.VirtualAccountNo)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	trxType := "C" // Closed amount
+	if va.Amount.IsZero() {
+		trxType = "O" // Open amount
+	}
+	response := &snap.BillInquiryResponse{
+		ResponseCode:    c.successCode(serviceCodeBillInquiry),
+		ResponseMessage: c.successMessage(),
+		VirtualAccountData: &snap.VirtualAccountData{
+			PartnerServiceId:      va.PartnerServiceID,
+			CustomerNo:            va.CustomerNo,
+			VirtualAccountNo:      va.VirtualAccountNo,
+			VirtualAccountName:    va.Name,
+			TotalAmount:           va.Amount.Amount(),
+			VirtualAccountTrxType: trxType,
+			PartnerReferenceNo:    request.PartnerReferenceNo,
+		},
+	}
+	if request.AdditionalInfo != nil {
+		response.AdditionalInfo = &snap.AdditionalInfoBillInquiryResponse{
+			BillerCode:    request.AdditionalInfo.BillerCode,
+			SourceAccount: request.AdditionalInfo.SourceAccount,
+		}
+	}
+	return response, nil
+}
+
+func (s *Server) billPayment(c *call) (any, *apiError) {
+	request := c.request.(*snap.BillPaymentRequest)
+	amount, err := request.PaidAmount.Money()
+	if err != nil {
+		return nil, errorf(http.StatusBadRequest, "01", "[paidAmount]")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.find(snap.ServiceCodeBillPayment, request.PartnerReferenceNo, "") != nil {
+		return nil, errorf(http.StatusConflict, "01", "")
+	}
+	va, apiErr := s.unpaidBill(request.VirtualAccountNo)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if !va.Amount.IsZero() && !va.Amount.Equal(amount) {
+		return nil, errorf(http.StatusNotFound, "13", "")
+	}
+
+	trx := &Transaction{
+		ServiceCode:        snap.ServiceCodeBillPayment,
+		PartnerReferenceNo: request.PartnerReferenceNo,
+		SourceAccount:      request.SourceAccount,
+		Amount:             amount,
+		VirtualAccountNo:   va.VirtualAccountNo,
+		Request:            c.body,
+	}
+	if request.AdditionalInfo != nil {
+		trx.CallbackURL = request.AdditionalInfo.CallbackUrl
+	}
+	if apiErr := s.accept(c, trx, "PAYMENT "+va.VirtualAccountNo); apiErr != nil {
+		return nil, apiErr
+	}
+	va.Paid = true
+
+	response := &snap.BillPaymentResponse{
+		ResponseCode:    c.successCode(snap.ServiceCodeBillPayment),
+		ResponseMessage: c.successMessage(),
+		VirtualAccountData: &snap.VirtualAccountDataBillPayment{
+			PartnerReferenceNo: trx.PartnerReferenceNo,
+			ReferenceNo:        trx.ReferenceNo,
+			PartnerServiceId:   va.PartnerServiceID,
+			CustomerNo:         va.CustomerNo,
+			VirtualAccountNo:   va.VirtualAccountNo,
+			VirtualAccountName: va.Name,
+			SourceAccount:      trx.SourceAccount,
+			PaidAmount:         request.PaidAmount,
+			TrxDateTime:        request.TrxDateTime,
+		},
+	}
+	if request.AdditionalInfo != nil {
+		response.AdditionalInfo = &snap.AdditionalInfoBillPaymentResponse{
+			BillerCode:   request.AdditionalInfo.BillerCode,
+			InstructDate: request.AdditionalInfo.InstructDate,
+			CallbackUrl:  request.AdditionalInfo.CallbackUrl,
+		}
+	}
+	return response, nil
+}
+
+// unpaidBill returns the virtual account that can still be paid. s.mu must be held.
+func (s *Server) unpaidBill(virtualAccountNo string) (*VirtualAccount, *apiError) {
+	va, ok := s.virtualAccounts[strings.TrimSpace(virtualAccountNo)]
+	if !ok {
+		return nil, errorf(http.StatusNotFound, "12", "")
+	}
+	if va.Paid {
+		return nil, errorf(http.StatusNotFound, "14", "")
+	}
+	return va, nil
+}
+
+// bankName returns the name of a bank from the embedded list
+func bankName(code string) string {
+	if bank, ok := banks.ByCode(code); ok {
+		return bank.Name
+	}
+	return ""
+}
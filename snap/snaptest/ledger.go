@@ -0,0 +1,301 @@
+package snaptest
+
+import (
+	"cmp"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/andremaeshaa/faspay-sendme-snap-go/snap"
+)
+
+// account is a source account held by the server
+type account struct {
+	balance snap.Money
+	history []*snap.DetailData
+}
+
+// VirtualAccount is a bill that can be paid with BillPayment
+type VirtualAccount struct {
+	PartnerServiceID string     `json:"partnerServiceId"`           // Biller prefix, left-padded with spaces to 8 characters
+	CustomerNo       string     `json:"customerNo"`                 // Customer number within the biller
+	VirtualAccountNo string     `json:"virtualAccountNo,omitempty"` // Defaults to the trimmed PartnerServiceID followed by CustomerNo
+	Name             string     `json:"name,omitempty"`             // Name returned by BillInquiry
+	Amount           snap.Money `json:"amount"`                     // Amount due; zero accepts any paid amount
+	Paid             bool       `json:"paid,omitempty"`             // Whether the bill was paid
+}
+
+// Transaction is a transfer, top-up or bill payment accepted by the server
+type Transaction struct {
+	ServiceCode        snap.ServiceCode // ServiceCodeTransferInterbank, ServiceCodeEmoneyTopup or ServiceCodeBillPayment
+	PartnerReferenceNo string
+	ReferenceNo        string // Assigned by the server
+	SourceAccount      string
+	Amount             snap.Money
+	Status             snap.TransactionStatusCode
+	Date               time.Time
+
+	BeneficiaryBankCode    string            // Transfers
+	BeneficiaryAccountNo   string            // Transfers
+	BeneficiaryAccountName string            // Transfers
+	CustomerNumber         string            // Top-ups
+	PlatformCode           snap.PlatformCode // Top-ups
+	VirtualAccountNo       string            // Bill payments
+
+	PartnerID   string // Partner that sent the request
+	CallbackURL string // additionalInfo.callbackUrl of the request
+	Request     []byte // Body of the request that created the transaction
+
+	entry        *snap.DetailData           // History entry of the debit
+	pendingPolls int                        // Status polls still answered with in progress
+	settleStatus snap.TransactionStatusCode // Status reached after pendingPolls, if any
+	callback     *Fault                     // FaultCallback applied to notifications
+}
+
+// Balance returns the balance of a source account
+func (s *Server) Balance(accountNo string) (snap.Money, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	acc, ok := s.accounts[accountNo]
+	if !ok {
+		return snap.Money{}, false
+	}
+	return acc.balance, true
+}
+
+// SetBalance sets the balance of a source account, opening it when needed. The change
+// does not appear in the history.
+func (s *Server) SetBalance(accountNo string, balance snap.Money) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.addAccount(accountNo, balance)
+}
+
+// AddBeneficiary registers a bank account that AccountInquiry finds and
+// TransferInterBank can credit
+func (s *Server) AddBeneficiary(bankCode, accountNo, name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.beneficiaries[beneficiaryKey(bankCode, accountNo)] = name
+}
+
+// AddVirtualAccount registers a bill that BillInquiry finds and BillPayment can pay
+func (s *Server) AddVirtualAccount(va VirtualAccount) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.addVirtualAccount(va)
+}
+
+// VirtualAccount returns the bill with the given virtual account number
+func (s *Server) VirtualAccount(virtualAccountNo string) (VirtualAccount, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	va, ok := s.virtualAccounts[strings.TrimSpace(virtualAccountNo)]
+	if !ok {
+		return VirtualAccount{}, false
+	}
+	return *va, true
+}
+
+// Transactions returns the transactions accepted so far, in order
+func (s *Server) Transactions() []Transaction {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	list := make([]Transaction, len(s.transactions))
+	for i, trx := range s.transactions {
+		list[i] = trx.snapshot()
+	}
+	return list
+}
+
+// Transaction returns the transaction with the given partnerReferenceNo
+func (s *Server) Transaction(partnerReferenceNo string) (Transaction, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, trx := range s.transactions {
+		if trx.PartnerReferenceNo == partnerReferenceNo {
+			return trx.snapshot(), true
+		}
+	}
+	return Transaction{}, false
+}
+
+// SetStatus changes the latestTransactionStatus of a transaction, for example to fail
+// a transfer after it was accepted. When a transaction becomes failed, canceled or
+// refunded, its amount is credited back to the source account and the refund appears
+// in the history; such a transaction cannot change anymore.
+func (s *Server) SetStatus(partnerReferenceNo string, status snap.TransactionStatusCode) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, trx := range s.transactions {
+		if trx.PartnerReferenceNo == partnerReferenceNo {
+			return s.setStatus(trx, status)
+		}
+	}
+	return fmt.Errorf("snaptest: no transaction with partnerReferenceNo %q", partnerReferenceNo)
+}
+
+func (s *Server) setStatus(trx *Transaction, status snap.TransactionStatusCode) error {
+	if trx.Status.IsTerminal() && !trx.Status.IsSuccess() && trx.Status != status {
+		return fmt.Errorf("snaptest: transaction %s is already %s", trx.PartnerReferenceNo, trx.Status)
+	}
+
+	if trx.Status == status {
+		return nil
+	}
+
+	trx.Status = status
+	trx.settleStatus = ""
+	trx.entry.Status = string(historyStatus(status))
+	if status.IsTerminal() && !status.IsSuccess() {
+		acc := s.accounts[trx.SourceAccount]
+		acc.balance, _ = acc.balance.Add(trx.Amount)
+		acc.history = append(acc.history, s.historyEntry(trx, snap.Credit, "REFUND "+trx.PartnerReferenceNo))
+		if va, ok := s.virtualAccounts[trx.VirtualAccountNo]; ok {
+			va.Paid = false
+		}
+	}
+	if status.IsTerminal() {
+		s.notify(trx)
+	}
+	return nil
+}
+
+// poll answers a status poll of trx, settling a transaction held in progress by
+// FaultInProgress once its polls are used up. s.mu must be held.
+func (s *Server) poll(trx *Transaction) {
+	if trx.Status != snap.StatusInProgress || trx.settleStatus == "" {
+		return
+	}
+	if trx.pendingPolls > 0 {
+		trx.pendingPolls--
+		return
+	}
+	_ = s.setStatus(trx, trx.settleStatus)
+}
+
+// addAccount opens accountNo or sets its balance; s.mu must be held or s not started
+func (s *Server) addAccount(accountNo string, balance snap.Money) {
+	if acc, ok := s.accounts[accountNo]; ok {
+		acc.balance = balance
+		return
+	}
+	s.accounts[accountNo] = &account{balance: balance}
+	if s.defaultAccount == "" {
+		s.defaultAccount = accountNo
+	}
+}
+
+func (s *Server) addVirtualAccount(va VirtualAccount) {
+	if va.VirtualAccountNo == "" {
+		va.VirtualAccountNo = strings.TrimSpace(va.PartnerServiceID) + va.CustomerNo
+	}
+	s.virtualAccounts[va.VirtualAccountNo] = &va
+}
+
+// accept takes trx.Amount from its source account and records the transaction, in
+// progress when c matched FaultInProgress. s.mu must be held.
+func (s *Server) accept(c *call, trx *Transaction, remark string) *apiError {
+	acc, ok := s.accounts[trx.SourceAccount]
+	if !ok {
+		return errorf(http.StatusNotFound, "11", "[Source Account]")
+	}
+	if cmp, err := acc.balance.Cmp(trx.Amount); err != nil || cmp < 0 {
+		return errorf(http.StatusForbidden, "14", "")
+	}
+
+	trx.ReferenceNo = s.nextReferenceNo()
+	trx.PartnerID = c.partnerID
+	trx.Date = s.clock()
+	trx.Status = snap.StatusSuccess
+	if fault, ok := firstFault(c.faults, FaultInProgress); ok {
+		trx.Status = snap.StatusInProgress
+		trx.pendingPolls = fault.Polls
+		trx.settleStatus = cmp.Or(fault.Status, snap.StatusSuccess)
+		c.status = http.StatusAccepted
+	}
+	if fault, ok := firstFault(c.faults, FaultCallback); ok {
+		trx.callback = &fault
+	}
+	trx.entry = s.historyEntry(trx, snap.Debit, remark)
+
+	acc.balance, _ = acc.balance.Sub(trx.Amount)
+	acc.history = append(acc.history, trx.entry)
+	s.transactions = append(s.transactions, trx)
+	if trx.Status.IsTerminal() {
+		s.notify(trx)
+	}
+	return nil
+}
+
+// find returns the transaction of a service with the given references. referenceNo
+// is only checked when set. s.mu must be held.
+func (s *Server) find(serviceCode snap.ServiceCode, partnerReferenceNo, referenceNo string) *Transaction {
+	for _, trx := range s.transactions {
+		if trx.ServiceCode == serviceCode && trx.PartnerReferenceNo == partnerReferenceNo &&
+			(referenceNo == "" || trx.ReferenceNo == referenceNo) {
+			return trx
+		}
+	}
+	return nil
+}
+
+// historyEntry returns the history record of a debit or credit for trx
+func (s *Server) historyEntry(trx *Transaction, direction snap.DebitCredit, remark string) *snap.DetailData {
+	kind := snap.TransactionTypeTransfer
+	switch trx.ServiceCode {
+	case snap.ServiceCodeEmoneyTopup:
+		kind = snap.TransactionTypeTopup
+	case snap.ServiceCodeBillPayment:
+		kind = snap.TransactionTypePayment
+	}
+	status := historyStatus(trx.Status)
+	if direction == snap.Credit {
+		status = snap.HistoryStatusSuccess
+	}
+	return &snap.DetailData{
+		DateTime:       snap.NewTime(s.clock()).String(),
+		Amount:         trx.Amount.Amount(),
+		Remark:         remark,
+		SourceOfFunds:  []*snap.SourceOfFunds{{Source: trx.SourceAccount}},
+		Status:         string(status),
+		Type:           string(kind),
+		AdditionalInfo: &snap.AdditionalInfoDetailData{DebitCredit: string(direction)},
+	}
+}
+
+// historyStatus maps a transaction status to the status of its history entry
+func historyStatus(status snap.TransactionStatusCode) snap.HistoryStatus {
+	switch {
+	case status.IsSuccess():
+		return snap.HistoryStatusSuccess
+	case status.IsTerminal():
+		return snap.HistoryStatusFailed
+	}
+	return snap.HistoryStatusPending
+}
+
+// history returns the entries of accountNo between from and to, both inclusive. s.mu
+// must be held.
+func (s *Server) history(accountNo string, from, to time.Time) []*snap.DetailData {
+	var entries []*snap.DetailData
+	for _, entry := range s.accounts[accountNo].history {
+		at, err := snap.ParseTime(entry.DateTime)
+		if err != nil || at.Before(from) || at.After(to) {
+			continue
+		}
+		copied := *entry
+		entries = append(entries, &copied)
+	}
+	return entries
+}
+
+func (trx *Transaction) snapshot() Transaction {
+	copied := *trx
+	copied.Request = slices.Clone(trx.Request)
+	copied.entry = nil
+	copied.callback = nil
+	return copied
+}
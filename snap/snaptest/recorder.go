@@ -0,0 +1,376 @@
+package snaptest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/andremaeshaa/faspay-sendme-snap-go/snap"
+)
+
+// RecorderMode selects whether a Recorder talks to the API or to its cassette
+type RecorderMode int
+
+const (
+	// ModeReplay answers every request from the cassette and never touches the network
+	ModeReplay RecorderMode = iota
+	// ModeRecord sends every request to the API and records the interaction
+	ModeRecord
+	// ModeRecordOnce replays the cassette when the file exists and records it otherwise
+	ModeRecordOnce
+)
+
+// scrubbed replaces the values of headers that carry secrets or signatures
+const scrubbed = "[scrubbed]"
+
+// ErrInteractionNotFound is returned in replay mode for a request that the cassette has
+// no unused interaction for
+var ErrInteractionNotFound = errors.New("snaptest: no recorded interaction matches the request")
+
+// Cassette is the file a Recorder reads and writes. Interactions are stored in the order
+// they were recorded.
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction is a recorded request and the response it got
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// RecordedRequest is the scrubbed form of a request. Body holds the normalized JSON body
+// that requests are matched on.
+type RecordedRequest struct {
+	Method   string            `json:"method"`
+	Endpoint string            `json:"endpoint"`
+	Header   map[string]string `json:"header,omitempty"`
+	Body     json.RawMessage   `json:"body,omitempty"`
+}
+
+// RecordedResponse is the scrubbed form of a response. Text holds bodies that are not
+// JSON, such as the HTML error pages of a gateway.
+type RecordedResponse struct {
+	StatusCode int               `json:"statusCode"`
+	Header     map[string]string `json:"header,omitempty"`
+	Body       json.RawMessage   `json:"body,omitempty"`
+	Text       string            `json:"text,omitempty"`
+}
+
+// Recorder is an http.RoundTripper that records the interactions of a client with the
+// sandbox into a cassette file and replays them offline. Pass its Wrap method to
+// snap.WithTransportRecorder:
+//
+//	recorder, err := snaptest.NewRecorder("testdata/transfer.json", snaptest.ModeRecordOnce)
+//	defer recorder.Save()
+//	client, err := snap.NewClient(partnerID, privateKey, nil,
+//		snap.WithEnvironment(snap.Sandbox), snap.WithTransportRecorder(recorder.Wrap))
+//
+// Requests are matched on method, endpoint path and JSON body with the keys sorted and
+// the ignored fields removed; X-TIMESTAMP, X-EXTERNAL-ID and X-SIGNATURE are never
+// compared. Identical requests replay their recorded responses in order. Signatures,
+// timestamps, partner and external IDs are scrubbed from the cassette, so replayed
+// responses cannot be verified and the replaying client must not use
+// snap.WithFaspayPublicKey.
+type Recorder struct {
+	path      string
+	mode      RecorderMode
+	next      http.RoundTripper
+	redaction *snap.RedactionPolicy
+	ignored   []string
+	scrub     []string
+
+	mu       sync.Mutex
+	cassette Cassette
+	used     []bool
+	replay   bool
+	changed  bool
+}
+
+// RecorderOption configures a Recorder
+type RecorderOption func(*Recorder)
+
+// WithRedaction masks the fields of request and response bodies covered by policy before
+// they are written, for instance snap.DefaultRedactionPolicy to keep account numbers and
+// names out of the repository. Requests are masked the same way before they are matched.
+func WithRedaction(policy *snap.RedactionPolicy) RecorderOption {
+	return func(r *Recorder) {
+		r.redaction = policy
+	}
+}
+
+// WithIgnoredFields sets the JSON body fields, at any depth, that are left out when
+// requests are matched. It defaults to transactionDate, fromDateTime and toDateTime, which
+// callers usually derive from the current time.
+func WithIgnoredFields(fields ...string) RecorderOption {
+	return func(r *Recorder) {
+		r.ignored = fields
+	}
+}
+
+// WithScrubbedHeaders adds headers whose values are replaced before they are written, on
+// top of Authorization, X-SIGNATURE, X-TIMESTAMP, X-PARTNER-ID and X-EXTERNAL-ID
+func WithScrubbedHeaders(headers ...string) RecorderOption {
+	return func(r *Recorder) {
+		r.scrub = append(r.scrub, headers...)
+	}
+}
+
+// NewRecorder returns a Recorder for the cassette at path. In ModeReplay the cassette must
+// exist; in ModeRecordOnce it is replayed when it exists.
+func NewRecorder(path string, mode RecorderMode, opts ...RecorderOption) (*Recorder, error) {
+	r := &Recorder{
+		path:    path,
+		mode:    mode,
+		ignored: []string{"transactionDate", "fromDateTime", "toDateTime"},
+		scrub:   []string{"Authorization", "X-SIGNATURE", "X-TIMESTAMP", "X-PARTNER-ID", "X-EXTERNAL-ID"},
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	switch mode {
+	case ModeRecord:
+		return r, nil
+	case ModeReplay, ModeRecordOnce:
+	default:
+		return nil, fmt.Errorf("snaptest: unknown recorder mode %d", mode)
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) && mode == ModeRecordOnce {
+		return r, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error loading cassette: %w", err)
+	}
+	if err := json.Unmarshal(data, &r.cassette); err != nil {
+		return nil, fmt.Errorf("error loading cassette %s: %w", path, err)
+	}
+	r.used = make([]bool, len(r.cassette.Interactions))
+	r.replay = true
+	return r, nil
+}
+
+// Replaying reports whether the recorder answers from its cassette
+func (r *Recorder) Replaying() bool {
+	return r.replay
+}
+
+// Wrap returns a RoundTripper that records the interactions sent through next, or
+// replays them without calling next. It is meant for snap.WithTransportRecorder.
+func (r *Recorder) Wrap(next http.RoundTripper) http.RoundTripper {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.next = next
+	return r
+}
+
+// Interactions returns the interactions of the cassette, including the ones recorded so far
+func (r *Recorder) Interactions() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Interaction(nil), r.cassette.Interactions...)
+}
+
+// Save writes the cassette when interactions were recorded. It creates the directory of
+// the cassette when needed and does nothing when replaying.
+func (r *Recorder) Save() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.replay || !r.changed {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(r.cassette, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding cassette: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return fmt.Errorf("error saving cassette: %w", err)
+	}
+	if err := os.WriteFile(r.path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("error saving cassette: %w", err)
+	}
+	r.changed = false
+	return nil
+}
+
+// RoundTrip records or replays a request
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	recorded := RecordedRequest{
+		Method:   req.Method,
+		Endpoint: req.URL.Path,
+		Header:   r.scrubHeader(req.Header),
+		Body:     r.normalize(body, false),
+	}
+
+	if r.replay {
+		return r.replayed(req, recorded)
+	}
+
+	r.mu.Lock()
+	next := r.next
+	r.mu.Unlock()
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	forwarded := req.Clone(req.Context())
+	forwarded.Body = io.NopCloser(bytes.NewReader(body))
+	forwarded.ContentLength = int64(len(body))
+	resp, err := next.RoundTrip(forwarded)
+	if err != nil {
+		return nil, err
+	}
+	responseBody, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(responseBody))
+
+	response := RecordedResponse{StatusCode: resp.StatusCode, Header: r.scrubHeader(resp.Header)}
+	if json.Valid(responseBody) {
+		response.Body = r.redact(responseBody)
+	} else {
+		response.Text = string(responseBody)
+	}
+
+	r.mu.Lock()
+	r.cassette.Interactions = append(r.cassette.Interactions, Interaction{Request: recorded, Response: response})
+	r.changed = true
+	r.mu.Unlock()
+	return resp, nil
+}
+
+// replayed answers req with the first unused interaction that matches it
+func (r *Recorder) replayed(req *http.Request, recorded RecordedRequest) (*http.Response, error) {
+	key := r.normalize(recorded.Body, true)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, interaction := range r.cassette.Interactions {
+		candidate := interaction.Request
+		if r.used[i] || candidate.Method != recorded.Method || candidate.Endpoint != recorded.Endpoint ||
+			!bytes.Equal(r.normalize(candidate.Body, true), key) {
+			continue
+		}
+		r.used[i] = true
+
+		body := []byte(interaction.Response.Text)
+		if interaction.Response.Body != nil {
+			body = interaction.Response.Body
+		}
+		header := make(http.Header, len(interaction.Response.Header))
+		for name, value := range interaction.Response.Header {
+			header.Set(name, value)
+		}
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", interaction.Response.StatusCode, http.StatusText(interaction.Response.StatusCode)),
+			StatusCode:    interaction.Response.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header,
+			Body:          io.NopCloser(bytes.NewReader(body)),
+			ContentLength: int64(len(body)),
+			Request:       req,
+		}, nil
+	}
+	return nil, fmt.Errorf("%w: %s %s %s", ErrInteractionNotFound, recorded.Method, recorded.Endpoint, recorded.Body)
+}
+
+// normalize returns body minified with its keys sorted and the redaction policy applied.
+// When matching, the ignored fields are removed as well.
+func (r *Recorder) normalize(body []byte, matching bool) json.RawMessage {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var document any
+	if err := decoder.Decode(&document); err != nil {
+		// Keep the body as a JSON string so it still matches byte for byte
+		quoted, _ := json.Marshal(string(body))
+		return quoted
+	}
+	if matching {
+		document = r.strip(document)
+	}
+	normalized, err := json.Marshal(document)
+	if err != nil {
+		return nil
+	}
+	return r.redact(normalized)
+}
+
+// strip removes the ignored fields from a decoded JSON document
+func (r *Recorder) strip(v any) any {
+	switch value := v.(type) {
+	case map[string]any:
+		for key, nested := range value {
+			if r.isIgnored(key) {
+				delete(value, key)
+				continue
+			}
+			value[key] = r.strip(nested)
+		}
+	case []any:
+		for i, nested := range value {
+			value[i] = r.strip(nested)
+		}
+	}
+	return v
+}
+
+func (r *Recorder) isIgnored(field string) bool {
+	for _, ignored := range r.ignored {
+		if strings.EqualFold(ignored, field) {
+			return true
+		}
+	}
+	return false
+}
+
+// redact masks body with the redaction policy, if any
+func (r *Recorder) redact(body []byte) json.RawMessage {
+	if r.redaction == nil {
+		return json.RawMessage(body)
+	}
+	return json.RawMessage(r.redaction.RedactJSON(body))
+}
+
+// scrubHeader flattens header and replaces the values of secret headers
+func (r *Recorder) scrubHeader(header http.Header) map[string]string {
+	if len(header) == 0 {
+		return nil
+	}
+	flat := make(map[string]string, len(header))
+	for name, values := range header {
+		flat[http.CanonicalHeaderKey(name)] = strings.Join(values, ", ")
+	}
+	for _, name := range r.scrub {
+		if _, ok := flat[http.CanonicalHeaderKey(name)]; ok {
+			flat[http.CanonicalHeaderKey(name)] = scrubbed
+		}
+	}
+	return flat
+}
@@ -0,0 +1,101 @@
+package snaptest
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andremaeshaa/faspay-sendme-snap-go/snap"
+)
+
+// TestRecorder tests that interactions recorded against a server replay offline with
+// other timestamps and external IDs, and that secrets stay out of the cassette
+func TestRecorder(t *testing.T) {
+	cassette := filepath.Join(t.TempDir(), "cassettes", "transfer.json")
+	ctx := context.Background()
+
+	recorder, err := NewRecorder(cassette, ModeRecordOnce, WithRedaction(snap.DefaultRedactionPolicy()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recorder.Replaying() {
+		t.Fatal("Expected a missing cassette to be recorded")
+	}
+	server, _ := newTestServer(t)
+	client, err := server.NewClient(snap.WithClock(func() time.Time { return testTime }), snap.WithTransportRecorder(recorder.Wrap))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, reference := range []string{"TRX0001", "TRX0002"} {
+		if _, err := client.TransferInterBank(ctx, transferRequest(reference, snap.MustIDR(10_000))); err != nil {
+			t.Fatalf("TransferInterBank failed: %v", err)
+		}
+	}
+	status := snap.NewStatusTransferRequest("TRX0001", "")
+	for range 2 {
+		if _, err := client.StatusTransfer(ctx, status); err != nil {
+			t.Fatalf("StatusTransfer failed: %v", err)
+		}
+	}
+	if err := recorder.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	server.Close()
+
+	data, err := os.ReadFile(cassette)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"X-Signature": "[scrubbed]"`) {
+		t.Error("Expected the signature to be scrubbed from the cassette")
+	}
+	for _, secret := range []string{server.Requests()[0].ExternalID, "9920017573", "John Doe"} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("Expected %q to be scrubbed from the cassette", secret)
+		}
+	}
+
+	later := testTime.Add(24 * time.Hour)
+	replayer, err := NewRecorder(cassette, ModeRecordOnce, WithRedaction(snap.DefaultRedactionPolicy()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !replayer.Replaying() || len(replayer.Interactions()) != 4 {
+		t.Fatalf("Expected 4 interactions to replay, got %d", len(replayer.Interactions()))
+	}
+	offline, err := snap.NewClient(DefaultPartnerID, PartnerPrivateKey(), nil,
+		snap.WithBaseURL("http://sandbox.invalid"),
+		snap.WithClock(func() time.Time { return later }),
+		snap.WithTransportRecorder(replayer.Wrap),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	request := transferRequest("TRX0002", snap.MustIDR(10_000))
+	request.SetTransactionDate(later)
+	transfer, err := offline.TransferInterBank(ctx, request)
+	if err != nil || transfer.PartnerReferenceNo != "TRX0002" {
+		t.Fatalf("Expected the second transfer to replay, got %+v, %v", transfer, err)
+	}
+	for range 2 {
+		replayed, err := offline.StatusTransfer(ctx, status)
+		if err != nil || replayed.Status() != snap.StatusSuccess {
+			t.Fatalf("Expected the status to replay, got %+v, %v", replayed, err)
+		}
+	}
+	if _, err := offline.StatusTransfer(ctx, status); !errors.Is(err, ErrInteractionNotFound) {
+		t.Errorf("Expected each interaction to replay once, got %v", err)
+	}
+	if _, err := offline.TransferInterBank(ctx, transferRequest("TRX0003", snap.MustIDR(10_000))); !errors.Is(err, ErrInteractionNotFound) {
+		t.Errorf("Expected an unknown transfer not to match, got %v", err)
+	}
+
+	if _, err := NewRecorder(filepath.Join(t.TempDir(), "missing.json"), ModeReplay); err == nil {
+		t.Error("Expected replaying a missing cassette to fail")
+	}
+}
@@ -0,0 +1,149 @@
+package snaptest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/andremaeshaa/faspay-sendme-snap-go/snap"
+	"gopkg.in/yaml.v3"
+)
+
+// Scenario describes the state of a Server and the faults it injects. It can be
+// declared in Go or loaded from YAML or JSON with ParseScenario:
+//
+//	name: transfer times out, then fails
+//	accounts:
+//	  - accountNo: "9920017573"
+//	    balance: {value: "1000000.00", currency: IDR}
+//	beneficiaries:
+//	  - {bankCode: "008", accountNo: "60004400184", name: John Doe}
+//	faults:
+//	  - {kind: timeout, endpoint: transfer, times: 1}
+//	  - {kind: in_progress, endpoint: transfer, polls: 2, status: "06"}
+//	  - {kind: callback, delay: 2s, copies: 2}
+type Scenario struct {
+	Name            string                `json:"name,omitempty"`
+	Accounts        []ScenarioAccount     `json:"accounts,omitempty"`
+	Beneficiaries   []ScenarioBeneficiary `json:"beneficiaries,omitempty"`
+	VirtualAccounts []VirtualAccount      `json:"virtualAccounts,omitempty"`
+	Faults          []Fault               `json:"faults,omitempty"`
+}
+
+// ScenarioAccount is a source account opened by a Scenario
+type ScenarioAccount struct {
+	AccountNo string     `json:"accountNo"`
+	Balance   snap.Money `json:"balance"`
+}
+
+// ScenarioBeneficiary is a bank account registered by a Scenario
+type ScenarioBeneficiary struct {
+	BankCode  string `json:"bankCode"`
+	AccountNo string `json:"accountNo"`
+	Name      string `json:"name"`
+}
+
+// ParseScenario decodes a scenario from YAML or JSON. Field names are the JSON names
+// in both formats, and amounts are SNAP amount objects.
+func ParseScenario(data []byte) (Scenario, error) {
+	var document any
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return Scenario{}, fmt.Errorf("error parsing scenario: %w", err)
+	}
+	normalized, err := json.Marshal(document)
+	if err != nil {
+		return Scenario{}, fmt.Errorf("error parsing scenario: %w", err)
+	}
+
+	var scenario Scenario
+	decoder := json.NewDecoder(bytes.NewReader(normalized))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&scenario); err != nil {
+		return Scenario{}, fmt.Errorf("error parsing scenario: %w", err)
+	}
+	if err := scenario.Validate(); err != nil {
+		return Scenario{}, err
+	}
+	return scenario, nil
+}
+
+// LoadScenarioFile reads a YAML or JSON scenario from a file
+func LoadScenarioFile(path string) (Scenario, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Scenario{}, err
+	}
+	scenario, err := ParseScenario(data)
+	if err != nil {
+		return Scenario{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return scenario, nil
+}
+
+// Validate checks the accounts and faults of the scenario
+func (sc Scenario) Validate() error {
+	var errs []error
+	for i, acc := range sc.Accounts {
+		if acc.AccountNo == "" {
+			errs = append(errs, fmt.Errorf("accounts[%d]: accountNo is required", i))
+		}
+	}
+	for i, beneficiary := range sc.Beneficiaries {
+		if beneficiary.BankCode == "" || beneficiary.AccountNo == "" {
+			errs = append(errs, fmt.Errorf("beneficiaries[%d]: bankCode and accountNo are required", i))
+		}
+	}
+	for i, va := range sc.VirtualAccounts {
+		if va.VirtualAccountNo == "" && va.CustomerNo == "" {
+			errs = append(errs, fmt.Errorf("virtualAccounts[%d]: virtualAccountNo or customerNo is required", i))
+		}
+	}
+	for i, f := range sc.Faults {
+		if err := f.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("faults[%d]: %w", i, err))
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("invalid scenario %q: %w", sc.Name, err)
+	}
+	return nil
+}
+
+// WithScenario opens the accounts, registers the beneficiaries and virtual accounts
+// and injects the faults of a scenario. It panics when the scenario is invalid.
+func WithScenario(sc Scenario) Option {
+	return func(s *Server) {
+		if err := s.apply(sc); err != nil {
+			panic("snaptest: " + err.Error())
+		}
+	}
+}
+
+// Apply adds the accounts, beneficiaries, virtual accounts and faults of a scenario to
+// the running server
+func (s *Server) Apply(sc Scenario) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.apply(sc)
+}
+
+func (s *Server) apply(sc Scenario) error {
+	if err := sc.Validate(); err != nil {
+		return err
+	}
+	for _, acc := range sc.Accounts {
+		s.addAccount(acc.AccountNo, acc.Balance)
+	}
+	for _, beneficiary := range sc.Beneficiaries {
+		s.beneficiaries[beneficiaryKey(beneficiary.BankCode, beneficiary.AccountNo)] = beneficiary.Name
+	}
+	for _, va := range sc.VirtualAccounts {
+		s.addVirtualAccount(va)
+	}
+	for _, f := range sc.Faults {
+		_ = s.addFault(f) // Validated above
+	}
+	return nil
+}
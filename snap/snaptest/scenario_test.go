@@ -0,0 +1,74 @@
+package snaptest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andremaeshaa/faspay-sendme-snap-go/snap"
+)
+
+// TestLoadScenarioFile tests that a YAML scenario sets up the server and its faults
+func TestLoadScenarioFile(t *testing.T) {
+	scenario, err := LoadScenarioFile("testdata/scenario.yaml")
+	if err != nil {
+		t.Fatalf("LoadScenarioFile failed: %v", err)
+	}
+	if len(scenario.Faults) != 3 || scenario.Faults[0].Delay != 10*time.Millisecond || scenario.Faults[1].Status != snap.StatusFailed ||
+		!scenario.VirtualAccounts[0].Amount.Equal(snap.MustIDR(100_000)) {
+		t.Fatalf("Unexpected scenario %+v", scenario)
+	}
+
+	server := NewServer(WithClock(func() time.Time { return testTime }), WithScenario(scenario))
+	defer server.Close()
+	client, err := server.NewClient(snap.WithClock(func() time.Time { return testTime }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	if _, err := client.TransferInterBank(ctx, transferRequest("TRX0001", snap.MustIDR(10_000))); !errors.Is(err, snap.ErrTimeout) {
+		t.Fatalf("Expected a timeout, got %v", err)
+	}
+	for _, expected := range []snap.TransactionStatusCode{snap.StatusInProgress, snap.StatusFailed} {
+		status, err := client.StatusTransfer(ctx, snap.NewStatusTransferRequest("TRX0001", ""))
+		if err != nil || status.Status() != expected {
+			t.Fatalf("Expected status %q, got %+v, %v", expected, status, err)
+		}
+	}
+	expectBalance(t, server, snap.MustIDR(1_000_000))
+}
+
+// TestParseScenario tests JSON scenarios and the errors reported for invalid ones
+func TestParseScenario(t *testing.T) {
+	scenario := Scenario{
+		Name:   "json",
+		Faults: []Fault{{Kind: FaultCallback, Delay: 1500 * time.Millisecond, Copies: 3}},
+	}
+	data, err := json.Marshal(scenario)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"delay":"1.5s"`) {
+		t.Errorf("Expected the delay as a duration string, got %s", data)
+	}
+	parsed, err := ParseScenario(data)
+	if err != nil || parsed.Faults[0] != scenario.Faults[0] {
+		t.Errorf("Expected the scenario to round trip, got %+v, %v", parsed, err)
+	}
+
+	for _, invalid := range []string{
+		`{"faults": [{"kind": "timeout", "delay": "soon"}]}`,
+		`{"faults": [{"kind": "timeout", "endpoint": "refund"}]}`,
+		`{"accounts": [{"balance": {"value": "1.00", "currency": "IDR"}}]}`,
+		`{"fault": []}`,
+		"faults: [",
+	} {
+		if _, err := ParseScenario([]byte(invalid)); err == nil {
+			t.Errorf("Expected an error for %s", invalid)
+		}
+	}
+}
@@ -0,0 +1,449 @@
+// Package snaptest provides an in-process fake of the Faspay SendMe SNAP API for
+// integration tests.
+//
+// A Server implements the nine endpoints called by snap.Client. It verifies request
+// signatures, keeps account balances in memory, applies transfers, top-ups and bill
+// payments, and answers StatusTransfer, CustomerTopupStatus, InquiryBalance and
+// HistoryList consistently with what was done:
+//
+//	server := snaptest.NewServer(
+//		snaptest.WithAccount("9920017573", snap.MustIDR(1_000_000)),
+//		snaptest.WithBeneficiary("008", "60004400184", "John Doe"),
+//	)
+//	defer server.Close()
+//
+//	client, err := server.NewClient()
+//	...
+//	balance, _ := server.Balance("9920017573")
+//
+// Final statuses are notified to the callbackUrl of the request, signed with
+// FaspayPublicKey's private key. Faults script the failures Faspay produces in
+// production, such as timeouts after a transfer was accepted, transfers stuck in
+// progress and duplicated notifications. A Scenario declares accounts and faults in Go,
+// YAML or JSON.
+package snaptest
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/andremaeshaa/faspay-sendme-snap-go/snap"
+)
+
+// DefaultPartnerID is the partner ID accepted by a Server unless WithPartner is used
+const DefaultPartnerID = "99999"
+
+// Server is a fake Faspay SendMe server listening on a local address. It is safe for
+// concurrent use.
+type Server struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	now      func() time.Time
+	partners map[string]*rsa.PublicKey
+
+	accounts        map[string]*account
+	defaultAccount  string
+	beneficiaries   map[string]string // bankCode/accountNo -> account name
+	virtualAccounts map[string]*VirtualAccount
+	transactions    []*Transaction
+	externalIDs     map[string]bool // partnerID/externalID already used
+	requests        []Request
+	lastReferenceNo int
+
+	faults       []*fault
+	callbacks    []Callback
+	callbackHTTP *http.Client
+	lastNotice   int
+	deliveries   int       // Callback deliveries in flight, guarded by mu
+	delivered    sync.Cond // Broadcast with mu held when deliveries drops to zero
+	closed       bool
+	done         chan struct{} // Closed by Close
+	closeOnce    sync.Once
+}
+
+// Option is a function that configures a Server
+type Option func(*Server)
+
+// WithClock sets the function the server reads the current time from, for response
+// timestamps, transaction dates and history entries. time.Now is used by default.
+func WithClock(now func() time.Time) Option {
+	return func(s *Server) {
+		s.now = now
+	}
+}
+
+// WithPartner accepts requests from partnerID signed with the key matching the PEM
+// encoded public key. When WithPartner is not used, DefaultPartnerID is accepted with
+// the key returned by PartnerPrivateKey. It panics when the key cannot be parsed.
+func WithPartner(partnerID string, publicKeyPEM []byte) Option {
+	return func(s *Server) {
+		publicKey, err := snap.ParsePublicKey(publicKeyPEM)
+		if err != nil {
+			panic(fmt.Sprintf("snaptest: partner %s: %v", partnerID, err))
+		}
+		s.partners[partnerID] = publicKey
+	}
+}
+
+// WithAccount opens a source account with the given balance. The first account opened
+// is used for top-ups that do not name a source account.
+func WithAccount(accountNo string, balance snap.Money) Option {
+	return func(s *Server) {
+		s.addAccount(accountNo, balance)
+	}
+}
+
+// WithBeneficiary registers a bank account that AccountInquiry finds and
+// TransferInterBank can credit
+func WithBeneficiary(bankCode, accountNo, name string) Option {
+	return func(s *Server) {
+		s.beneficiaries[beneficiaryKey(bankCode, accountNo)] = name
+	}
+}
+
+// WithVirtualAccount registers a bill that BillInquiry finds and BillPayment can pay
+func WithVirtualAccount(va VirtualAccount) Option {
+	return func(s *Server) {
+		s.addVirtualAccount(va)
+	}
+}
+
+// NewServer starts a fake server. The caller must call Close when finished.
+func NewServer(options ...Option) *Server {
+	s := &Server{
+		now:             time.Now,
+		partners:        make(map[string]*rsa.PublicKey),
+		accounts:        make(map[string]*account),
+		beneficiaries:   make(map[string]string),
+		virtualAccounts: make(map[string]*VirtualAccount),
+		externalIDs:     make(map[string]bool),
+		callbackHTTP:    &http.Client{Timeout: 10 * time.Second},
+		done:            make(chan struct{}),
+	}
+	s.delivered.L = &s.mu
+	for _, option := range options {
+		option(s)
+	}
+	if len(s.partners) == 0 {
+		s.partners[DefaultPartnerID] = &testKeys().partner.PublicKey
+	}
+
+	s.Server = httptest.NewServer(s)
+	return s
+}
+
+// Close cancels held responses and delayed callbacks, then shuts the server down
+func (s *Server) Close() {
+	s.closeOnce.Do(func() {
+		s.mu.Lock()
+		s.closed = true
+		s.mu.Unlock()
+		close(s.done)
+		s.WaitCallbacks()
+		s.Server.Close()
+	})
+}
+
+// NewClient returns a snap client for DefaultPartnerID that sends its requests to the
+// server and verifies response signatures. options are applied after the server's.
+func (s *Server) NewClient(options ...snap.ClientOption) (snap.Services, error) {
+	options = append([]snap.ClientOption{
+		snap.WithBaseURL(s.URL),
+		snap.WithHTTPClient(&http.Client{Transport: s.Client().Transport}),
+		snap.WithFaspayPublicKey(s.FaspayPublicKey()),
+	}, options...)
+	return snap.NewClient(DefaultPartnerID, PartnerPrivateKey(), nil, options...)
+}
+
+// PartnerPrivateKey returns the PKCS#8 PEM private key of DefaultPartnerID. The key is
+// generated once per process and only meant for tests.
+func PartnerPrivateKey() []byte {
+	return testKeys().partnerPEM
+}
+
+// PartnerPublicKey returns the PEM public key matching PartnerPrivateKey
+func PartnerPublicKey() []byte {
+	return testKeys().partnerPublicPEM
+}
+
+// FaspayPublicKey returns the PEM public key the server signs responses with, for
+// snap.WithFaspayPublicKey and callback.NewHandler
+func (s *Server) FaspayPublicKey() []byte {
+	return testKeys().faspayPublicPEM
+}
+
+// Request is a request received by the server
+type Request struct {
+	Endpoint   string
+	PartnerID  string
+	ExternalID string
+	Timestamp  string
+	Body       []byte
+}
+
+// Requests returns the requests received so far, in order, including rejected ones
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// endpoint handles one API operation
+type endpoint struct {
+	serviceCode snap.ServiceCode
+	newRequest  func() validatable
+	handle      func(s *Server, c *call) (any, *apiError)
+}
+
+// call is a request being handled
+type call struct {
+	body      []byte
+	request   validatable // Decoded and validated body
+	partnerID string
+	faults    []Fault // Faults matching the request
+	status    int     // HTTP status of a successful response
+}
+
+var endpoints = map[string]endpoint{
+	snap.EndpointAccountInquiry:      {snap.ServiceCodeAccountInquiryExternal, requestOf[snap.ExternalAccountInquiryRequest], (*Server).accountInquiry},
+	snap.EndpointTransferInterbank:   {snap.ServiceCodeTransferInterbank, requestOf[snap.TransferInterBankRequest], (*Server).transferInterBank},
+	snap.EndpointInquiryStatus:       {snap.ServiceCodeTransferStatus, requestOf[snap.StatusTransferRequest], (*Server).statusTransfer},
+	snap.EndpointInquiryBalance:      {snap.ServiceCodeBalanceInquiry, requestOf[snap.InquiryBalanceRequest], (*Server).inquiryBalance},
+	snap.EndpointHistoryList:         {snap.ServiceCodeHistoryList, requestOf[snap.HistoryListRequest], (*Server).historyList},
+	snap.EndpointCustomerTopup:       {snap.ServiceCodeEmoneyTopup, requestOf[snap.CustomerTopupRequest], (*Server).customerTopup},
+	snap.EndpointCustomerTopupStatus: {snap.ServiceCodeEmoneyTopupStatus, requestOf[snap.CustomerTopupStatusRequest], (*Server).customerTopupStatus},
+	snap.EndpointBillInquiry:         {serviceCodeBillInquiry, requestOf[snap.BillInquiryRequest], (*Server).billInquiry},
+	snap.EndpointBillPayment:         {snap.ServiceCodeBillPayment, requestOf[snap.BillPaymentRequest], (*Server).billPayment},
+}
+
+// serviceCodeBillInquiry is the SNAP service code of the virtual account inquiry
+const serviceCodeBillInquiry snap.ServiceCode = "32"
+
+// apiError is a SNAP error response
+type apiError struct {
+	status   int
+	caseCode string
+	message  string
+}
+
+// errorf returns an apiError whose message is the SNAP description of the code,
+// followed by details when given
+func errorf(status int, caseCode string, details string, args ...any) *apiError {
+	message := http.StatusText(status)
+	if info, ok := snap.LookupResponseCode(fmt.Sprintf("%d00%s", status, caseCode)); ok {
+		message = info.Description
+	}
+	if details != "" {
+		message += " " + fmt.Sprintf(details, args...)
+	}
+	return &apiError{status: status, caseCode: caseCode, message: message}
+}
+
+// ServeHTTP verifies and answers a SNAP request
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ep, ok := endpoints[r.URL.Path]
+	if !ok || r.Method != http.MethodPost {
+		s.writeJSON(w, r.URL.Path, http.StatusNotFound, response("", errorf(http.StatusNotFound, "00", "")))
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		s.writeError(w, r.URL.Path, ep.serviceCode, errorf(http.StatusBadRequest, "00", ""))
+		return
+	}
+
+	request := Request{
+		Endpoint:   r.URL.Path,
+		PartnerID:  r.Header.Get("X-PARTNER-ID"),
+		ExternalID: r.Header.Get("X-EXTERNAL-ID"),
+		Timestamp:  r.Header.Get("X-TIMESTAMP"),
+		Body:       body,
+	}
+	s.mu.Lock()
+	s.requests = append(s.requests, request)
+	s.mu.Unlock()
+
+	if apiErr := s.authenticate(r, request); apiErr != nil {
+		s.writeError(w, r.URL.Path, ep.serviceCode, apiErr)
+		return
+	}
+
+	// Faults only apply to requests Faspay would accept, so a malformed request is
+	// rejected as such and does not count against a fault's Skip or Times
+	c := &call{body: body, request: ep.newRequest(), partnerID: request.PartnerID, status: http.StatusOK}
+	if apiErr := decode(body, c.request); apiErr != nil {
+		s.writeError(w, r.URL.Path, ep.serviceCode, apiErr)
+		return
+	}
+	c.faults = s.matchFaults(r.URL.Path, body)
+	if apiErr := rejection(c.faults); apiErr != nil {
+		s.writeError(w, r.URL.Path, ep.serviceCode, apiErr)
+		return
+	}
+
+	result, apiErr := ep.handle(s, c)
+	if fault, ok := firstFault(c.faults, FaultTimeout); ok {
+		s.hold(r.Context(), fault.Delay)
+		apiErr = errorf(http.StatusGatewayTimeout, "00", "")
+	}
+	if apiErr != nil {
+		s.writeError(w, r.URL.Path, ep.serviceCode, apiErr)
+		return
+	}
+	s.writeJSON(w, r.URL.Path, c.status, result)
+}
+
+// authenticate checks the SNAP headers, the signature and the external ID
+func (s *Server) authenticate(r *http.Request, request Request) *apiError {
+	for _, header := range []string{"X-TIMESTAMP", "X-SIGNATURE", "X-PARTNER-ID", "X-EXTERNAL-ID", "CHANNEL-ID"} {
+		if r.Header.Get(header) == "" {
+			return errorf(http.StatusBadRequest, "02", "[%s]", header)
+		}
+	}
+	if _, err := time.Parse(time.RFC3339, request.Timestamp); err != nil {
+		return errorf(http.StatusBadRequest, "01", "[X-TIMESTAMP]")
+	}
+
+	publicKey, ok := s.partners[request.PartnerID]
+	if !ok {
+		return errorf(http.StatusUnauthorized, "00", "[Unknown Partner]")
+	}
+	if err := snap.VerifyRequest(publicKey, r, request.Body); err != nil {
+		return errorf(http.StatusUnauthorized, "00", "[Signature]")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := request.PartnerID + "/" + request.ExternalID
+	if s.externalIDs[key] {
+		return errorf(http.StatusConflict, "00", "[X-EXTERNAL-ID]")
+	}
+	s.externalIDs[key] = true
+	return nil
+}
+
+// errorResponse is the body of a SNAP error
+type errorResponse struct {
+	ResponseCode    string `json:"responseCode"`
+	ResponseMessage string `json:"responseMessage"`
+}
+
+// response returns the error body for apiErr under serviceCode
+func response(serviceCode snap.ServiceCode, apiErr *apiError) errorResponse {
+	if serviceCode == "" {
+		serviceCode = "00"
+	}
+	return errorResponse{
+		ResponseCode:    fmt.Sprintf("%d%s%s", apiErr.status, string(serviceCode), apiErr.caseCode),
+		ResponseMessage: apiErr.message,
+	}
+}
+
+// successCode returns the responseCode of a successful call, which is 202 while the
+// transaction is in progress
+func (c *call) successCode(serviceCode snap.ServiceCode) string {
+	return fmt.Sprintf("%d%s00", c.status, string(serviceCode))
+}
+
+// successMessage returns the responseMessage of a successful call
+func (c *call) successMessage() string {
+	if c.status == http.StatusAccepted {
+		return "Request In Progress"
+	}
+	return "Successful"
+}
+
+func (s *Server) writeError(w http.ResponseWriter, path string, serviceCode snap.ServiceCode, apiErr *apiError) {
+	s.writeJSON(w, path, apiErr.status, response(serviceCode, apiErr))
+}
+
+// writeJSON writes v as a signed SNAP response
+func (s *Server) writeJSON(w http.ResponseWriter, path string, status int, v any) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	timestamp := snap.NewTime(s.clock()).String()
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-TIMESTAMP", timestamp)
+	w.Header().Set("X-SIGNATURE", signFaspay(http.MethodPost, path, body, timestamp))
+	w.WriteHeader(status)
+	_, _ = w.Write(body)
+}
+
+// clock returns the current time of the server
+func (s *Server) clock() time.Time {
+	return s.now()
+}
+
+// signFaspay signs a message with the server's Faspay key, as Faspay signs responses
+// and notifications
+func signFaspay(method, path string, body []byte, timestamp string) string {
+	digest := sha256.Sum256([]byte(snap.StringToSign(method, path, body, timestamp)))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, testKeys().faspay, crypto.SHA256, digest[:])
+	if err != nil {
+		panic(fmt.Sprintf("snaptest: signing: %v", err))
+	}
+	return base64.StdEncoding.EncodeToString(signature)
+}
+
+// keys are the throwaway keypairs shared by all servers of the process
+type keys struct {
+	partner          *rsa.PrivateKey
+	partnerPEM       []byte
+	partnerPublicPEM []byte
+	faspay           *rsa.PrivateKey
+	faspayPublicPEM  []byte
+}
+
+var testKeys = sync.OnceValue(func() *keys {
+	partner, partnerPEM, partnerPublicPEM := generateKey()
+	faspay, _, faspayPublicPEM := generateKey()
+	return &keys{
+		partner:          partner,
+		partnerPEM:       partnerPEM,
+		partnerPublicPEM: partnerPublicPEM,
+		faspay:           faspay,
+		faspayPublicPEM:  faspayPublicPEM,
+	}
+})
+
+// generateKey returns a 2048-bit RSA key with its PKCS#8 and public PEM encodings
+func generateKey() (*rsa.PrivateKey, []byte, []byte) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(fmt.Sprintf("snaptest: generating key: %v", err))
+	}
+	privateDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		panic(fmt.Sprintf("snaptest: encoding key: %v", err))
+	}
+	publicDER, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		panic(fmt.Sprintf("snaptest: encoding key: %v", err))
+	}
+	return key,
+		pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateDER}),
+		pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER})
+}
+
+func beneficiaryKey(bankCode, accountNo string) string {
+	return strings.TrimSpace(bankCode) + "/" + strings.TrimSpace(accountNo)
+}
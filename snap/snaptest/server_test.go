@@ -0,0 +1,217 @@
+package snaptest
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/andremaeshaa/faspay-sendme-snap-go/snap"
+)
+
+// testTime is the fixed clock shared by the test server and clients
+var testTime = time.Date(2025, 6, 9, 3, 30, 3, 0, time.UTC)
+
+func newTestServer(t *testing.T) (*Server, snap.Services) {
+	t.Helper()
+	server := NewServer(
+		WithClock(func() time.Time { return testTime }),
+		WithAccount("9920017573", snap.MustIDR(1_000_000)),
+		WithBeneficiary("008", "60004400184", "John Doe"),
+		WithVirtualAccount(VirtualAccount{PartnerServiceID: "   12345", CustomerNo: "0001", Name: "PLN Postpaid", Amount: snap.MustIDR(100_000)}),
+	)
+	t.Cleanup(server.Close)
+
+	client, err := server.NewClient(snap.WithClock(func() time.Time { return testTime }))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	return server, client
+}
+
+func transferRequest(partnerReferenceNo string, amount snap.Money) *snap.TransferInterBankRequest {
+	request := &snap.TransferInterBankRequest{
+		PartnerReferenceNo:     partnerReferenceNo,
+		Amount:                 amount.Amount(),
+		BeneficiaryAccountName: "John Doe",
+		BeneficiaryAccountNo:   "60004400184",
+		BeneficiaryBankCode:    "008",
+		SourceAccountNo:        "9920017573",
+	}
+	request.SetTransactionDate(testTime)
+	return request
+}
+
+func expectBalance(t *testing.T, server *Server, expected snap.Money) {
+	t.Helper()
+	if balance, ok := server.Balance("9920017573"); !ok || !balance.Equal(expected) {
+		t.Errorf("Balance = %v, expected %v", balance, expected)
+	}
+}
+
+// TestServer tests every endpoint through a real client and the consistency of
+// balances, statuses and history
+func TestServer(t *testing.T) {
+	server, client := newTestServer(t)
+	ctx := context.Background()
+
+	inquiry, err := client.AccountInquiry(ctx, &snap.ExternalAccountInquiryRequest{
+		BeneficiaryBankCode:  "008",
+		BeneficiaryAccountNo: "60004400184",
+		PartnerReferenceNo:   "INQ0001",
+	})
+	if err != nil {
+		t.Fatalf("AccountInquiry failed: %v", err)
+	}
+	if inquiry.BeneficiaryAccountName != "John Doe" || inquiry.BeneficiaryBankName != "PT Bank Mandiri (Persero) Tbk" {
+		t.Errorf("Unexpected inquiry %+v", inquiry)
+	}
+
+	transfer, err := client.TransferInterBank(ctx, transferRequest("TRX0001", snap.MustIDR(250_000)))
+	if err != nil {
+		t.Fatalf("TransferInterBank failed: %v", err)
+	}
+	if transfer.AdditionalInfo.Status() != snap.StatusSuccess {
+		t.Errorf("Unexpected transfer status %q", transfer.AdditionalInfo.LatestTransactionStatus)
+	}
+	expectBalance(t, server, snap.MustIDR(750_000))
+
+	status, err := client.StatusTransfer(ctx, transfer.StatusRequest())
+	if err != nil {
+		t.Fatalf("StatusTransfer failed: %v", err)
+	}
+	if status.Status() != snap.StatusSuccess || status.Amount.Value != "250000.00" || status.OriginalReferenceNo != transfer.ReferenceNo {
+		t.Errorf("Unexpected status %+v", status)
+	}
+
+	topupRequest := &snap.CustomerTopupRequest{
+		PartnerReferenceNo: "TOP0001",
+		CustomerNumber:     "081234567890",
+		Amount:             snap.MustIDR(50_000).Amount(),
+		AdditionalInfo:     &snap.AdditionalInfoCustomerTopupRequest{PlatformCode: string(snap.PlatformGoPay)},
+	}
+	topupRequest.SetTransactionDate(testTime)
+	topup, err := client.CustomerTopup(ctx, topupRequest)
+	if err != nil {
+		t.Fatalf("CustomerTopup failed: %v", err)
+	}
+	topupStatus, err := client.CustomerTopupStatus(ctx, topup.StatusRequest())
+	if err != nil {
+		t.Fatalf("CustomerTopupStatus failed: %v", err)
+	}
+	if topupStatus.Status() != snap.StatusSuccess || topupStatus.AdditionalInfo.SourceAccount != "9920017573" ||
+		topupStatus.AdditionalInfo.PlatformName != "GoPay" {
+		t.Errorf("Unexpected top-up status %+v", topupStatus)
+	}
+
+	bill, err := client.BillInquiry(ctx, &snap.BillInquiryRequest{
+		PartnerReferenceNo: "BIL0001",
+		PartnerServiceId:   "   12345",
+		CustomerNo:         "0001",
+		VirtualAccountNo:   "123450001",
+	})
+	if err != nil {
+		t.Fatalf("BillInquiry failed: %v", err)
+	}
+	if bill.VirtualAccountData.VirtualAccountName != "PLN Postpaid" || bill.VirtualAccountData.TotalAmount.Value != "100000.00" {
+		t.Errorf("Unexpected bill %+v", bill.VirtualAccountData)
+	}
+	payment := &snap.BillPaymentRequest{
+		PartnerReferenceNo: "PAY0001",
+		PartnerServiceId:   "   12345",
+		CustomerNo:         "0001",
+		VirtualAccountNo:   "123450001",
+		SourceAccount:      "9920017573",
+		PaidAmount:         bill.VirtualAccountData.TotalAmount,
+	}
+	payment.SetTrxDateTime(testTime)
+	if _, err := client.BillPayment(ctx, payment); err != nil {
+		t.Fatalf("BillPayment failed: %v", err)
+	}
+	if va, _ := server.VirtualAccount("123450001"); !va.Paid {
+		t.Error("Expected the bill to be paid")
+	}
+
+	balance, err := client.InquiryBalance(ctx, &snap.InquiryBalanceRequest{AccountNo: "9920017573"})
+	if err != nil {
+		t.Fatalf("InquiryBalance failed: %v", err)
+	}
+	if balance.AccountInfos[0].AvailableBalance.Value != "600000.00" {
+		t.Errorf("Unexpected balance %+v", balance.AccountInfos[0].AvailableBalance)
+	}
+
+	if err := server.SetStatus("TRX0001", snap.StatusFailed); err != nil {
+		t.Fatal(err)
+	}
+	expectBalance(t, server, snap.MustIDR(850_000))
+	status, err = client.StatusTransfer(ctx, transfer.StatusRequest())
+	if err != nil || status.Status() != snap.StatusFailed {
+		t.Errorf("Expected the transfer to be failed, got %+v, %v", status, err)
+	}
+
+	var types []snap.TransactionType
+	var directions []snap.DebitCredit
+	history := &snap.HistoryAllRequest{AccountNo: "9920017573", From: testTime.Add(-time.Hour), To: testTime.Add(time.Hour)}
+	for entry, err := range snap.HistoryAll(ctx, client, history) {
+		if err != nil {
+			t.Fatalf("HistoryAll failed: %v", err)
+		}
+		types = append(types, entry.TransactionType())
+		directions = append(directions, entry.Direction())
+	}
+	if len(types) != 4 || types[0] != snap.TransactionTypeTransfer || types[1] != snap.TransactionTypeTopup ||
+		types[2] != snap.TransactionTypePayment || directions[3] != snap.Credit {
+		t.Errorf("Unexpected history %v %v", types, directions)
+	}
+
+	if got := len(server.Transactions()); got != 3 {
+		t.Errorf("Expected 3 transactions, got %d", got)
+	}
+	if got := len(server.Requests()); got != 10 {
+		t.Errorf("Expected 10 requests, got %d", got)
+	}
+}
+
+// TestServerErrors tests the SNAP errors the server reports
+func TestServerErrors(t *testing.T) {
+	server, client := newTestServer(t)
+	ctx := context.Background()
+
+	if _, err := client.TransferInterBank(ctx, transferRequest("TRX0001", snap.MustIDR(10_000))); err != nil {
+		t.Fatalf("TransferInterBank failed: %v", err)
+	}
+	_, err := client.TransferInterBank(ctx, transferRequest("TRX0001", snap.MustIDR(10_000)))
+	if !errors.Is(err, snap.ErrDuplicateReference) {
+		t.Errorf("Expected a duplicate reference error, got %v", err)
+	}
+
+	_, err = client.TransferInterBank(ctx, transferRequest("TRX0002", snap.MustIDR(5_000_000)))
+	if !errors.Is(err, snap.ErrInsufficientFunds) {
+		t.Errorf("Expected an insufficient funds error, got %v", err)
+	}
+	expectBalance(t, server, snap.MustIDR(990_000))
+
+	unknown := transferRequest("TRX0003", snap.MustIDR(10_000))
+	unknown.BeneficiaryAccountNo = "1234567890"
+	if _, err := client.TransferInterBank(ctx, unknown); !snap.IsNotFoundError(err) {
+		t.Errorf("Expected a not found error, got %v", err)
+	}
+
+	if _, err := client.StatusTransfer(ctx, snap.NewStatusTransferRequest("TRX9999", "")); !snap.IsNotFoundError(err) {
+		t.Errorf("Expected a not found error, got %v", err)
+	}
+
+	otherKey, err := os.ReadFile("../testdata/private_key.pem")
+	if err != nil {
+		t.Fatal(err)
+	}
+	forged, err := snap.NewClient(DefaultPartnerID, otherKey, nil, snap.WithBaseURL(server.URL), snap.WithHTTPClient(server.Client()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = forged.InquiryBalance(ctx, &snap.InquiryBalanceRequest{AccountNo: "9920017573"})
+	if !errors.Is(err, snap.ErrSignature) {
+		t.Errorf("Expected a signature error, got %v", err)
+	}
+}
@@ -0,0 +1,266 @@
+package snap
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TimestampLayout is the SNAP date and time format, used for X-TIMESTAMP and for
+// date fields such as transactionDate
+const TimestampLayout = "2006-01-02T15:04:05-07:00"
+
+// Jakarta is the Asia/Jakarta time zone (WIB, UTC+7) Faspay works in. It is used for
+// values without an offset and by NewTime and Now. When the tz database is not
+// available, it is a fixed UTC+7 zone, which is equivalent as WIB has no daylight
+// saving time.
+var Jakarta = loadJakarta()
+
+func loadJakarta() *time.Location {
+	if loc, err := time.LoadLocation("Asia/Jakarta"); err == nil {
+		return loc
+	}
+	return time.FixedZone("WIB", 7*60*60)
+}
+
+// parseLayouts are the variants accepted by ParseTime. Layouts without an offset are
+// read in Jakarta.
+var parseLayouts = []struct {
+	layout string
+	local  bool
+}{
+	{time.RFC3339Nano, false},
+	{"2006-01-02T15:04:05.999999999-0700", false},
+	{"2006-01-02 15:04:05.999999999-07:00", false},
+	{localDateTimeLayout + ".999999999", true},
+	{"2006-01-02 15:04:05.999999999", true},
+	{time.DateOnly, true},
+}
+
+// Time is a date and time that marshals in the SNAP format, e.g.
+// "2025-06-09T10:30:03+07:00". The zero Time marshals as an empty string, which is
+// what Faspay sends for unset dates.
+type Time struct {
+	time.Time
+}
+
+// NewTime returns t in Jakarta, truncated to the second precision of SNAP timestamps
+func NewTime(t time.Time) Time {
+	if t.IsZero() {
+		return Time{}
+	}
+	return Time{t.In(Jakarta).Truncate(time.Second)}
+}
+
+// Now returns the current time in Jakarta
+func Now() Time {
+	return NewTime(time.Now())
+}
+
+// ParseTime parses a date and time in the SNAP format or one of the variants Faspay
+// returns: fractional seconds, "Z", an offset without colon, a space instead of "T",
+// no offset, or a date only. Values without an offset are read in Jakarta. An empty
+// value returns the zero Time.
+func ParseTime(value string) (Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return Time{}, nil
+	}
+	for _, candidate := range parseLayouts {
+		var t time.Time
+		var err error
+		if candidate.local {
+			t, err = time.ParseInLocation(candidate.layout, value, Jakarta)
+		} else {
+			t, err = time.Parse(candidate.layout, value)
+		}
+		if err == nil {
+			return Time{t}, nil
+		}
+	}
+	return Time{}, fmt.Errorf("invalid SNAP timestamp %q: expected a date and time such as \"2025-06-09T10:30:03+07:00\"", value)
+}
+
+// String returns the time in the SNAP format, or an empty string for the zero Time
+func (t Time) String() string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(TimestampLayout)
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (t Time) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, accepting the variants of ParseTime
+func (t *Time) UnmarshalText(data []byte) error {
+	parsed, err := ParseTime(string(data))
+	if err != nil {
+		return err
+	}
+	*t = parsed
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler
+func (t Time) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.String())
+}
+
+// UnmarshalJSON implements json.Unmarshaler, accepting null and the variants of ParseTime
+func (t *Time) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		*t = Time{}
+		return nil
+	}
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return fmt.Errorf("SNAP timestamp must be a string: %w", err)
+	}
+	return t.UnmarshalText([]byte(value))
+}
+
+// formatTime formats t for a string date field; the zero time gives an empty string
+func formatTime(t time.Time) string {
+	return NewTime(t).String()
+}
+
+// TransactionDateTime parses TransactionDate
+func (r *TransferInterBankRequest) TransactionDateTime() (Time, error) {
+	return ParseTime(r.TransactionDate)
+}
+
+// SetTransactionDate sets TransactionDate to t in Jakarta
+func (r *TransferInterBankRequest) SetTransactionDate(t time.Time) {
+	r.TransactionDate = formatTime(t)
+}
+
+// InstructDateTime parses InstructDate
+func (a *AdditionalInfoTransferInterBank) InstructDateTime() (Time, error) {
+	return ParseTime(a.InstructDate)
+}
+
+// SetInstructDate sets InstructDate to t in Jakarta; the zero time clears it
+func (a *AdditionalInfoTransferInterBank) SetInstructDate(t time.Time) {
+	a.InstructDate = formatTime(t)
+}
+
+// InstructDateTime parses InstructDate
+func (a *AdditionalInfoTransferInterBankResponse) InstructDateTime() (Time, error) {
+	return ParseTime(a.InstructDate)
+}
+
+// TransactionDateTime parses TransactionDate
+func (r *StatusTransferResponse) TransactionDateTime() (Time, error) {
+	return ParseTime(r.TransactionDate)
+}
+
+// TransactionStatusDateTime parses TransactionStatusDate
+func (a *AdditionalInfoStatusTransferResponse) TransactionStatusDateTime() (Time, error) {
+	return ParseTime(a.TransactionStatusDate)
+}
+
+// From parses FromDateTime
+func (r *HistoryListRequest) From() (Time, error) {
+	return ParseTime(r.FromDateTime)
+}
+
+// To parses ToDateTime
+func (r *HistoryListRequest) To() (Time, error) {
+	return ParseTime(r.ToDateTime)
+}
+
+// SetRange sets FromDateTime and ToDateTime to from and to in Jakarta
+func (r *HistoryListRequest) SetRange(from, to time.Time) {
+	r.FromDateTime = formatTime(from)
+	r.ToDateTime = formatTime(to)
+}
+
+// From parses FromDateTime
+func (a *AdditionalInfoHistoryListResponse) From() (Time, error) {
+	return ParseTime(a.FromDateTime)
+}
+
+// To parses ToDateTime
+func (a *AdditionalInfoHistoryListResponse) To() (Time, error) {
+	return ParseTime(a.ToDateTime)
+}
+
+// Time parses DateTime
+func (d *DetailData) Time() (Time, error) {
+	return ParseTime(d.DateTime)
+}
+
+// TransactionDateTime parses TransactionDate
+func (r *CustomerTopupRequest) TransactionDateTime() (Time, error) {
+	return ParseTime(r.TransactionDate)
+}
+
+// SetTransactionDate sets TransactionDate to t in Jakarta
+func (r *CustomerTopupRequest) SetTransactionDate(t time.Time) {
+	r.TransactionDate = formatTime(t)
+}
+
+// InstructDateTime parses InstructDate
+func (a *AdditionalInfoCustomerTopupRequest) InstructDateTime() (Time, error) {
+	return ParseTime(a.InstructDate)
+}
+
+// SetInstructDate sets InstructDate to t in Jakarta; the zero time clears it
+func (a *AdditionalInfoCustomerTopupRequest) SetInstructDate(t time.Time) {
+	a.InstructDate = formatTime(t)
+}
+
+// TransactionDateTime parses TransactionDate
+func (a *AdditionalInfoCustomerTopup) TransactionDateTime() (Time, error) {
+	return ParseTime(a.TransactionDate)
+}
+
+// InstructDateTime parses InstructDate
+func (a *AdditionalInfoCustomerTopup) InstructDateTime() (Time, error) {
+	return ParseTime(a.InstructDate)
+}
+
+// TransactionDateTime parses TransactionDate
+func (a *AdditionalInfoTopupStatus) TransactionDateTime() (Time, error) {
+	return ParseTime(a.TransactionDate)
+}
+
+// TransactionStatusDateTime parses TransactionStatusDate
+func (a *AdditionalInfoTopupStatus) TransactionStatusDateTime() (Time, error) {
+	return ParseTime(a.TransactionStatusDate)
+}
+
+// TrxTime parses TrxDateTime; a value without offset is read in Jakarta
+func (r *BillPaymentRequest) TrxTime() (Time, error) {
+	return ParseTime(r.TrxDateTime)
+}
+
+// SetTrxDateTime sets TrxDateTime to t in Jakarta
+func (r *BillPaymentRequest) SetTrxDateTime(t time.Time) {
+	r.TrxDateTime = formatTime(t)
+}
+
+// InstructDateTime parses InstructDate
+func (a *AdditionalInfoBillPayment) InstructDateTime() (Time, error) {
+	return ParseTime(a.InstructDate)
+}
+
+// SetInstructDate sets InstructDate to t in Jakarta; the zero time clears it
+func (a *AdditionalInfoBillPayment) SetInstructDate(t time.Time) {
+	a.InstructDate = formatTime(t)
+}
+
+// TrxTime parses TrxDateTime; a value without offset is read in Jakarta
+func (v *VirtualAccountDataBillPayment) TrxTime() (Time, error) {
+	return ParseTime(v.TrxDateTime)
+}
+
+// InstructDateTime parses InstructDate
+func (a *AdditionalInfoBillPaymentResponse) InstructDateTime() (Time, error) {
+	return ParseTime(a.InstructDate)
+}
@@ -0,0 +1,114 @@
+package snap
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// TestParseTime tests the timestamp variants Faspay returns
+func TestParseTime(t *testing.T) {
+	expected := time.Date(2025, 6, 9, 10, 30, 3, 0, Jakarta)
+
+	for _, value := range []string{
+		"2025-06-09T10:30:03+07:00",
+		"2025-06-09T03:30:03Z",
+		"2025-06-09T10:30:03.000+07:00",
+		"2025-06-09T10:30:03+0700",
+		"2025-06-09 10:30:03+07:00",
+		"2025-06-09T10:30:03",
+		"2025-06-09 10:30:03",
+		" 2025-06-09T10:30:03+07:00 ",
+	} {
+		parsed, err := ParseTime(value)
+		if err != nil {
+			t.Errorf("ParseTime(%q) failed: %v", value, err)
+			continue
+		}
+		if !parsed.Equal(expected) {
+			t.Errorf("ParseTime(%q) = %v, expected %v", value, parsed, expected)
+		}
+	}
+
+	if parsed, err := ParseTime("2025-06-09"); err != nil || !parsed.Equal(time.Date(2025, 6, 9, 0, 0, 0, 0, Jakarta)) {
+		t.Errorf("Expected a date to be midnight in Jakarta, got %v, %v", parsed, err)
+	}
+	if parsed, err := ParseTime(""); err != nil || !parsed.IsZero() {
+		t.Errorf("Expected the zero Time for an empty value, got %v, %v", parsed, err)
+	}
+	for _, value := range []string{"09-06-2025", "2025-06-09T25:00:00+07:00", "yesterday"} {
+		if _, err := ParseTime(value); err == nil {
+			t.Errorf("Expected an error for %q", value)
+		}
+	}
+}
+
+// TestTimeFormat tests that Time marshals in the SNAP format in Jakarta
+func TestTimeFormat(t *testing.T) {
+	utc := time.Date(2025, 6, 9, 3, 30, 3, 999_000_000, time.UTC)
+	snapTime := NewTime(utc)
+	if snapTime.String() != "2025-06-09T10:30:03+07:00" {
+		t.Errorf("Unexpected format %q", snapTime.String())
+	}
+	if _, offset := Now().Zone(); offset != 7*60*60 {
+		t.Errorf("Expected Now in UTC+7, got offset %d", offset)
+	}
+
+	type event struct {
+		At    Time `json:"at"`
+		Empty Time `json:"empty"`
+	}
+	data, err := json.Marshal(event{At: snapTime})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"at":"2025-06-09T10:30:03+07:00","empty":""}` {
+		t.Errorf("Unexpected JSON %s", data)
+	}
+
+	var decoded event
+	if err := json.Unmarshal([]byte(`{"at":"2025-06-09 10:30:03","empty":null}`), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !decoded.At.Equal(snapTime.Time) || !decoded.Empty.IsZero() {
+		t.Errorf("Unexpected decoded value %+v", decoded)
+	}
+	if err := json.Unmarshal([]byte(`{"at":20250609}`), &decoded); err == nil {
+		t.Error("Expected an error for a number")
+	}
+}
+
+// TestTimeAccessors tests the typed accessors of string date fields
+func TestTimeAccessors(t *testing.T) {
+	at := time.Date(2025, 6, 9, 3, 30, 3, 0, time.UTC)
+
+	transfer := testTransferRequest()
+	transfer.SetTransactionDate(at)
+	transfer.AdditionalInfo = &AdditionalInfoTransferInterBank{InstructDate: "2025-06-09T10:30:03+07:00"}
+	transfer.AdditionalInfo.SetInstructDate(time.Time{})
+	if transfer.TransactionDate != "2025-06-09T10:30:03+07:00" || transfer.AdditionalInfo.InstructDate != "" {
+		t.Errorf("Unexpected dates %q and %q", transfer.TransactionDate, transfer.AdditionalInfo.InstructDate)
+	}
+	if err := transfer.Validate(); err != nil {
+		t.Errorf("Expected the request to stay valid, got %v", err)
+	}
+	if parsed, err := transfer.TransactionDateTime(); err != nil || !parsed.Equal(at) {
+		t.Errorf("TransactionDateTime = %v, %v", parsed, err)
+	}
+
+	history := &HistoryListRequest{}
+	history.SetRange(at, at.Add(24*time.Hour))
+	if history.FromDateTime != "2025-06-09T10:30:03+07:00" || history.ToDateTime != "2025-06-10T10:30:03+07:00" {
+		t.Errorf("Unexpected range %q - %q", history.FromDateTime, history.ToDateTime)
+	}
+
+	payment := &BillPaymentRequest{TrxDateTime: "2025-06-09T10:30:03"}
+	if parsed, err := payment.TrxTime(); err != nil || !parsed.Equal(at) {
+		t.Errorf("TrxTime = %v, %v", parsed, err)
+	}
+
+	entry := &DetailData{DateTime: "not a date"}
+	if _, err := entry.Time(); err == nil {
+		t.Error("Expected an error for an invalid dateTime")
+	}
+}
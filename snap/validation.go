@@ -0,0 +1,526 @@
+package snap
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// SNAP field limits enforced before a request is signed
+const (
+	maxPartnerReferenceNo     = 64
+	maxReferenceNo            = 64
+	maxBeneficiaryAccountNo   = 34
+	maxBeneficiaryAccountName = 100
+	maxBankCode               = 8
+	maxSourceAccountNo        = 19
+	maxAccountNo              = 16
+	maxCustomerNumber         = 20
+	maxEmail                  = 50
+	maxPartnerServiceID       = 8
+	maxCustomerNo             = 20
+	maxVirtualAccountNo       = 28
+	maxVirtualAccountName     = 255
+	maxAmountDigits           = 16
+)
+
+// localDateTimeLayout is the ISO-8601 layout without offset that Faspay accepts for trxDateTime
+const localDateTimeLayout = "2006-01-02T15:04:05"
+
+// FieldError describes one invalid field of a request
+type FieldError struct {
+	Field   string // JSON path of the field, e.g. "amount.value"
+	Message string // What is wrong with the field
+}
+
+// Error returns the error message
+func (e FieldError) Error() string {
+	if e.Field == "" {
+		return e.Message
+	}
+	return e.Field + ": " + e.Message
+}
+
+// ValidationError is returned when a request breaks the SNAP field rules. It lists
+// every invalid field so that all of them can be reported at once. The request is
+// never signed or sent.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+// Error returns the error message
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Fields))
+	for i, field := range e.Fields {
+		messages[i] = field.Error()
+	}
+	return "invalid request: " + strings.Join(messages, "; ")
+}
+
+// Is reports whether target is ErrInvalidRequest
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrInvalidRequest
+}
+
+// Field returns the errors reported for the given JSON path
+func (e *ValidationError) Field(path string) []FieldError {
+	var errs []FieldError
+	for _, field := range e.Fields {
+		if field.Field == path {
+			errs = append(errs, field)
+		}
+	}
+	return errs
+}
+
+// validator collects field errors while a request is checked
+type validator struct {
+	errs []FieldError
+}
+
+func (v *validator) add(field, format string, args ...any) {
+	v.errs = append(v.errs, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// merge adds the field errors of a *ValidationError returned by an earlier check,
+// so that every phase is reported in one error. Other errors are returned as is.
+func (v *validator) merge(err error) error {
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		v.errs = append(v.errs, validationErr.Fields...)
+		return nil
+	}
+	return err
+}
+
+// failed reports whether an error was recorded for field
+func (v *validator) failed(field string) bool {
+	return slices.ContainsFunc(v.errs, func(e FieldError) bool { return e.Field == field })
+}
+
+// err returns a *ValidationError when any field failed, nil otherwise
+func (v *validator) err() error {
+	if len(v.errs) == 0 {
+		return nil
+	}
+	return &ValidationError{Fields: v.errs}
+}
+
+// required reports whether value is set, recording an error when it is not
+func (v *validator) required(field, value string) bool {
+	if strings.TrimSpace(value) == "" {
+		v.add(field, "is required")
+		return false
+	}
+	return true
+}
+
+func (v *validator) maxLen(field, value string, max int) {
+	if n := utf8.RuneCountInString(value); n > max {
+		v.add(field, "must be at most %d characters, got %d", max, n)
+	}
+}
+
+func (v *validator) numeric(field, value string) {
+	if value != "" && !isDigits(value) {
+		v.add(field, "must contain digits only")
+	}
+}
+
+// amount checks that a is set, positive, has at most two decimals and is in IDR, and
+// reports whether it is valid
+func (v *validator) amount(field string, a *Amount) bool {
+	if a == nil {
+		v.add(field, "is required")
+		return false
+	}
+	before := len(v.errs)
+
+	if v.required(field+".value", a.Value) {
+		minor, err := parseMinorUnits(a.Value)
+		switch {
+		case err != nil:
+			v.add(field+".value", "must be a decimal with up to two decimals, e.g. \"10000.00\"")
+		case minor <= 0:
+			v.add(field+".value", "must be greater than zero")
+		case len(Money{minor: minor}.Value()) > maxAmountDigits+3:
+			v.add(field+".value", "must have at most %d digits before the decimal point", maxAmountDigits)
+		}
+	}
+
+	if v.required(field+".currency", a.Currency) && a.Currency != CurrencyIDR {
+		v.add(field+".currency", "must be %s, got %q", CurrencyIDR, a.Currency)
+	}
+	return len(v.errs) == before
+}
+
+// customerNumber checks that value is a mobile number platform can be topped up to,
+// in any format NormalizeCustomerNumber accepts
+func (v *validator) customerNumber(field, value string, platform EmoneyPlatform) {
+	if _, err := platform.NormalizeCustomerNumber(value); err != nil {
+		v.add(field, "must be a mobile number such as \"081234567890\"")
+	}
+}
+
+// platformAmount checks a valid amount against the top-up limits of platform
+func (v *validator) platformAmount(field string, a *Amount, platform EmoneyPlatform) {
+	money, err := a.Money()
+	if err != nil {
+		return
+	}
+	if err := platform.CheckAmount(money); err != nil {
+		v.add(field, "%s", err)
+	}
+}
+
+// timestamp checks that value is an ISO-8601 date and time with offset,
+// e.g. "2025-06-09T10:30:03+07:00", and returns the parsed time
+func (v *validator) timestamp(field, value string) (time.Time, bool) {
+	if value == "" {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		v.add(field, "must be an ISO-8601 date and time with offset, e.g. \"2025-06-09T10:30:03+07:00\"")
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// callbackURL checks that value, when set, is an absolute http or https URL
+func (v *validator) callbackURL(field, value string) {
+	if value == "" {
+		return
+	}
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		v.add(field, "must be an absolute http or https URL")
+	}
+}
+
+// emails checks that value, when set, is a comma separated list of email addresses
+func (v *validator) emails(field, value string) {
+	if value == "" {
+		return
+	}
+	for _, address := range strings.Split(value, ",") {
+		if _, err := mail.ParseAddress(strings.TrimSpace(address)); err != nil {
+			v.add(field, "%q is not a valid email address", strings.TrimSpace(address))
+		}
+	}
+}
+
+// bankCode checks a required numeric bank code
+func (v *validator) bankCode(field, value string) {
+	if v.required(field, value) {
+		v.maxLen(field, value, maxBankCode)
+		v.numeric(field, value)
+	}
+}
+
+// partnerReferenceNo checks a required partnerReferenceNo
+func (v *validator) partnerReferenceNo(field, value string) {
+	if v.required(field, value) {
+		v.maxLen(field, value, maxPartnerReferenceNo)
+	}
+}
+
+// originalReference checks the references of a status request, of which at least one is required
+func (v *validator) originalReference(partnerReferenceNo, referenceNo string) {
+	if partnerReferenceNo == "" && referenceNo == "" {
+		v.add("originalPartnerReferenceNo", "or originalReferenceNo is required")
+	}
+	v.maxLen("originalPartnerReferenceNo", partnerReferenceNo, maxPartnerReferenceNo)
+	v.maxLen("originalReferenceNo", referenceNo, maxReferenceNo)
+}
+
+// serviceCode checks a required 2-digit SNAP service code
+func (v *validator) serviceCode(value string) {
+	if v.required("serviceCode", value) && (len(value) != 2 || !isDigits(value)) {
+		v.add("serviceCode", "must be a 2-digit SNAP service code, e.g. \"18\"")
+	}
+}
+
+// missingRequest is the error returned when Validate is called on a nil request
+func missingRequest() error {
+	return &ValidationError{Fields: []FieldError{{Message: "request is required"}}}
+}
+
+// Validate checks the request against the SNAP field rules
+func (r *ExternalAccountInquiryRequest) Validate() error {
+	if r == nil {
+		return missingRequest()
+	}
+	var v validator
+	v.partnerReferenceNo("partnerReferenceNo", r.PartnerReferenceNo)
+	v.bankCode("beneficiaryBankCode", r.BeneficiaryBankCode)
+	if v.required("beneficiaryAccountNo", r.BeneficiaryAccountNo) {
+		v.maxLen("beneficiaryAccountNo", r.BeneficiaryAccountNo, maxBeneficiaryAccountNo)
+	}
+	if r.AdditionalInfo != nil {
+		v.maxLen("additionalInfo.sourceAccount", r.AdditionalInfo.SourceAccount, maxSourceAccountNo)
+	}
+	return v.err()
+}
+
+// Validate checks the request against the SNAP field rules
+func (r *TransferInterBankRequest) Validate() error {
+	if r == nil {
+		return missingRequest()
+	}
+	var v validator
+	v.partnerReferenceNo("partnerReferenceNo", r.PartnerReferenceNo)
+	v.amount("amount", r.Amount)
+	if v.required("beneficiaryAccountName", r.BeneficiaryAccountName) {
+		v.maxLen("beneficiaryAccountName", r.BeneficiaryAccountName, maxBeneficiaryAccountName)
+	}
+	if v.required("beneficiaryAccountNo", r.BeneficiaryAccountNo) {
+		v.maxLen("beneficiaryAccountNo", r.BeneficiaryAccountNo, maxBeneficiaryAccountNo)
+	}
+	v.bankCode("beneficiaryBankCode", r.BeneficiaryBankCode)
+	v.maxLen("beneficiaryEmail", r.BeneficiaryEmail, maxEmail)
+	v.emails("beneficiaryEmail", r.BeneficiaryEmail)
+	if v.required("sourceAccountNo", r.SourceAccountNo) {
+		v.maxLen("sourceAccountNo", r.SourceAccountNo, maxSourceAccountNo)
+	}
+	if v.required("transactionDate", r.TransactionDate) {
+		v.timestamp("transactionDate", r.TransactionDate)
+	}
+	if r.AdditionalInfo != nil {
+		v.timestamp("additionalInfo.instructDate", r.AdditionalInfo.InstructDate)
+		v.callbackURL("additionalInfo.callbackUrl", r.AdditionalInfo.CallbackUrl)
+	}
+	return v.err()
+}
+
+// Validate checks the request against the SNAP field rules
+func (r *StatusTransferRequest) Validate() error {
+	if r == nil {
+		return missingRequest()
+	}
+	var v validator
+	v.originalReference(r.OriginalPartnerReferenceNo, r.OriginalReferenceNo)
+	v.serviceCode(r.ServiceCode)
+	return v.err()
+}
+
+// Validate checks the request against the SNAP field rules
+func (r *InquiryBalanceRequest) Validate() error {
+	if r == nil {
+		return missingRequest()
+	}
+	var v validator
+	if v.required("accountNo", r.AccountNo) {
+		v.maxLen("accountNo", r.AccountNo, maxAccountNo)
+	}
+	return v.err()
+}
+
+// Validate checks the request against the SNAP field rules
+func (r *HistoryListRequest) Validate() error {
+	if r == nil {
+		return missingRequest()
+	}
+	var v validator
+	var from, to time.Time
+	var hasFrom, hasTo bool
+	if v.required("fromDateTime", r.FromDateTime) {
+		from, hasFrom = v.timestamp("fromDateTime", r.FromDateTime)
+	}
+	if v.required("toDateTime", r.ToDateTime) {
+		to, hasTo = v.timestamp("toDateTime", r.ToDateTime)
+	}
+	if hasFrom && hasTo && to.Before(from) {
+		v.add("toDateTime", "must not be before fromDateTime")
+	}
+	if r.AdditionalInfo == nil {
+		v.add("additionalInfo.accountNo", "is required")
+	} else if v.required("additionalInfo.accountNo", r.AdditionalInfo.AccountNo) {
+		v.maxLen("additionalInfo.accountNo", r.AdditionalInfo.AccountNo, maxAccountNo)
+	}
+	return v.err()
+}
+
+// Validate checks the request against the SNAP field rules
+func (r *CustomerTopupRequest) Validate() error {
+	if r == nil {
+		return missingRequest()
+	}
+	var v validator
+	v.partnerReferenceNo("partnerReferenceNo", r.PartnerReferenceNo)
+	if v.required("customerNumber", r.CustomerNumber) {
+		// Separators are removed when the number is normalized, so they do not count
+		v.maxLen("customerNumber", compactNumber(r.CustomerNumber), maxCustomerNumber)
+	}
+	v.amount("amount", r.Amount)
+	if v.required("transactionDate", r.TransactionDate) {
+		v.timestamp("transactionDate", r.TransactionDate)
+	}
+	if r.AdditionalInfo == nil {
+		v.add("additionalInfo.platformCode", "is required")
+	} else {
+		v.required("additionalInfo.platformCode", r.AdditionalInfo.PlatformCode)
+		v.maxLen("additionalInfo.sourceAccount", r.AdditionalInfo.SourceAccount, maxSourceAccountNo)
+		v.timestamp("additionalInfo.instructDate", r.AdditionalInfo.InstructDate)
+		v.emails("additionalInfo.beneficiaryEmail", r.AdditionalInfo.BeneficiaryEmail)
+		v.callbackURL("additionalInfo.callbackUrl", r.AdditionalInfo.CallbackUrl)
+	}
+	return v.err()
+}
+
+// Validate checks the request against the SNAP field rules
+func (r *CustomerTopupStatusRequest) Validate() error {
+	if r == nil {
+		return missingRequest()
+	}
+	var v validator
+	v.originalReference(r.OriginalPartnerReferenceNo, r.OriginalReferenceNo)
+	v.serviceCode(r.ServiceCode)
+	return v.err()
+}
+
+// virtualAccount checks the virtual account fields shared by bill inquiry and payment
+func (v *validator) virtualAccount(partnerServiceID, customerNo, virtualAccountNo string) {
+	if v.required("partnerServiceId", partnerServiceID) {
+		v.maxLen("partnerServiceId", partnerServiceID, maxPartnerServiceID)
+	}
+	if v.required("customerNo", customerNo) {
+		v.maxLen("customerNo", customerNo, maxCustomerNo)
+	}
+	if v.required("virtualAccountNo", virtualAccountNo) {
+		v.maxLen("virtualAccountNo", virtualAccountNo, maxVirtualAccountNo)
+	}
+}
+
+// Validate checks the request against the SNAP field rules
+func (r *BillInquiryRequest) Validate() error {
+	if r == nil {
+		return missingRequest()
+	}
+	var v validator
+	v.partnerReferenceNo("partnerReferenceNo", r.PartnerReferenceNo)
+	v.virtualAccount(r.PartnerServiceId, r.CustomerNo, r.VirtualAccountNo)
+	if r.AdditionalInfo != nil {
+		v.numeric("additionalInfo.billerCode", r.AdditionalInfo.BillerCode)
+		v.maxLen("additionalInfo.sourceAccount", r.AdditionalInfo.SourceAccount, maxSourceAccountNo)
+	}
+	return v.err()
+}
+
+// Validate checks the request against the SNAP field rules. trxDateTime may be sent
+// with or without offset.
+func (r *BillPaymentRequest) Validate() error {
+	if r == nil {
+		return missingRequest()
+	}
+	var v validator
+	v.partnerReferenceNo("partnerReferenceNo", r.PartnerReferenceNo)
+	v.virtualAccount(r.PartnerServiceId, r.CustomerNo, r.VirtualAccountNo)
+	v.maxLen("virtualAccountName", r.VirtualAccountName, maxVirtualAccountName)
+	if v.required("sourceAccount", r.SourceAccount) {
+		v.maxLen("sourceAccount", r.SourceAccount, maxSourceAccountNo)
+	}
+	v.amount("paidAmount", r.PaidAmount)
+	if v.required("trxDateTime", r.TrxDateTime) {
+		if _, err := time.Parse(localDateTimeLayout, r.TrxDateTime); err != nil {
+			v.timestamp("trxDateTime", r.TrxDateTime)
+		}
+	}
+	if r.AdditionalInfo != nil {
+		v.numeric("additionalInfo.billerCode", r.AdditionalInfo.BillerCode)
+		v.timestamp("additionalInfo.instructDate", r.AdditionalInfo.InstructDate)
+		v.callbackURL("additionalInfo.callbackUrl", r.AdditionalInfo.CallbackUrl)
+	}
+	return v.err()
+}
+
+// BankCodeValidator checks a beneficiary bank code, for example against the list in
+// the banks package. It returns an error describing why the code is not accepted.
+type BankCodeValidator func(code string) error
+
+// WithBankCodeValidator checks the beneficiaryBankCode of AccountInquiry and
+// TransferInterBank requests with check before they are signed. A rejected code is
+// reported as a ValidationError. Bank codes are only checked for format by default.
+func WithBankCodeValidator(check BankCodeValidator) ClientOption {
+	return func(c *Client) {
+		c.bankCodeValidator = check
+	}
+}
+
+// ValidateBankCode checks the request with Validate and the beneficiary bank code
+// with check, and reports the errors of both in one ValidationError
+func (r *ExternalAccountInquiryRequest) ValidateBankCode(check BankCodeValidator) error {
+	if r == nil {
+		return missingRequest()
+	}
+	var v validator
+	if err := v.merge(r.Validate()); err != nil {
+		return err
+	}
+	v.knownBankCode("beneficiaryBankCode", r.BeneficiaryBankCode, check)
+	return v.err()
+}
+
+// ValidateBankCode checks the request with Validate and the beneficiary bank code
+// with check, and reports the errors of both in one ValidationError
+func (r *TransferInterBankRequest) ValidateBankCode(check BankCodeValidator) error {
+	if r == nil {
+		return missingRequest()
+	}
+	var v validator
+	if err := v.merge(r.Validate()); err != nil {
+		return err
+	}
+	v.knownBankCode("beneficiaryBankCode", r.BeneficiaryBankCode, check)
+	return v.err()
+}
+
+// knownBankCode checks code with check, unless the field already failed the format rules
+func (v *validator) knownBankCode(field, code string, check BankCodeValidator) {
+	if check == nil || v.failed(field) {
+		return
+	}
+	if err := check(code); err != nil {
+		v.add(field, "%s", err)
+	}
+}
+
+// ValidatePlatform checks the request with Validate and the customer number and
+// amount against the platform of the top-up in registry, and reports the errors of
+// both in one ValidationError. DefaultPlatforms is used when registry is nil. The
+// customer number of a platform the registry does not list is sent as is, so only
+// its length is checked.
+func (r *CustomerTopupRequest) ValidatePlatform(registry *PlatformRegistry) error {
+	if r == nil {
+		return missingRequest()
+	}
+	var v validator
+	if err := v.merge(r.Validate()); err != nil {
+		return err
+	}
+	if r.AdditionalInfo == nil {
+		return v.err()
+	}
+	if registry == nil {
+		registry = DefaultPlatforms()
+	}
+	platform, ok := registry.Lookup(PlatformCode(r.AdditionalInfo.PlatformCode))
+	if !ok {
+		if !v.failed("customerNumber") {
+			v.maxLen("customerNumber", r.CustomerNumber, maxCustomerNumber)
+		}
+		return v.err()
+	}
+	if !v.failed("customerNumber") {
+		v.customerNumber("customerNumber", r.CustomerNumber, platform)
+	}
+	if !v.failed("amount.value") {
+		v.platformAmount("amount.value", r.Amount, platform)
+	}
+	return v.err()
+}
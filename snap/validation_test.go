@@ -0,0 +1,260 @@
+package snap
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// TestTransferInterBankRequestValidate tests the field rules of a transfer request
+func TestTransferInterBankRequestValidate(t *testing.T) {
+	if err := testTransferRequest().Validate(); err != nil {
+		t.Fatalf("Expected valid request, got %v", err)
+	}
+
+	request := &TransferInterBankRequest{
+		PartnerReferenceNo:   strings.Repeat("9", 65),
+		Amount:               &Amount{Value: "10,000.00", Currency: "USD"},
+		BeneficiaryAccountNo: "60004400184",
+		BeneficiaryBankCode:  "BCA",
+		BeneficiaryEmail:     "not-an-email",
+		SourceAccountNo:      "9920017573",
+		TransactionDate:      "2025-06-09 10:30:03",
+		AdditionalInfo:       &AdditionalInfoTransferInterBank{CallbackUrl: "callback"},
+	}
+
+	err := request.Validate()
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("Expected *ValidationError, got %T", err)
+	}
+	if !errors.Is(err, ErrInvalidRequest) || !IsValidationError(err) {
+		t.Error("Expected the error to match ErrInvalidRequest and IsValidationError")
+	}
+
+	for _, field := range []string{
+		"partnerReferenceNo",
+		"amount.value",
+		"amount.currency",
+		"beneficiaryAccountName",
+		"beneficiaryBankCode",
+		"beneficiaryEmail",
+		"transactionDate",
+		"additionalInfo.callbackUrl",
+	} {
+		if len(validationErr.Field(field)) == 0 {
+			t.Errorf("Expected an error for %s, got %v", field, err)
+		}
+	}
+	if len(validationErr.Field("sourceAccountNo")) != 0 {
+		t.Errorf("Expected no error for sourceAccountNo, got %v", validationErr.Field("sourceAccountNo"))
+	}
+}
+
+// TestRequestValidate tests the field rules of the other requests
+func TestRequestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		request interface{ Validate() error }
+		fields  []string
+	}{
+		{
+			name:    "StatusTransferMissingReference",
+			request: &StatusTransferRequest{ServiceCode: "18"},
+			fields:  []string{"originalPartnerReferenceNo"},
+		},
+		{
+			name:    "StatusTransferBadServiceCode",
+			request: &StatusTransferRequest{OriginalReferenceNo: "53883", ServiceCode: "transfer"},
+			fields:  []string{"serviceCode"},
+		},
+		{
+			name:    "InquiryBalanceMissingAccount",
+			request: &InquiryBalanceRequest{},
+			fields:  []string{"accountNo"},
+		},
+		{
+			name: "HistoryListReversedRange",
+			request: &HistoryListRequest{
+				FromDateTime:   "2024-12-30T00:00:00+07:00",
+				ToDateTime:     "2024-12-01T00:00:00+07:00",
+				AdditionalInfo: &AdditionalHistoryListRequest{AccountNo: "9920017573"},
+			},
+			fields: []string{"toDateTime"},
+		},
+		{
+			name: "CustomerTopupZeroAmount",
+			request: &CustomerTopupRequest{
+				PartnerReferenceNo: "20250609150352617",
+				CustomerNumber:     "0812254830",
+				Amount:             &Amount{Value: "0.00", Currency: "IDR"},
+				TransactionDate:    "2025-06-09T15:03:52+07:00",
+				AdditionalInfo:     &AdditionalInfoCustomerTopupRequest{},
+			},
+			fields: []string{"amount.value", "additionalInfo.platformCode"},
+		},
+		{
+			name:    "BillPaymentMissingFields",
+			request: &BillPaymentRequest{TrxDateTime: "09-06-2025"},
+			fields:  []string{"partnerReferenceNo", "partnerServiceId", "customerNo", "virtualAccountNo", "sourceAccount", "paidAmount", "trxDateTime"},
+		},
+		{
+			name:    "NilRequest",
+			request: (*BillInquiryRequest)(nil),
+			fields:  []string{""},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var validationErr *ValidationError
+			if !errors.As(tt.request.Validate(), &validationErr) {
+				t.Fatal("Expected *ValidationError")
+			}
+			for _, field := range tt.fields {
+				if len(validationErr.Field(field)) == 0 {
+					t.Errorf("Expected an error for %q, got %v", field, validationErr)
+				}
+			}
+		})
+	}
+
+	valid := []interface{ Validate() error }{
+		&CustomerTopupRequest{
+			PartnerReferenceNo: "20250609150352617",
+			CustomerNumber:     "0812254830",
+			Amount:             &Amount{Value: "76860.00", Currency: "IDR"},
+			TransactionDate:    "2025-06-09T15:03:52+07:00",
+			AdditionalInfo: &AdditionalInfoCustomerTopupRequest{
+				SourceAccount:    "9920017573",
+				PlatformCode:     "gpy",
+				BeneficiaryEmail: "aanfaspay2022@gmail.com,aan28setiawan@gmail.com",
+				CallbackUrl:      "https://example.com/v1/snap/callback",
+			},
+		},
+		&BillPaymentRequest{
+			PartnerReferenceNo: "20250609162921210",
+			PartnerServiceId:   "    7008",
+			CustomerNo:         "08000047816",
+			VirtualAccountNo:   "700808000047816",
+			SourceAccount:      "9920017573",
+			PaidAmount:         &Amount{Value: "41454.00", Currency: "IDR"},
+			TrxDateTime:        "2025-06-09T16:29:21",
+		},
+	}
+	for _, request := range valid {
+		if err := request.Validate(); err != nil {
+			t.Errorf("Expected %T to be valid, got %v", request, err)
+		}
+	}
+}
+
+// TestServiceValidatesBeforeSending tests that invalid requests never reach the API
+func TestServiceValidatesBeforeSending(t *testing.T) {
+	sent := false
+	mockHTTPClient := NewMockClient(func(req *http.Request) (*http.Response, error) {
+		sent = true
+		return MockTransferInterBankSuccessResponse(), nil
+	})
+
+	client, err := NewClient("99999", getTestPrivateKey(), nil, WithHTTPClient(mockHTTPClient))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	request := testTransferRequest()
+	request.Amount = nil
+	_, err = client.TransferInterBank(context.Background(), request)
+	if !errors.Is(err, ErrInvalidRequest) {
+		t.Fatalf("Expected ErrInvalidRequest, got %v", err)
+	}
+	if sent {
+		t.Error("Expected the request not to be sent")
+	}
+}
+
+// TestWithBankCodeValidator tests that a rejected bank code stops the request before it is sent
+func TestWithBankCodeValidator(t *testing.T) {
+	sent := 0
+	mockHTTPClient := NewMockClient(func(req *http.Request) (*http.Response, error) {
+		sent++
+		return MockTransferInterBankSuccessResponse(), nil
+	})
+
+	known := func(code string) error {
+		if code != "008" {
+			return errors.New("unknown bank code")
+		}
+		return nil
+	}
+	client, err := NewClient("99999", getTestPrivateKey(), nil, WithHTTPClient(mockHTTPClient), WithBankCodeValidator(known))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	request := testTransferRequest()
+	request.BeneficiaryBankCode = "999"
+	_, err = client.TransferInterBank(context.Background(), request)
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) || len(validationErr.Field("beneficiaryBankCode")) != 1 {
+		t.Fatalf("Expected a beneficiaryBankCode error, got %v", err)
+	}
+
+	_, err = client.AccountInquiry(context.Background(), &ExternalAccountInquiryRequest{
+		BeneficiaryBankCode:  "999",
+		BeneficiaryAccountNo: "60004400184",
+		PartnerReferenceNo:   "20250606234037372",
+	})
+	if !errors.Is(err, ErrInvalidRequest) {
+		t.Fatalf("Expected ErrInvalidRequest, got %v", err)
+	}
+	if sent != 0 {
+		t.Fatalf("Expected no request to be sent, got %d", sent)
+	}
+
+	request.BeneficiaryBankCode = "008"
+	if _, err := client.TransferInterBank(context.Background(), request); err != nil {
+		t.Fatalf("Expected a known bank code to be accepted, got %v", err)
+	}
+
+	request.BeneficiaryBankCode = "999"
+	request.Amount = nil
+	err = request.ValidateBankCode(known)
+	if !errors.As(err, &validationErr) || len(validationErr.Field("beneficiaryBankCode")) != 1 || len(validationErr.Field("amount")) != 1 {
+		t.Errorf("Expected amount and beneficiaryBankCode errors together, got %v", err)
+	}
+	if err := (*TransferInterBankRequest)(nil).ValidateBankCode(known); !errors.Is(err, ErrInvalidRequest) {
+		t.Errorf("Expected a nil request to be invalid, got %v", err)
+	}
+}
+
+// TestValidatePlatformPhases tests that platform errors are reported with the field
+// errors and that customer numbers are measured without separators
+func TestValidatePlatformPhases(t *testing.T) {
+	request := &CustomerTopupRequest{
+		PartnerReferenceNo: "20250609150352617",
+		CustomerNumber:     "+62 (812) 3456 - 7890",
+		Amount:             &Amount{Value: "50000.00", Currency: "IDR"},
+		TransactionDate:    "2025-06-09T15:03:52+07:00",
+		AdditionalInfo:     &AdditionalInfoCustomerTopupRequest{PlatformCode: string(PlatformOVO)},
+	}
+	if err := request.ValidatePlatform(nil); err != nil {
+		t.Fatalf("Expected a separated mobile number to be valid, got %v", err)
+	}
+
+	request.CustomerNumber = "0212345678"
+	request.TransactionDate = ""
+	var validationErr *ValidationError
+	if err := request.ValidatePlatform(nil); !errors.As(err, &validationErr) || len(validationErr.Field("customerNumber")) != 1 || len(validationErr.Field("transactionDate")) != 1 {
+		t.Errorf("Expected customerNumber and transactionDate errors together, got %v", err)
+	}
+
+	request.CustomerNumber = "+62 (812) 3456 - 7890"
+	request.TransactionDate = "2025-06-09T15:03:52+07:00"
+	request.AdditionalInfo.PlatformCode = "xyz"
+	if err := request.ValidatePlatform(nil); !errors.As(err, &validationErr) || len(validationErr.Field("customerNumber")) != 1 {
+		t.Errorf("Expected the number of an unlisted platform, sent as is, to be too long, got %v", err)
+	}
+}